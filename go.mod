@@ -3,8 +3,10 @@ module Bitcoin
 go 1.20
 
 require (
+	github.com/golang/snappy v0.0.4
 	github.com/peteprogrammer/go-automapper v0.0.0-20200419053654-7c63d5bb0eb4
 	github.com/syndtr/goleveldb v1.0.0
+	golang.org/x/crypto v0.15.0
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -12,7 +14,6 @@ require (
 
 require (
 	github.com/golang/protobuf v1.5.3 // indirect
-	github.com/golang/snappy v0.0.4 // indirect
 	github.com/stretchr/testify v1.8.4 // indirect
 	golang.org/x/net v0.18.0 // indirect
 	golang.org/x/sys v0.14.0 // indirect
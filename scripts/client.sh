@@ -1,3 +1,3 @@
 ROOT="$(dirname "$(dirname "$(readlink -fm "$0")")")"
 cd $(dirname "$0")
-go run $ROOT/src/client/main.go 
\ No newline at end of file
+go run $ROOT/src/client "$@" 
\ No newline at end of file
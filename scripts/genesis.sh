@@ -0,0 +1,3 @@
+ROOT="$(dirname "$(dirname "$(readlink -fm "$0")")")"
+cd $(dirname "$0")
+go run $ROOT/src/genesis/main.go "$@"
@@ -16,9 +16,20 @@ const (
 )
 
 type IBitcoinClient interface {
+	Handshake(req *protocol.HandshakeReq) (*protocol.HandshakeReply, error)
+	Inv(req *protocol.InvReq) (*protocol.InvReply, error)
+	GetData(req *protocol.GetDataReq) (*protocol.GetDataReply, error)
 	SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error)
 	SendBlock(req *protocol.BlockReq) (*protocol.BlockReply, error)
 	GetBlocks(req *protocol.GetBlocksReq) (*protocol.GetBlocksReply, error)
+	GetHeaders(req *protocol.GetHeadersReq) (*protocol.GetHeadersReply, error)
+	GetChainInfo(req *protocol.GetChainInfoReq) (*protocol.GetChainInfoReply, error)
+	GetBlockByHash(req *protocol.GetBlockByHashReq) (*protocol.BlockReq, error)
+	GetBlockByNumber(req *protocol.GetBlockByNumberReq) (*protocol.BlockReq, error)
+	GetTransaction(req *protocol.GetTransactionReq) (*protocol.GetTransactionReply, error)
+	GetBalance(req *protocol.GetBalanceReq) (*protocol.GetBalanceReply, error)
+	ListUnspent(req *protocol.ListUnspentReq) (*protocol.ListUnspentReply, error)
+	ListChainTips(req *protocol.ListChainTipsReq) (*protocol.ListChainTipsReply, error)
 }
 
 type BitcoinClient struct {
@@ -30,6 +41,39 @@ func NewBitcoinClient(addr string) IBitcoinClient {
 	return &BitcoinClient{addr: addr}
 }
 
+func (cli *BitcoinClient) Handshake(req *protocol.HandshakeReq) (*protocol.HandshakeReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewNodeClient(cli.conn)
+	return client.Handshake(ctx, req)
+}
+
+func (cli *BitcoinClient) Inv(req *protocol.InvReq) (*protocol.InvReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewNodeClient(cli.conn)
+	return client.Inv(ctx, req)
+}
+
+func (cli *BitcoinClient) GetData(req *protocol.GetDataReq) (*protocol.GetDataReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewNodeClient(cli.conn)
+	return client.GetData(ctx, req)
+}
+
 func (cli *BitcoinClient) SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
 	ctx, cancel, err := cli.prepare()
 	if err != nil {
@@ -63,6 +107,94 @@ func (cli *BitcoinClient) GetBlocks(req *protocol.GetBlocksReq) (*protocol.GetBl
 	return client.GetBlocks(ctx, req)
 }
 
+func (cli *BitcoinClient) GetHeaders(req *protocol.GetHeadersReq) (*protocol.GetHeadersReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewBlockClient(cli.conn)
+	return client.GetHeaders(ctx, req)
+}
+
+func (cli *BitcoinClient) GetChainInfo(req *protocol.GetChainInfoReq) (*protocol.GetChainInfoReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.GetChainInfo(ctx, req)
+}
+
+func (cli *BitcoinClient) GetBlockByHash(req *protocol.GetBlockByHashReq) (*protocol.BlockReq, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.GetBlockByHash(ctx, req)
+}
+
+func (cli *BitcoinClient) GetBlockByNumber(req *protocol.GetBlockByNumberReq) (*protocol.BlockReq, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.GetBlockByNumber(ctx, req)
+}
+
+func (cli *BitcoinClient) GetTransaction(req *protocol.GetTransactionReq) (*protocol.GetTransactionReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.GetTransaction(ctx, req)
+}
+
+func (cli *BitcoinClient) GetBalance(req *protocol.GetBalanceReq) (*protocol.GetBalanceReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.GetBalance(ctx, req)
+}
+
+func (cli *BitcoinClient) ListUnspent(req *protocol.ListUnspentReq) (*protocol.ListUnspentReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.ListUnspent(ctx, req)
+}
+
+func (cli *BitcoinClient) ListChainTips(req *protocol.ListChainTipsReq) (*protocol.ListChainTipsReply, error) {
+	ctx, cancel, err := cli.prepare()
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	client := protocol.NewQueryClient(cli.conn)
+	return client.ListChainTips(ctx, req)
+}
+
 func (cli *BitcoinClient) prepare() (context.Context, context.CancelFunc, error) {
 	if cli.conn == nil || cli.conn.GetState() == connectivity.Shutdown {
 		// Set up a connection to the server.
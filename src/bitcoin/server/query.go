@@ -0,0 +1,137 @@
+package server
+
+import (
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"Bitcoin/src/protocol"
+	"bytes"
+	"context"
+)
+
+func (s *BitcoinServer) GetChainInfo(ctx context.Context, request *protocol.GetChainInfoReq) (*protocol.GetChainInfoReply, error) {
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+
+	block, err := s.blockService.GetBlock(mainChain.LastBlockHash, false)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+
+	reply := &protocol.GetChainInfoReply{
+		Number:    block.Number,
+		Hash:      block.Hash,
+		Bits:      block.Bits,
+		Timestamp: block.Time.UnixMilli(),
+		Tips:      uint32(s.chainService.ChainLen()),
+	}
+	return reply, nil
+}
+
+func (s *BitcoinServer) GetBlockByHash(ctx context.Context, request *protocol.GetBlockByHashReq) (*protocol.BlockReq, error) {
+	block, err := s.blockService.GetBlock(request.Hash, true)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+	return model.BlockTo(block)
+}
+
+func (s *BitcoinServer) GetBlockByNumber(ctx context.Context, request *protocol.GetBlockByNumberReq) (*protocol.BlockReq, error) {
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+
+	block, err := s.blockService.GetBlockByNumber(mainChain.LastBlockHash, request.Number, true)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+	return model.BlockTo(block)
+}
+
+func (s *BitcoinServer) GetTransaction(ctx context.Context, request *protocol.GetTransactionReq) (*protocol.GetTransactionReply, error) {
+	tx, err := s.blockService.GetTx(request.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errors.ErrTxNotFound
+	}
+
+	reply := &protocol.GetTransactionReply{
+		Transaction: model.TransactionTo(tx),
+		BlockHash:   tx.BlockHash,
+	}
+	return reply, nil
+}
+
+func (s *BitcoinServer) GetBalance(ctx context.Context, request *protocol.GetBalanceReq) (*protocol.GetBalanceReply, error) {
+	// the utxo is changed when adding blocks
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	address, err := cryptography.ParseNetworkAddress(request.Address, s.cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, n := s.chainService.GetBalance(address.Hash)
+	return &protocol.GetBalanceReply{Balance: balance, Outs: uint32(n)}, nil
+}
+
+func (s *BitcoinServer) ListUnspent(ctx context.Context, request *protocol.ListUnspentReq) (*protocol.ListUnspentReply, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	address, err := cryptography.ParseNetworkAddress(request.Address, s.cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	utxo := s.chainService.ListUnspent(address.Hash)
+
+	outs := make([]*protocol.UnspentOut, 0, len(utxo))
+	for key, out := range utxo {
+		point, err := model.ParseOutPoint(key)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, &protocol.UnspentOut{Hash: point.Hash, Index: point.Index, Value: out.Value})
+	}
+	return &protocol.ListUnspentReply{Outs: outs}, nil
+}
+
+func (s *BitcoinServer) ListChainTips(ctx context.Context, request *protocol.ListChainTipsReq) (*protocol.ListChainTipsReply, error) {
+	var mainTip []byte
+	if mainChain := s.chainService.GetMainChain(); mainChain != nil {
+		mainTip = mainChain.LastBlockHash
+	}
+	chainTips, err := s.blockService.ListChainTips(mainTip)
+	if err != nil {
+		return nil, err
+	}
+
+	tips := make([]*protocol.ChainTip, len(chainTips))
+	for i, tip := range chainTips {
+		tips[i] = &protocol.ChainTip{
+			Hash:      tip.Hash,
+			Length:    tip.Number,
+			Main:      bytes.Equal(tip.Hash, mainTip),
+			Work:      tip.Work.Bytes(),
+			Status:    tip.Status,
+			BranchLen: tip.BranchLen,
+		}
+	}
+	return &protocol.ListChainTipsReply{Tips: tips}, nil
+}
@@ -4,24 +4,31 @@ import (
 	"Bitcoin/src/config"
 	"Bitcoin/src/database"
 	"Bitcoin/src/errors"
+	"Bitcoin/src/infra"
 	"Bitcoin/src/model"
 	"Bitcoin/src/protocol"
 	"Bitcoin/src/service"
+	"bytes"
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"sync"
+
+	"google.golang.org/grpc/peer"
 )
 
 const (
 	TxBroadcastQueueSize    = 10000
 	BlockBroadcastQueueSize = 10000
 	PullBlockQueueSize      = 100
-	BlocksPerSave           = 100
 )
 
 type BitcoinServer struct {
 	protocol.TransactionServer
 	protocol.BlockServer
+	protocol.QueryServer
+	protocol.NodeServer
 	cfg                 *config.Config
 	nodeService         *service.NodeService
 	chainService        *service.ChainService
@@ -30,6 +37,8 @@ type BitcoinServer struct {
 	syncService         *service.SyncService
 	mineService         *service.MineService
 	mempool             *service.MemPool
+	orphanPool          *service.OrphanPool[*model.Block]
+	orphanTxPool        *service.OrphanPool[*model.Transaction]
 	txBroadcastQueue    chan *model.Transaction
 	blockBroadcastQueue chan *model.Block
 	syncBlockQueue      chan string
@@ -37,19 +46,23 @@ type BitcoinServer struct {
 	ctx                 context.Context
 	cancelFunc          context.CancelCauseFunc
 	exiting             bool
+	lock                sync.Mutex
 }
 
-func NewBitcoinServer(cfg *config.Config, blockdb database.IBlockDB) (*BitcoinServer, error) {
+// NewBitcoinServer loads the chain from the saved utxo, or rebuilds it from the saved blocks with reindex
+func NewBitcoinServer(cfg *config.Config, blockdb database.IBlockDB, reindex bool) (*BitcoinServer, error) {
 	ctx, cancelFunc := context.WithCancelCause(context.Background())
 
-	utxo := make(map[string]uint64)
+	utxo := make(map[string]*model.Out)
 	server := &BitcoinServer{
 		cfg:                 cfg,
 		nodeService:         service.NewNodeService(cfg.Endpoint, cfg.Bootstraps),
-		chainService:        service.NewChainService(utxo),
+		chainService:        service.NewChainService(blockdb, utxo),
 		txService:           service.NewTransactionService(blockdb, utxo),
-		blockService:        service.NewBlockService(blockdb),
+		blockService:        service.NewBlockService(cfg, blockdb),
 		mempool:             service.NewMemPool(int(cfg.MaxTxSizePerBlock)),
+		orphanPool:          service.NewOrphanBlockPool(),
+		orphanTxPool:        service.NewOrphanTxPool(),
 		txBroadcastQueue:    make(chan *model.Transaction, TxBroadcastQueueSize),
 		blockBroadcastQueue: make(chan *model.Block, BlockBroadcastQueueSize),
 		syncBlockQueue:      make(chan string, PullBlockQueueSize),
@@ -57,36 +70,29 @@ func NewBitcoinServer(cfg *config.Config, blockdb database.IBlockDB) (*BitcoinSe
 		ctx:                 ctx,
 		cancelFunc:          cancelFunc,
 	}
-	server.syncService = service.NewSyncService(server.chainService, server.nodeService, server.addBlock)
+	server.syncService = service.NewSyncService(server.chainService, server.nodeService, server.blockService, server.orphanPool, server.connectBlock)
 	server.mineService = service.NewMineService(cfg, server.txService, server.mempool)
 
-	if err := server.load(); err != nil {
+	if err := server.load(reindex); err != nil {
 		return nil, err
 	}
 
 	return server, nil
 }
 
+// AddTx accepts the transaction from a handshaked peer, or from the client on the same host without the node
 func (s *BitcoinServer) AddTx(ctx context.Context, request *protocol.TransactionReq) (*protocol.TransactionReply, error) {
+	if request.Node != "" || !isLocal(ctx) {
+		if err := s.checkPeer(ctx, request.Node); err != nil {
+			return &protocol.TransactionReply{Result: false}, err
+		}
+	}
 	tx := model.TransactionFrom(request)
 
 	log.Printf("received transaction: %x", tx.Hash)
-	f := func(hash []byte) *model.Transaction {
-		return s.mempool.Get(hash)
-	}
-
-	if err := s.txService.ValidateTx(tx, f); err != nil {
-		log.Printf("validate transaction %x failed: %v", tx.Hash, err)
+	if err := s.receiveTx(tx, request.Node); err != nil {
 		return &protocol.TransactionReply{Result: false}, err
 	}
-	log.Printf("validated transaction: %x", tx.Hash)
-
-	s.mempool.Put(tx)
-	log.Printf("puted transaction on mempool: %x", tx.Hash)
-
-	s.txBroadcastQueue <- tx
-
-	log.Printf("broadcast the transaction: %x", tx.Hash)
 
 	if err := s.nodeService.AddAddrs(request.Nodes); err != nil {
 		log.Printf("add nodes failed: %v", err)
@@ -98,30 +104,186 @@ func (s *BitcoinServer) AddTx(ctx context.Context, request *protocol.Transaction
 }
 
 func (s *BitcoinServer) NewBlock(ctx context.Context, request *protocol.BlockReq) (*protocol.BlockReply, error) {
+	if err := s.checkPeer(ctx, request.Node); err != nil {
+		return &protocol.BlockReply{Result: false}, err
+	}
+
 	block, err := model.BlockFrom(request)
 	if err != nil {
 		return &protocol.BlockReply{Result: false}, err
 	}
 	log.Printf("received block: %x", block.Hash)
 
-	err = s.addBlock(block)
-	//if the prev block doesn't exist, then maybe we fall behind with current chain or a new main chain show up,
-	// so we need sync with the request node
-	if err == errors.ErrPrevBlockNotFound {
-		if len(s.syncBlockQueue) < PullBlockQueueSize {
-			s.syncBlockQueue <- request.Node
+	if err := s.receiveBlock(block, request.Node); err != nil {
+		return &protocol.BlockReply{Result: false}, err
+	}
+
+	return &protocol.BlockReply{Result: true}, nil
+}
+
+// Inv handles the announcement from the peer, the blocks and transactions not seen yet are fetched from it
+func (s *BitcoinServer) Inv(ctx context.Context, request *protocol.InvReq) (*protocol.InvReply, error) {
+	if err := s.checkPeer(ctx, request.Node); err != nil {
+		return &protocol.InvReply{Result: false}, err
+	}
+
+	hashes := make([][]byte, len(request.Items))
+	for i, item := range request.Items {
+		hashes[i] = item.Hash
+	}
+	s.nodeService.MarkKnown(request.Node, hashes...)
+
+	if err := s.nodeService.AddAddrs(request.Nodes); err != nil {
+		log.Printf("add nodes failed: %v", err)
+	}
+
+	wanted := make([]*protocol.InvItem, 0, len(request.Items))
+	for _, item := range request.Items {
+		has, err := s.hasItem(item)
+		if err != nil {
+			return &protocol.InvReply{Result: false}, err
+		}
+		if !has && s.nodeService.MarkSeen(item.Hash) {
+			wanted = append(wanted, item)
 		}
 	}
+
+	if len(wanted) > 0 {
+		go s.fetchData(request.Node, wanted)
+	}
+	return &protocol.InvReply{Result: true}, nil
+}
+
+// GetData replies the blocks and the transactions on the mempool, the unknown ones are skipped
+func (s *BitcoinServer) GetData(ctx context.Context, request *protocol.GetDataReq) (*protocol.GetDataReply, error) {
+	if err := s.checkPeer(ctx, request.Node); err != nil {
+		return nil, err
+	}
+
+	reply := &protocol.GetDataReply{}
+	for _, item := range request.Items {
+		switch item.Type {
+		case protocol.InvType_INV_TX:
+			s.lock.Lock()
+			tx := s.mempool.Get(item.Hash)
+			s.lock.Unlock()
+			if tx != nil {
+				reply.Txs = append(reply.Txs, model.TransactionTo(tx))
+			}
+		case protocol.InvType_INV_BLOCK:
+			block, err := s.blockService.GetBlock(item.Hash, true)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				continue
+			}
+			blockReq, err := model.BlockTo(block)
+			if err != nil {
+				return nil, err
+			}
+			reply.Blocks = append(reply.Blocks, blockReq)
+		}
+	}
+	return reply, nil
+}
+
+func (s *BitcoinServer) Handshake(ctx context.Context, request *protocol.HandshakeReq) (*protocol.HandshakeReply, error) {
+	handshake := model.HandshakeFrom(request)
+	if handshake.Addr != "" && !fromAddr(ctx, handshake.Addr) {
+		log.Printf("refused handshake from %v, not the address of the connection", handshake.Addr)
+		return nil, errors.ErrPeerAddrMismatch
+	}
+
+	local, err := s.nodeService.AcceptHandshake(handshake)
 	if err != nil {
-		return &protocol.BlockReply{Result: false}, err
+		log.Printf("refused handshake from %v: %v", handshake.Addr, err)
+		return nil, err
 	}
+	log.Printf("accepted handshake from %v, height: %d", handshake.Addr, handshake.Height)
 
-	return &protocol.BlockReply{Result: true}, nil
+	if handshake.Height > local.Height && len(s.syncBlockQueue) < PullBlockQueueSize {
+		s.syncBlockQueue <- handshake.Addr
+	}
+	return model.HandshakeToReply(local), nil
+}
+
+// checkPeer refuses the calls from the peer which has not finished the handshake,
+// or claims the address of another peer
+func (s *BitcoinServer) checkPeer(ctx context.Context, addr string) error {
+	if !fromAddr(ctx, addr) {
+		log.Printf("refused the call from %v, not the address of the connection", addr)
+		return errors.ErrPeerAddrMismatch
+	}
+	if !s.nodeService.IsHandshaked(addr) {
+		log.Printf("refused the call from %v, not handshaked", addr)
+		return errors.ErrPeerNotHandshaked
+	}
+	return nil
+}
+
+// isLocal reports whether the call comes from the same host
+func isLocal(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	addr, ok := p.Addr.(*net.TCPAddr)
+	return ok && addr.IP.IsLoopback()
+}
+
+// fromAddr reports whether the call comes from the host of the address, the host name is resolved
+func fromAddr(ctx context.Context, addr string) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	remote, ok := p.Addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip.Equal(remote.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandshakeNodes handshakes with the bootstraps and syncs if any of them is higher
+func (s *BitcoinServer) HandshakeNodes() {
+	s.nodeService.HandshakeNodes()
+
+	best := s.nodeService.BestNode(s.height())
+	if best != nil && len(s.syncBlockQueue) < PullBlockQueueSize {
+		s.syncBlockQueue <- best.Addr
+	}
+}
+
+func (s *BitcoinServer) height() uint64 {
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil {
+		return 0
+	}
+	return mainChain.Length
 }
 
 func (s *BitcoinServer) GetBlocks(ctx context.Context, request *protocol.GetBlocksReq) (*protocol.GetBlocksReply, error) {
+	if err := s.checkPeer(ctx, request.Node); err != nil {
+		return &protocol.GetBlocksReply{}, err
+	}
+
 	mainChain := s.chainService.GetMainChain()
-	blocks, end, err := s.blockService.GetBlocks(mainChain.LastBlockHash, request.Blockhashes, []context.Context{ctx, s.ctx})
+	blocks, end, err := s.blockService.GetBlocks(mainChain.LastBlockHash, request.Blockhashes, []context.Context{ctx})
 	if blocks == nil || err != nil {
 		return &protocol.GetBlocksReply{}, err
 	}
@@ -141,9 +303,27 @@ func (s *BitcoinServer) GetBlocks(ctx context.Context, request *protocol.GetBloc
 	return reply, nil
 }
 
+func (s *BitcoinServer) GetHeaders(ctx context.Context, request *protocol.GetHeadersReq) (*protocol.GetHeadersReply, error) {
+	if err := s.checkPeer(ctx, request.Node); err != nil {
+		return &protocol.GetHeadersReply{}, err
+	}
+
+	mainChain := s.chainService.GetMainChain()
+	headers, err := s.blockService.GetHeaders(mainChain.LastBlockHash, request.Locator, []context.Context{ctx})
+	if err != nil {
+		return &protocol.GetHeadersReply{}, err
+	}
+
+	reply := &protocol.GetHeadersReply{Headers: make([]*protocol.BlockReq, len(headers))}
+	for i, header := range headers {
+		reply.Headers[i] = model.HeaderTo(header)
+	}
+	return reply, nil
+}
+
 func (s *BitcoinServer) BroadcastTx() {
 	for tx := range s.txBroadcastQueue {
-		s.nodeService.SendTx(tx)
+		s.nodeService.AnnounceTx(tx)
 
 		if s.exiting {
 			s.exitChan <- "BroadcastTx"
@@ -154,7 +334,7 @@ func (s *BitcoinServer) BroadcastTx() {
 
 func (s *BitcoinServer) BroadcastBlock() {
 	for block := range s.blockBroadcastQueue {
-		s.nodeService.SendBlock(block)
+		s.nodeService.AnnounceBlock(block)
 
 		if s.exiting {
 			s.exitChan <- "BroadcastBlock"
@@ -169,7 +349,8 @@ func (s *BitcoinServer) SyncBlocks(wait *sync.WaitGroup) {
 		s.cancelFunc(errors.ErrServerCancelMining)
 		// pending the mining task
 		wait.Add(1)
-		s.syncService.SyncBlocks(addr)
+		log.Printf("sync triggered by %v", addr)
+		s.syncService.Sync()
 		wait.Done()
 
 		if s.exiting {
@@ -198,13 +379,6 @@ func (s *BitcoinServer) MineBlock(wait *sync.WaitGroup) {
 			log.Printf("add block error: %v", err)
 			continue
 		}
-
-		if block.Number%BlocksPerSave == 0 {
-			if err := s.chainService.Save(s.cfg.DataDir); err != nil {
-				log.Printf("chain service save error: %v", err)
-				continue
-			}
-		}
 	}
 	s.exitChan <- "MineBlock"
 }
@@ -218,100 +392,512 @@ func (s *BitcoinServer) Shutdown() error {
 		log.Printf("%s exited", component)
 	}
 
-	if err := s.mempool.Save(s.cfg.DataDir); err != nil {
+	// the memory engine runs without the data dir, the mempool is not kept then
+	if s.cfg.DataDir == "" {
+		return nil
+	}
+	return s.mempool.Save(s.cfg.DataDir)
+}
+
+func (s *BitcoinServer) load(reindex bool) error {
+	genesis, err := s.blockService.TryAddGenesis(s.cfg.Genesis)
+	if err != nil {
+		return err
+	}
+	s.nodeService.SetIdentity(s.cfg.Network, genesis.Hash, s.height)
+
+	if s.cfg.DataDir != "" {
+		if err := s.mempool.Load(s.cfg.DataDir); err != nil {
+			return err
+		}
+	}
+
+	if reindex {
+		return s.reindex(genesis)
+	}
+
+	tip, err := s.chainService.Load()
+	if err != nil {
+		return err
+	}
+
+	// the tips are derived from the block index, the utxo is brought to the main tip
+	chains, err := s.blockService.ChainTips()
+	if err != nil {
+		return err
+	}
+	s.chainService.SetChains(chains)
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil || bytes.Equal(tip, mainChain.LastBlockHash) {
+		return nil
+	}
+
+	if tip != nil {
+		err := s.switchChain(&model.Chain{LastBlockHash: tip})
+		if err == nil {
+			return nil
+		}
+		log.Printf("utxo tip %x is unknown: %v", tip, err)
+	}
+
+	// a fresh node or an unknown tip applies the main chain from the genesis block
+	return s.rebuildUtxo(genesis)
+}
+
+// reindex rebuilds the block index, the chain tips and the utxo only from the saved blocks
+func (s *BitcoinServer) reindex(genesis *model.Block) error {
+	// the utxo can't be rebuilt without the pruned blocks
+	pruned, err := s.blockService.GetPruned()
+	if err != nil {
 		return err
 	}
+	if pruned > 0 {
+		return errors.ErrBlockPruned
+	}
 
-	if err := s.chainService.Save(s.cfg.DataDir); err != nil {
+	log.Printf("reindexing the blocks")
+	if err := s.blockService.Reindex(); err != nil {
 		return err
 	}
+	chains, err := s.blockService.ChainTips()
+	if err != nil {
+		return err
+	}
+	s.chainService.SetChains(chains)
 
+	if err := s.rebuildUtxo(genesis); err != nil {
+		return err
+	}
+	mainChain := s.chainService.GetMainChain()
+	log.Printf("reindexed %d chains, the main chain is at %d: %x", len(chains), mainChain.Length, mainChain.LastBlockHash)
 	return nil
 }
 
-func (s *BitcoinServer) load() error {
-	if err := s.blockService.TryAddGenesis(s.cfg.DataDir, s.cfg.InitDifficultyLevel); err != nil {
+// VerifyChain validates the last n blocks of the main chain again, it returns the first inconsistency found
+func (s *BitcoinServer) VerifyChain(n uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil {
+		return nil
+	}
+
+	hash := mainChain.LastBlockHash
+	var verified uint64
+	for ; verified < n; verified++ {
+		block, err := s.blockService.GetBlock(hash, true)
+		// the pruned blocks are too deep to verify
+		if err == errors.ErrBlockPruned {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %x of the main chain: %w", hash, errors.ErrBlockNotFound)
+		}
+		// the genesis block is checked against its specification on loading
+		if len(block.Prevhash) == 0 {
+			break
+		}
+
+		if err := s.verifyBlock(block); err != nil {
+			return fmt.Errorf("block %d %x: %w", block.Number, block.Hash, err)
+		}
+		hash = block.Prevhash
+	}
+	log.Printf("verified the last %d blocks of the main chain", verified)
+	return nil
+}
+
+func (s *BitcoinServer) verifyBlock(block *model.Block) error {
+	if err := s.blockService.VerifyBlock(block); err != nil {
 		return err
 	}
+	reward, err := s.blockService.GetReward(block)
+	if err != nil {
+		return err
+	}
+	return s.txService.VerifyOnChainTxs(block.GetTxs(), block.Hash, reward)
+}
+
+// rebuildUtxo clears the utxo and applies the main chain from the genesis block
+func (s *BitcoinServer) rebuildUtxo(genesis *model.Block) error {
+	if err := s.chainService.Reset(); err != nil {
+		return err
+	}
+	if err := s.chainService.ApplyBlock(genesis); err != nil {
+		return err
+	}
+	return s.switchChain(&model.Chain{LastBlockHash: genesis.Hash})
+}
+
+// switchChain brings the utxo from the chain it is at to the main chain. The blocks of the main chain are checked
+// against the utxo at the fork point before any of them is applied, since the blocks on a side chain are saved
+// without the check. The block failing it is marked invalid, and the main chain is chosen again without it
+func (s *BitcoinServer) switchChain(from *model.Chain) error {
+	for {
+		mainChain := s.chainService.GetMainChain()
+		if mainChain == nil || bytes.Equal(mainChain.LastBlockHash, from.LastBlockHash) {
+			return nil
+		}
+
+		applyBlocks, rollbackBlocks, err := s.blockService.GetBlocksOfChain(mainChain, from)
+		if err != nil {
+			return err
+		}
+		invalid, err := s.chainService.ValidateBlocks(rollbackBlocks, applyBlocks)
+		if err == nil {
+			return s.chainService.SwitchBlocks(rollbackBlocks, applyBlocks)
+		}
+
+		log.Printf("switch to chain %x failed at block %x: %v", mainChain.LastBlockHash, invalid.Hash, err)
+		s.markInvalid(invalid, err)
+		if !service.IsConsensusErr(err) {
+			return err
+		}
+
+		chains, err := s.blockService.ChainTips()
+		if err != nil {
+			return err
+		}
+		s.chainService.SetChains(chains)
+	}
+}
 
-	if err := s.mempool.Load(s.cfg.DataDir); err != nil {
+// acceptTx puts the valid transaction on the mempool and queues it to announce,
+// the transaction already on the mempool is not announced again
+func (s *BitcoinServer) acceptTx(tx *model.Transaction) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.mempool.Get(tx.Hash) != nil {
+		return nil
+	}
+
+	f := func(hash []byte) *model.Transaction {
+		return s.mempool.Get(hash)
+	}
+	if err := s.txService.ValidateTx(tx, f); err != nil {
+		log.Printf("validate transaction %x failed: %v", tx.Hash, err)
 		return err
 	}
+	if s.mempool.Conflict(tx) {
+		log.Printf("transaction %x conflicts with the mempool", tx.Hash)
+		return errors.ErrInDoubleSpend
+	}
+	log.Printf("validated transaction: %x", tx.Hash)
+
+	s.mempool.Put(tx)
+	log.Printf("puted transaction on mempool: %x", tx.Hash)
+
+	s.nodeService.MarkSeen(tx.Hash)
+	s.txBroadcastQueue <- tx
+	return nil
+}
 
-	chains, err := s.chainService.Load(s.cfg.DataDir)
+// receiveTx accepts the transaction from the peer, the orphan is kept and its missing parents are fetched from the peer,
+// the orphans spending the accepted transaction are validated again
+func (s *BitcoinServer) receiveTx(tx *model.Transaction, addr string) error {
+	err := s.acceptTx(tx)
+	if err == errors.ErrPrevTxNotFound {
+		if s.orphanTxPool.Put(tx, addr) {
+			log.Printf("transaction %x from %v is an orphan", tx.Hash, addr)
+			s.fetchParents(tx, addr)
+		}
+		return err
+	}
 	if err != nil {
 		return err
 	}
 
-	for _, chain := range chains {
-		blockHashes := [][]byte{chain.LastBlockHash}
-		for len(blockHashes) > 0 {
-			blockHash := blockHashes[0]
-			blockHashes = blockHashes[1:]
-			blocks, err := s.blockService.FilterBlock(blockHash)
-			if err != nil {
-				return err
+	s.acceptOrphanTxs(tx.Hash)
+	return nil
+}
+
+// acceptOrphanTxs accepts the orphans spending the outputs of the parents and their descendants,
+// the ones still missing a parent are kept
+func (s *BitcoinServer) acceptOrphanTxs(parents ...[]byte) {
+	for len(parents) > 0 {
+		for _, orphan := range s.orphanTxPool.PopChildren(parents[0]) {
+			err := s.acceptTx(orphan.Item)
+			if err == errors.ErrPrevTxNotFound {
+				s.orphanTxPool.Put(orphan.Item, orphan.Addr)
+				continue
 			}
-			for _, block := range blocks {
-				//TODO: better apply algorithm, only apply the utxo once, no need rollback
-				s.applyBlock(block)
-				blockHashes = append(blockHashes, block.Hash)
+			if err != nil {
+				log.Printf("accept orphan transaction %x failed: %v", orphan.Item.Hash, err)
+				continue
 			}
+			log.Printf("accepted orphan transaction: %x", orphan.Item.Hash)
+			parents = append(parents, orphan.Item.Hash)
+		}
+		parents = parents[1:]
+	}
+}
+
+// fetchParents fetches the parents of the orphan not stored or being fetched from the peer
+func (s *BitcoinServer) fetchParents(tx *model.Transaction, addr string) {
+	if addr == "" {
+		return
+	}
+
+	items := make([]*protocol.InvItem, 0, len(tx.Ins))
+	for _, in := range tx.Ins {
+		item := &protocol.InvItem{Type: protocol.InvType_INV_TX, Hash: in.PrevHash}
+		has, err := s.hasItem(item)
+		if err != nil {
+			log.Printf("check parent %x of transaction %x failed: %v", in.PrevHash, tx.Hash, err)
+			return
+		}
+		if !has && s.nodeService.MarkSeen(in.PrevHash) {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) > 0 {
+		go s.fetchData(addr, items)
+	}
+}
+
+// receiveBlock adds the block from the peer, if the prev block doesn't exist, then maybe we fall behind
+// with current chain or a new main chain show up, so we need sync with the highest node,
+// which is at least as high as the block
+func (s *BitcoinServer) receiveBlock(block *model.Block, addr string) error {
+	err := s.connectBlock(block)
+	if err == errors.ErrPrevBlockNotFound {
+		// the orphan is connected once the parent arrives, the proof of work limits the junk from the peer
+		if infra.CheckProofOfWork(block.Hash, block.Bits) && s.orphanPool.Put(block, addr) {
+			log.Printf("block %x from %v is an orphan", block.Hash, addr)
+		}
+		s.nodeService.UpdateHeight(addr, block.Number)
+		if len(s.syncBlockQueue) < PullBlockQueueSize {
+			s.syncBlockQueue <- addr
+		}
+	}
+	return err
+}
+
+// hasItem reports whether the announced block or transaction is already stored
+func (s *BitcoinServer) hasItem(item *protocol.InvItem) (bool, error) {
+	switch item.Type {
+	case protocol.InvType_INV_TX:
+		if s.orphanTxPool.Has(item.Hash) {
+			return true, nil
+		}
+		s.lock.Lock()
+		tx := s.mempool.Get(item.Hash)
+		s.lock.Unlock()
+		if tx != nil {
+			return true, nil
+		}
+		tx, err := s.txService.GetTx(item.Hash)
+		return tx != nil, err
+	case protocol.InvType_INV_BLOCK:
+		if s.orphanPool.Has(item.Hash) {
+			return true, nil
+		}
+		block, err := s.blockService.GetBlock(item.Hash, false)
+		return block != nil, err
+	}
+	return false, errors.ErrInvTypeUnknown
+}
+
+// fetchData fetches the announced blocks and transactions from the peer,
+// the ones not received are forgotten so they can be fetched from other peers
+func (s *BitcoinServer) fetchData(addr string, items []*protocol.InvItem) {
+	wanted := make(map[string]bool)
+	for _, item := range items {
+		wanted[string(item.Hash)] = true
+	}
+	defer func() {
+		for hash := range wanted {
+			s.nodeService.Forget([]byte(hash))
+		}
+	}()
+
+	reply, err := s.nodeService.GetData(addr, items)
+	if err != nil {
+		log.Printf("get data from %v failed: %v", addr, err)
+		return
+	}
+
+	for _, request := range reply.Txs {
+		tx := model.TransactionFrom(request)
+		if !wanted[string(tx.Hash)] {
+			log.Printf("transaction %x from %v is not requested", tx.Hash, addr)
+			continue
+		}
+		delete(wanted, string(tx.Hash))
+
+		if err := s.receiveTx(tx, addr); err != nil {
+			log.Printf("accept transaction %x from %v failed: %v", tx.Hash, addr, err)
+		}
+	}
+
+	for _, request := range reply.Blocks {
+		block, err := model.BlockFrom(request)
+		if err != nil {
+			log.Printf("convert block from %v failed: %v", addr, err)
+			continue
+		}
+		if !wanted[string(block.Hash)] {
+			log.Printf("block %x from %v is not requested", block.Hash, addr)
+			continue
+		}
+		delete(wanted, string(block.Hash))
+
+		if err := s.receiveBlock(block, addr); err != nil {
+			log.Printf("add block %x from %v failed: %v", block.Hash, addr, err)
 		}
 	}
+}
+
+// connectBlock adds the block, then the orphans waiting for it and their descendants
+func (s *BitcoinServer) connectBlock(block *model.Block) error {
+	if err := s.addBlock(block); err != nil {
+		return err
+	}
+	s.acceptOrphanTxsOf(block)
 
+	parents := [][]byte{block.Hash}
+	for len(parents) > 0 {
+		for _, orphan := range s.orphanPool.PopChildren(parents[0]) {
+			if err := s.addBlock(orphan.Item); err != nil {
+				log.Printf("add orphan block %x failed: %v", orphan.Item.Hash, err)
+				continue
+			}
+			log.Printf("connected orphan block: %x", orphan.Item.Hash)
+			s.acceptOrphanTxsOf(orphan.Item)
+			parents = append(parents, orphan.Item.Hash)
+		}
+		parents = parents[1:]
+	}
 	return nil
 }
 
+// acceptOrphanTxsOf drops the orphan transactions confirmed by the block, and accepts the ones spending its transactions
+func (s *BitcoinServer) acceptOrphanTxsOf(block *model.Block) {
+	txs := block.GetTxs()
+	hashes := make([][]byte, len(txs))
+	for i, tx := range txs {
+		s.orphanTxPool.Take(tx.Hash)
+		hashes[i] = tx.Hash
+	}
+	s.acceptOrphanTxs(hashes...)
+}
+
 func (s *BitcoinServer) addBlock(block *model.Block) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	err := s.blockService.Validate(block)
 	if err != nil {
+		s.markInvalid(block, err)
 		return err
 	}
 	log.Printf("validated block: %x", block.Hash)
 
-	reward := block.GetNextReward(s.cfg.InitRewrad, s.cfg.BlocksPerRewrad)
-	txs := block.GetTxs()
-
-	isMainChain, err := s.applyBlock(block)
+	reward, err := s.blockService.GetReward(block)
 	if err != nil {
-		log.Printf("apply block %x failed: %v", block.Hash, err)
 		return err
 	}
+	txs := block.GetTxs()
+
+	// only the transactions extending the main chain can be checked against the utxo
+	mainChain := s.chainService.GetMainChain()
+	isMainChain := mainChain == nil || bytes.Equal(mainChain.LastBlockHash, block.Prevhash)
 
 	if err = s.txService.ValidateOnChainTxs(txs, block.Hash, reward, isMainChain); err != nil {
+		// the transactions on a side chain are not checked against its own utxo
+		if isMainChain {
+			s.markInvalid(block, err)
+		}
 		return err
 	}
 
-	s.mempool.Remove(block.GetTxs())
-
-	if err = s.blockService.SaveBlock(block); err != nil {
+	// the block extending the main chain is saved with its utxo changes at once
+	if isMainChain {
+		err = s.chainService.ConnectBlock(block)
+	} else {
+		err = s.blockService.SaveBlock(block)
+	}
+	if err != nil {
 		log.Printf("save block %x failed: %v", block.Hash, err)
 		return err
 	}
 	log.Printf("saved block: %x", block.Hash)
 
+	if err = s.applyBlock(block); err != nil {
+		log.Printf("apply block %x failed: %v", block.Hash, err)
+		return err
+	}
+	s.prune()
+
+	s.mempool.Remove(txs)
+
+	s.nodeService.MarkSeen(block.Hash)
 	s.blockBroadcastQueue <- block
 
 	return nil
 }
 
-func (s *BitcoinServer) applyBlock(block *model.Block) (bool, error) {
-	applyChain, rollbackChain := s.chainService.ApplyChain(block)
+// prune deletes the old block bodies on the pruned node, the failure only delays the pruning
+func (s *BitcoinServer) prune() {
+	mainChain := s.chainService.GetMainChain()
+	unspent := func(point model.OutPoint) bool {
+		return s.chainService.GetUtxo(point) != nil
+	}
+	if err := s.blockService.Prune(mainChain.LastBlockHash, unspent); err != nil {
+		log.Printf("prune blocks failed: %v", err)
+	}
+}
+
+// markInvalid marks the block breaking the consensus rules, so it and its descendants are refused later
+func (s *BitcoinServer) markInvalid(block *model.Block, err error) {
+	if !service.IsConsensusErr(err) {
+		return
+	}
+	if err := s.blockService.MarkInvalid(block); err != nil {
+		log.Printf("mark block %x invalid failed: %v", block.Hash, err)
+		return
+	}
+	log.Printf("marked block %x invalid", block.Hash)
+}
+
+func (s *BitcoinServer) applyBlock(block *model.Block) error {
+	index, err := s.blockService.GetIndex(block.Hash)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return errors.ErrBlockNotFound
+	}
 
-	if applyChain != nil && rollbackChain != nil {
-		applyBlocks, rollbackBlocks, err := s.blockService.GetBlocksOfChain(applyChain, rollbackChain)
+	applyChain, rollbackChain := s.chainService.ApplyChain(index)
+	if applyChain == nil {
+		// the block is on a side chain
+		return nil
+	}
+
+	if s.cfg.Server != block.Miner {
+		s.cancelFunc(errors.ErrServerCancelMining)
+	}
+
+	if rollbackChain != nil {
+		if err := s.switchChain(rollbackChain); err != nil {
+			return err
+		}
+		// the block is refused if it or a block before it on its chain fails the utxo on switching
+		index, err := s.blockService.GetIndex(block.Hash)
 		if err != nil {
-			return false, err
+			return err
 		}
-		s.chainService.SwitchBlocks(rollbackBlocks, applyBlocks)
-		return false, nil
-	} else {
-		if s.cfg.Server != block.Miner {
-			s.cancelFunc(errors.ErrServerCancelMining)
+		if index.Failed() {
+			return errors.ErrBlockInvalid
 		}
-
-		s.chainService.ApplyBlock(block)
-		return true, nil
 	}
+	// the block extending the main chain is connected on saving
+	return nil
 }
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"Bitcoin/src/bitcoin/client"
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/model"
+	"Bitcoin/src/protocol"
+	"Bitcoin/src/wallet"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"math/big"
+	"os"
+	"time"
+)
+
+func keygen(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "privkey.pem", "the path of the private key file")
+	flags.Parse(args)
+
+	privkey, pubkey, err := cryptography.GenerateKeys()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, privkey, 0600); err != nil {
+		return err
+	}
+
+	prefix, err := cryptography.NetworkPrefix(*network)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]string{
+		"privkey": *out,
+		"address": cryptography.NewAddress(prefix, pubkey).String(),
+	})
+}
+
+func address(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("address", flag.ExitOnError)
+	privkeyFile := flags.String("privkey", "privkey.pem", "the path of the private key file")
+	flags.Parse(args)
+
+	privkey, err := os.ReadFile(*privkeyFile)
+	if err != nil {
+		return err
+	}
+	pubkey, err := cryptography.PublicKeyOf(privkey)
+	if err != nil {
+		return err
+	}
+	prefix, err := cryptography.NetworkPrefix(*network)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]string{
+		"address": cryptography.NewAddress(prefix, pubkey).String(),
+		"pubkey":  base64.RawStdEncoding.EncodeToString(pubkey),
+	})
+}
+
+func info(cli client.IBitcoinClient, args []string) error {
+	reply, err := cli.GetChainInfo(&protocol.GetChainInfoReq{})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]any{
+		"number":    reply.Number,
+		"hash":      hex.EncodeToString(reply.Hash),
+		"bits":      reply.Bits,
+		"timestamp": time.UnixMilli(reply.Timestamp),
+		"tips":      reply.Tips,
+	})
+}
+
+func tips(cli client.IBitcoinClient, args []string) error {
+	reply, err := cli.ListChainTips(&protocol.ListChainTipsReq{})
+	if err != nil {
+		return err
+	}
+
+	tips := make([]map[string]any, len(reply.Tips))
+	for i, tip := range reply.Tips {
+		tips[i] = map[string]any{
+			"hash":   hex.EncodeToString(tip.Hash),
+			"length": tip.Length,
+			"main":   tip.Main,
+			"work":   new(big.Int).SetBytes(tip.Work).String(),
+			"status": tip.Status,
+			"branch": tip.BranchLen,
+		}
+	}
+	return printJSON(tips)
+}
+
+func balance(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("balance", flag.ExitOnError)
+	address := flags.String("address", "", "the address")
+	flags.Parse(args)
+
+	if _, err := cryptography.ParseNetworkAddress(*address, *network); err != nil {
+		return err
+	}
+
+	reply, err := cli.GetBalance(&protocol.GetBalanceReq{Address: *address})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]any{
+		"balance": reply.Balance,
+		"outs":    reply.Outs,
+	})
+}
+
+func unspent(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("unspent", flag.ExitOnError)
+	address := flags.String("address", "", "the address")
+	flags.Parse(args)
+
+	if _, err := cryptography.ParseNetworkAddress(*address, *network); err != nil {
+		return err
+	}
+
+	reply, err := cli.ListUnspent(&protocol.ListUnspentReq{Address: *address})
+	if err != nil {
+		return err
+	}
+
+	outs := make([]map[string]any, len(reply.Outs))
+	for i, out := range reply.Outs {
+		outs[i] = map[string]any{
+			"hash":  hex.EncodeToString(out.Hash),
+			"index": out.Index,
+			"value": out.Value,
+		}
+	}
+	return printJSON(outs)
+}
+
+func block(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("block", flag.ExitOnError)
+	hash := flags.String("hash", "", "the block hash")
+	number := flags.Uint64("number", 0, "the block number on the main chain")
+	flags.Parse(args)
+
+	var req *protocol.BlockReq
+	if *hash != "" {
+		blockHash, err := hex.DecodeString(*hash)
+		if err != nil {
+			return err
+		}
+		req, err = cli.GetBlockByHash(&protocol.GetBlockByHashReq{Hash: blockHash})
+		if err != nil {
+			return err
+		}
+	} else if *number > 0 {
+		var err error
+		req, err = cli.GetBlockByNumber(&protocol.GetBlockByNumberReq{Number: *number})
+		if err != nil {
+			return err
+		}
+	} else {
+		return errors.New("either hash or number is required")
+	}
+
+	block, err := model.BlockFrom(req)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]any{
+		"block":        block,
+		"transactions": block.GetTxs(),
+	})
+}
+
+func tx(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("tx", flag.ExitOnError)
+	hash := flags.String("hash", "", "the transaction hash")
+	flags.Parse(args)
+
+	txHash, err := hex.DecodeString(*hash)
+	if err != nil {
+		return err
+	}
+
+	reply, err := cli.GetTransaction(&protocol.GetTransactionReq{Hash: txHash})
+	if err != nil {
+		return err
+	}
+
+	tx := model.TransactionFrom(reply.Transaction)
+	tx.BlockHash = reply.BlockHash
+	return printJSON(tx)
+}
+
+func createtx(cli client.IBitcoinClient, args []string) error {
+	tx, err := buildTx(cli, "createtx", args)
+	if err != nil {
+		return err
+	}
+	return printJSON(tx)
+}
+
+func sendtx(cli client.IBitcoinClient, args []string) error {
+	flags := flag.NewFlagSet("sendtx", flag.ExitOnError)
+	file := flags.String("file", "", "the path of the transaction file")
+	flags.Parse(args)
+
+	var data []byte
+	var err error
+	if *file != "" {
+		data, err = os.ReadFile(*file)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	var tx model.Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return err
+	}
+	return sendTx(cli, &tx)
+}
+
+func send(cli client.IBitcoinClient, args []string) error {
+	tx, err := buildTx(cli, "send", args)
+	if err != nil {
+		return err
+	}
+	return sendTx(cli, tx)
+}
+
+func sendTx(cli client.IBitcoinClient, tx *model.Transaction) error {
+	reply, err := cli.SendTx(model.TransactionTo(tx))
+	if err != nil {
+		return err
+	}
+
+	return printJSON(map[string]any{
+		"hash":   hex.EncodeToString(tx.Hash),
+		"result": reply.Result,
+	})
+}
+
+// buildTx spends the unspent outputs of the private key to the address, the change goes back to the private key
+func buildTx(cli client.IBitcoinClient, name string, args []string) (*model.Transaction, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	privkeyFile := flags.String("privkey", "privkey.pem", "the path of the private key file")
+	to := flags.String("to", "", "the address of the receiver")
+	value := flags.Uint64("value", 0, "the value to send")
+	fee := flags.Uint64("fee", 0, "the fee to the miner")
+	flags.Parse(args)
+
+	if *value == 0 {
+		return nil, errors.New("the value is zero")
+	}
+
+	privkey, err := os.ReadFile(*privkeyFile)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := cryptography.PublicKeyOf(privkey)
+	if err != nil {
+		return nil, err
+	}
+	toAddress, err := cryptography.ParseNetworkAddress(*to, *network)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := cryptography.NetworkPrefix(*network)
+	if err != nil {
+		return nil, err
+	}
+	fromAddress := cryptography.NewAddress(prefix, pubkey)
+
+	reply, err := cli.ListUnspent(&protocol.ListUnspentReq{Address: fromAddress.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	key := &wallet.Key{Privkey: privkey, Pubkey: pubkey}
+	coins := make([]*wallet.Coin, len(reply.Outs))
+	for i, out := range reply.Outs {
+		coins[i] = &wallet.Coin{
+			Point: model.OutPoint{Hash: out.Hash, Index: out.Index},
+			Out:   &model.Out{Address: fromAddress.Hash, Value: out.Value},
+			Key:   key,
+		}
+	}
+
+	tx, _, err := wallet.BuildTx(coins, toAddress.Hash, fromAddress.Hash, *value, *fee)
+	return tx, err
+}
+
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(data, '\n'))
+	return err
+}
@@ -3,36 +3,62 @@ package main
 import (
 	"Bitcoin/src/bitcoin/client"
 	"Bitcoin/src/cryptography"
-	"Bitcoin/src/protocol"
 	"flag"
+	"fmt"
 	"log"
-	"time"
+	"os"
 )
 
 var (
-	addr = flag.String("addr", "localhost:50051", "the address to connect to")
+	addr    = flag.String("addr", "localhost:50051", "the address to connect to")
+	network = flag.String("network", cryptography.MainNet, "the network of the addresses")
 )
 
+type command struct {
+	usage string
+	run   func(cli client.IBitcoinClient, args []string) error
+}
+
+// the addresses are Base58Check of the pubkey hashes, the same as the miner address in the config,
+// the private keys are read from the pem files, the hashes are hex
+var commands = map[string]command{
+	"keygen":   {"keygen -out <privkey file>: generate a key pair", keygen},
+	"address":  {"address -privkey <privkey file>: show the address and pubkey of the private key", address},
+	"info":     {"info: show the tip of the main chain", info},
+	"tips":     {"tips: list the tips of all chains", tips},
+	"balance":  {"balance -address <address>: show the balance of the address", balance},
+	"unspent":  {"unspent -address <address>: list the unspent outputs of the address", unspent},
+	"block":    {"block -hash <hash> | -number <number>: show the block", block},
+	"tx":       {"tx -hash <hash>: show the transaction", tx},
+	"createtx": {"createtx -privkey <privkey file> -to <address> -value <value> [-fee <fee>]: build and sign a transaction from the unspent outputs", createtx},
+	"sendtx":   {"sendtx [-file <transaction file>]: send the transaction created by createtx, read stdin if no file", sendtx},
+	"send":     {"send -privkey <privkey file> -to <address> -value <value> [-fee <fee>]: createtx and sendtx", send},
+}
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
-	req := &protocol.TransactionReq{
-		InLen:  0,
-		OutLen: 0,
-		Ins:    []*protocol.InReq{},
-		Outs:   []*protocol.OutReq{},
-		Time:   time.Now().UnixMilli(),
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
 	}
-	hash, err := cryptography.Hash(req)
-	if err != nil {
-		log.Fatalf("hash transaction request failed: %v", err)
 
+	cli := client.NewBitcoinClient(*addr)
+	if err := cmd.run(cli, flag.Args()[1:]); err != nil {
+		log.Fatalf("%s failed: %v", flag.Arg(0), err)
 	}
-	req.Hash = hash
+}
 
-	client := client.NewBitcoinClient(*addr)
-	reply, err := client.SendTx(req)
-	if err != nil {
-		log.Fatalf("send transaction failed: %v", err)
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: client [-addr <address>] [-network <main|test>] <command> [args]\n\ncommands:\n")
+	for _, name := range []string{"keygen", "address", "info", "tips", "balance", "unspent", "block", "tx", "createtx", "sendtx", "send"} {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
 	}
-	log.Printf("send transaction result: %v", reply.Result)
 }
@@ -38,6 +38,11 @@ func (err NodeUnmarshalError) Error() string {
 	return fmt.Sprintf("%v, hash: %x, parent: %x, sibling: %x", err.Err, err.Hash, err.ParentHash, err.SiblingHash)
 }
 
+// Hashable is the value which computes its own hash, e.g. with a binary encoding
+type Hashable interface {
+	ComputeHash() ([]byte, error)
+}
+
 type MerkleTree[T any] struct {
 	Table [][]*MerkleTreeNode[T] `json:"table,omitempty"`
 }
@@ -155,7 +160,14 @@ func (node *MerkleTreeNode[T]) computeParentHash(sibling *MerkleTreeNode[T]) ([]
 	hashlist[1] = sibling.Hash
 
 	val := bytes.Join(hashlist, []byte(""))
-	return cryptography.Hash(hex.EncodeToString(val))
+	return cryptography.HashBytes(val), nil
+}
+
+func computeHash[T any](val T) ([]byte, error) {
+	if hashable, ok := any(val).(Hashable); ok {
+		return hashable.ComputeHash()
+	}
+	return cryptography.Hash(val)
 }
 
 func BuildTree[T any](vals []T) (*MerkleTree[T], error) {
@@ -163,7 +175,7 @@ func BuildTree[T any](vals []T) (*MerkleTree[T], error) {
 	nodes := make([]*MerkleTreeNode[T], len(vals))
 
 	for i := 0; i < len(vals); i++ {
-		hash, err := cryptography.Hash(vals[i])
+		hash, err := computeHash(vals[i])
 		if err != nil {
 			return nil, err
 		}
@@ -283,7 +295,8 @@ func (tree *MerkleTree[T]) GetVals() []T {
 }
 
 func rebuild[T any](tree *MerkleTree[T]) error {
-	if len(tree.Table) <= 1 {
+	// a tree of a single value only has the row of the root
+	if len(tree.Table) == 0 || (len(tree.Table) == 1 && len(tree.Table[0]) != 1) {
 		return RowUnmarshalError{Err: ErrMtFewRows}
 	}
 
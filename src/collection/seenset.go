@@ -0,0 +1,65 @@
+package collection
+
+import (
+	"sync"
+)
+
+// SeenSet remembers the recently seen hashes, the oldest one is evicted when it's full
+type SeenSet struct {
+	slots map[string]int
+	ring  []string
+	next  int
+	lock  sync.Mutex
+}
+
+func NewSeenSet(size int) *SeenSet {
+	return &SeenSet{
+		slots: make(map[string]int, size),
+		ring:  make([]string, size),
+	}
+}
+
+// Add returns false if the hash is already seen
+func (set *SeenSet) Add(hash []byte) bool {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	key := string(hash)
+	if _, has := set.slots[key]; has {
+		return false
+	}
+	if len(set.ring) == 0 {
+		return true
+	}
+
+	// the slot may be taken by a removed and re-added hash, only evict the hash still owning it
+	if old := set.ring[set.next]; old != "" && set.slots[old] == set.next {
+		delete(set.slots, old)
+	}
+	set.ring[set.next] = key
+	set.slots[key] = set.next
+	set.next = (set.next + 1) % len(set.ring)
+	return true
+}
+
+func (set *SeenSet) Has(hash []byte) bool {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	_, has := set.slots[string(hash)]
+	return has
+}
+
+func (set *SeenSet) Remove(hash []byte) {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	delete(set.slots, string(hash))
+}
+
+func (set *SeenSet) Len() int {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	return len(set.slots)
+}
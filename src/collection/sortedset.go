@@ -61,51 +61,40 @@ func (set *SortedSet[K, S, T]) Max() T {
 	return *new(T)
 }
 
-// TODO: test case
+// PopMax removes and returns the item of the max score, the last inserted one of the same score
 func (set *SortedSet[K, S, T]) PopMax() T {
 	if set.length > 0 {
-		node := set.header
 		tail := set.tail
-		for i := len(set.tail.levels) - 1; i >= 0; i-- {
-			for ; node.levels[i].forward != tail; node = node.levels[i].forward {
-			}
-			node.levels[i].forward = tail
-			node.levels[i].span = 0
-
-			if set.header.levels[i].forward == nil {
-				set.maxLevel = i - 1
-			}
-		}
-		set.tail = tail.backward
-		set.length--
+		set.Remove(tail.key, tail.score)
 		return tail.val
 	}
 	return *new(T)
 }
 
+// TopMax returns the items ranked in [m, n) in ascending order
 func (set *SortedSet[K, S, T]) TopMax(m, n int) []T {
+	if n > set.length {
+		n = set.length
+	}
+	if m >= n {
+		return []T{}
+	}
+
 	items := make([]T, 0, n-m)
 	node := set.header
-	span := 0
+	traversed := 0
 	for i := set.maxLevel - 1; i >= 0; i-- {
 		for ; node.levels[i].forward != nil; node = node.levels[i].forward {
-			if span+node.levels[i].span >= m {
+			if traversed+node.levels[i].span > m {
 				break
 			}
-			span += node.levels[i].span
-		}
-		if span+node.levels[i].span == m {
-			break
+			traversed += node.levels[i].span
 		}
 	}
 
-	for i := m; i < n; i++ {
-		if node.levels[0].forward != nil {
-			items = append(items, node.levels[0].forward.val)
-			node = node.levels[0].forward
-		} else {
-			break
-		}
+	for i := m; i < n && node.levels[0].forward != nil; i++ {
+		node = node.levels[0].forward
+		items = append(items, node.val)
 	}
 	return items
 }
@@ -116,6 +105,11 @@ func (set *SortedSet[K, S, T]) Insert(key K, score S, t T) {
 
 	nlevel := randomLevel()
 	if nlevel > set.maxLevel {
+		// the new levels of header span all the nodes
+		for l := set.maxLevel; l < nlevel; l++ {
+			set.header.levels[l].forward = nil
+			set.header.levels[l].span = set.length
+		}
 		set.maxLevel = nlevel
 	}
 
@@ -151,11 +145,10 @@ func (set *SortedSet[K, S, T]) Insert(key K, score S, t T) {
 	newnode.backward = nodes[0]
 
 	for i := 0; i < set.maxLevel; i++ {
-		original := nodes[i].levels[i].forward
-		newnode.levels[i].forward = original
-		nodes[i].levels[i].forward = newnode
-
 		if i < nlevel {
+			newnode.levels[i].forward = nodes[i].levels[i].forward
+			nodes[i].levels[i].forward = newnode
+
 			originalSpan := nodes[i].levels[i].span
 			nodes[i].levels[i].span = totalSpan - spans[i] + 1
 			newnode.levels[i].span = originalSpan + 1 - nodes[i].levels[i].span
@@ -168,71 +161,47 @@ func (set *SortedSet[K, S, T]) Insert(key K, score S, t T) {
 }
 
 func (set *SortedSet[K, S, T]) Remove(key K, score S) {
-	nodes := make([]*skipListNode[K, S, T], set.maxLevel)
-	exist := false
-	node := set.header
-	for i := set.maxLevel - 1; i >= 0; i-- {
-		for ; node.levels[i].forward != nil; node = node.levels[i].forward {
-			if node.levels[i].forward.key == key {
-				exist = true
-				break
-			}
-			if score < node.levels[i].forward.score {
-				break
-			}
-		}
-		nodes[i] = node
-	}
-
-	if !exist {
+	node, rank := set.find(key, score)
+	if node == nil {
 		return
 	}
 
+	prev := set.header
+	traversed := 0
 	for i := set.maxLevel - 1; i >= 0; i-- {
-		if nodes[i].levels[i].forward != nil {
-			span := nodes[i].levels[i].forward.levels[i].span
-			nodes[i].levels[i].forward = nodes[i].levels[i].forward.levels[i].forward
-			nodes[i].levels[i].span += span - 1
-		} else {
-			nodes[i].levels[i].span--
+		for ; prev.levels[i].forward != nil; prev = prev.levels[i].forward {
+			if traversed+prev.levels[i].span >= rank {
+				break
+			}
+			traversed += prev.levels[i].span
 		}
 
-		if set.header.levels[i].forward == nil {
-			set.maxLevel = i - 1
+		if prev.levels[i].forward == node {
+			prev.levels[i].span += node.levels[i].span - 1
+			prev.levels[i].forward = node.levels[i].forward
+		} else {
+			prev.levels[i].span--
 		}
 	}
 
-	if nodes[0].levels[0].forward != nil {
-		nodes[0].levels[0].forward.backward = nodes[0]
+	if node.levels[0].forward != nil {
+		node.levels[0].forward.backward = node.backward
 	} else {
-		set.tail = nodes[0]
+		set.tail = node.backward
 	}
 
+	for set.maxLevel > 0 && set.header.levels[set.maxLevel-1].forward == nil {
+		set.maxLevel--
+	}
 	set.length--
 }
 
 func (set *SortedSet[K, S, T]) Get(key K, score S) T {
-	nodes := make([]*skipListNode[K, S, T], set.maxLevel)
-	exist := false
-	node := set.header
-	for i := set.maxLevel - 1; i >= 0; i-- {
-		for ; node.levels[i].forward != nil; node = node.levels[i].forward {
-			if node.levels[i].forward.key == key {
-				exist = true
-				break
-			}
-			if score < node.levels[i].forward.score {
-				break
-			}
-		}
-		nodes[i] = node
-	}
-
-	if !exist {
-		return nodes[0].levels[0].forward.val
-	} else {
-		return *new(T)
+	node, _ := set.find(key, score)
+	if node != nil {
+		return node.val
 	}
+	return *new(T)
 }
 
 func (set *SortedSet[K, S, T]) Get1(key K) T {
@@ -249,10 +218,33 @@ func (set *SortedSet[K, S, T]) Len() int {
 	return set.length
 }
 
+// find returns the node of the key and its rank which starts from 1
+func (set *SortedSet[K, S, T]) find(key K, score S) (*skipListNode[K, S, T], int) {
+	node := set.header
+	rank := 0
+	for i := set.maxLevel - 1; i >= 0; i-- {
+		for ; node.levels[i].forward != nil; node = node.levels[i].forward {
+			if node.levels[i].forward.score >= score {
+				break
+			}
+			rank += node.levels[i].span
+		}
+	}
+
+	// the nodes with the same score are ordered by insertion
+	for node = node.levels[0].forward; node != nil && node.score == score; node = node.levels[0].forward {
+		rank++
+		if node.key == key {
+			return node, rank
+		}
+	}
+	return nil, 0
+}
+
 func randomLevel() int {
 	threshold := 2147483647 / 4
 	level := 1
-	for rand.Int() < threshold {
+	for rand.Int31() < int32(threshold) {
 		level += 1
 	}
 	if level < MaxLevel {
@@ -1,7 +1,8 @@
 package config
 
 import (
-	"encoding/base64"
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/database"
 	"errors"
 	"os"
 	"strings"
@@ -16,13 +17,20 @@ const (
 	DefaultMaxTxSizePerBlock   = 10
 	DefaultMaxTxSizeOfMempool  = 1000
 	DefaultBlockInterval       = 60
-	DefaultInitDifficultyLevel = 8
 	DefaultInitReward          = 50
+	DefaultGenesis             = "genesis.json"
+	DefaultNetwork             = cryptography.MainNet
+	SnappyCompression          = "snappy"
+
+	// the blocks within the depth from the main tip are never pruned, they may be rolled back
+	MinPruneDepth = 288
 )
 
 type Config struct {
 	Server              string
+	Network             string
 	DataDir             string
+	Genesis             string
 	Endpoint            string
 	Bootstraps          []string
 	BlocksPerDifficulty uint64
@@ -31,8 +39,15 @@ type Config struct {
 	MaxTxSizeOfMemPool  uint32
 	InitRewrad          uint64
 	BlockInterval       uint64
-	InitDifficultyLevel uint64
-	MinerPubkey         []byte
+	MinerAddress        []byte
+	// the block bodies deeper than the depth are pruned, 0 keeps all the blocks
+	PruneDepth uint64
+	// the block bodies are pruned from the oldest until their size in MB is under it, 0 prunes to the depth
+	PruneSize uint64
+	// the name of the storage engine registered in the database, the memory engine does not use the data dir
+	StorageEngine string
+	// the records of the blocks and the transactions are compressed by it, empty is not compressed
+	Compression string
 }
 
 // TODO: need more test cases
@@ -44,7 +59,11 @@ func Read(path string) (*Config, error) {
 
 	var s struct {
 		Server              string   `yaml:"server,omitempty"`
+		Network             string   `yaml:"network,omitempty"`
 		DataDir             string   `yaml:"data_dir,omitempty"`
+		StorageEngine       string   `yaml:"storage_engine,omitempty"`
+		Compression         string   `yaml:"compression,omitempty"`
+		Genesis             string   `yaml:"genesis,omitempty"`
 		Endpoint            string   `yaml:"endpoint,omitempty"`
 		Bootstraps          []string `yaml:"bootstraps,omitempty"`
 		BlocksPerDifficulty uint64   `yaml:"blocks_per_difficulty,omitempty"`
@@ -52,15 +71,16 @@ func Read(path string) (*Config, error) {
 		MaxTxSizePerBlock   uint16   `yaml:"max_tx_size_per_block,omitempty"`
 		MaxTxSizeOfMemPool  uint32   `yaml:"max_tx_size_of_mempool,omitempty"`
 		BlockInterval       uint64   `yaml:"block_interval,omitempty"`
-		InitDifficultyLevel uint64   `yaml:"init_difficulty_level,omitempty"`
 		MinerAddress        string   `yaml:"miner_address,omitempty"`
+		PruneDepth          uint64   `yaml:"prune_depth,omitempty"`
+		PruneSize           uint64   `yaml:"prune_size,omitempty"`
 	}
 	err = yaml.Unmarshal(file, &s)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.Trim(s.DataDir, "") == "" {
+	if strings.Trim(s.DataDir, "") == "" && s.StorageEngine != database.MemoryEngine {
 		return nil, errors.New("the data dir is empty")
 	}
 	if strings.Trim(s.Endpoint, "") == "" {
@@ -70,6 +90,10 @@ func Read(path string) (*Config, error) {
 		return nil, errors.New("the miner address is empty")
 	}
 
+	if s.Compression != "" && s.Compression != SnappyCompression {
+		return nil, errors.New("the compression is unknown")
+	}
+
 	if strings.Trim(s.Server, "") == "" {
 		s.Server = s.Endpoint
 	}
@@ -77,6 +101,10 @@ func Read(path string) (*Config, error) {
 	var config Config
 	automapper.MapLoose(&s, &config)
 
+	if config.Genesis == "" {
+		config.Genesis = DefaultGenesis
+	}
+
 	if config.BlocksPerDifficulty == 0 {
 		config.BlocksPerDifficulty = DefaultBlocksPerDifficulty
 	}
@@ -101,15 +129,24 @@ func Read(path string) (*Config, error) {
 		config.BlockInterval = DefaultBlockInterval
 	}
 
-	if config.InitDifficultyLevel == 0 {
-		config.InitDifficultyLevel = DefaultInitDifficultyLevel
+	if config.StorageEngine == "" {
+		config.StorageEngine = database.DefaultEngine
+	}
+
+	if config.Network == "" {
+		config.Network = DefaultNetwork
+	}
+
+	if (config.PruneDepth > 0 || config.PruneSize > 0) && config.PruneDepth < MinPruneDepth {
+		config.PruneDepth = MinPruneDepth
 	}
 
-	pubkey, err := base64.RawStdEncoding.DecodeString(s.MinerAddress)
+	// the miner address is Base58Check encoded, the coinbase is locked to its hash
+	address, err := cryptography.ParseNetworkAddress(s.MinerAddress, config.Network)
 	if err != nil {
 		return nil, err
 	}
 
-	config.MinerPubkey = pubkey
+	config.MinerAddress = address.Hash
 	return &config, nil
 }
@@ -0,0 +1,138 @@
+package cryptography
+
+import (
+	"Bitcoin/src/errors"
+	"bytes"
+	"math/big"
+)
+
+const (
+	AddressHashLen = 20
+	checksumLen    = 4
+	MainNet        = "main"
+	TestNet        = "test"
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// the version byte of the address of each network, the same as bitcoin p2pkh
+var networkPrefixes = map[string]byte{
+	MainNet: 0x00,
+	TestNet: 0x6f,
+}
+
+// Address is the hash of a public key with the prefix of its network, the outputs are locked to the hash
+type Address struct {
+	Prefix byte
+	Hash   []byte
+}
+
+// AddressHash computes the hash of the public key which the outputs are locked to
+func AddressHash(pubkey []byte) []byte {
+	return HashBytes(HashBytes(pubkey))[:AddressHashLen]
+}
+
+func NetworkPrefix(network string) (byte, error) {
+	prefix, ok := networkPrefixes[network]
+	if !ok {
+		return 0, errors.ErrNetworkUnknown
+	}
+	return prefix, nil
+}
+
+func NewAddress(prefix byte, pubkey []byte) *Address {
+	return &Address{Prefix: prefix, Hash: AddressHash(pubkey)}
+}
+
+// String encodes the address by Base58Check: prefix | hash | the first 4 bytes of double sha256 of them
+func (address *Address) String() string {
+	data := append([]byte{address.Prefix}, address.Hash...)
+	return base58Encode(append(data, checksum(data)...))
+}
+
+// ParseAddress decodes the Base58Check address and validates its checksum, length and network
+func ParseAddress(s string) (*Address, error) {
+	data, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 1+AddressHashLen+checksumLen {
+		return nil, errors.ErrAddressInvalid
+	}
+
+	payload, sum := data[:len(data)-checksumLen], data[len(data)-checksumLen:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, errors.ErrAddressChecksum
+	}
+
+	address := &Address{Prefix: payload[0], Hash: payload[1:]}
+	for _, prefix := range networkPrefixes {
+		if prefix == address.Prefix {
+			return address, nil
+		}
+	}
+	return nil, errors.ErrNetworkUnknown
+}
+
+// ParseNetworkAddress parses the address and checks it belongs to the network
+func ParseNetworkAddress(s, network string) (*Address, error) {
+	prefix, err := NetworkPrefix(network)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := ParseAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	if address.Prefix != prefix {
+		return nil, errors.ErrAddressNetworkMismatch
+	}
+	return address, nil
+}
+
+func checksum(data []byte) []byte {
+	return HashBytes(HashBytes(data))[:checksumLen]
+}
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	mod := new(big.Int)
+
+	encoded := make([]byte, 0, len(data)*138/100+1)
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	// every leading zero byte is encoded as the first letter
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	for _, c := range []byte(s) {
+		i := bytes.IndexByte([]byte(base58Alphabet), c)
+		if i < 0 {
+			return nil, errors.ErrAddressInvalid
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -1,7 +1,9 @@
 package cryptography
 
 import (
+	"Bitcoin/src/errors"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
@@ -24,6 +26,12 @@ func Hash(e any) ([]byte, error) {
 	return hash, nil
 }
 
+// HashBytes computes the sha256 of the data as is
+func HashBytes(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
 func Verify(pubkey, hash, signature []byte) (bool, error) {
 	publicKey, err := DecodePublicKey(pubkey)
 	if err != nil {
@@ -42,6 +50,43 @@ func Sign(privkey, hash []byte) ([]byte, error) {
 	return signature, err
 }
 
+// Signable is the content whose signature hash is signed instead of the content itself
+type Signable interface {
+	SignatureHash() ([]byte, error)
+}
+
+func SignContent(privkey []byte, content Signable) ([]byte, error) {
+	hash, err := content.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privkey, hash)
+}
+
+func GenerateKeys() ([]byte, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privkey, err := EncodePrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkey, err := EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privkey, pubkey, nil
+}
+
+func PublicKeyOf(privkey []byte) ([]byte, error) {
+	privateKey, err := DecodePrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	return EncodePublicKey(&privateKey.PublicKey)
+}
+
 func EncodePrivateKey(privateKey *ecdsa.PrivateKey) ([]byte, error) {
 	x509Encoded, err := x509.MarshalECPrivateKey(privateKey)
 	if err != nil {
@@ -62,6 +107,9 @@ func EncodePublicKey(publicKey *ecdsa.PublicKey) ([]byte, error) {
 
 func DecodePrivateKey(bytes []byte) (*ecdsa.PrivateKey, error) {
 	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, errors.ErrKeyInvalid
+	}
 	x509Encoded := block.Bytes
 	privateKey, err := x509.ParseECPrivateKey(x509Encoded)
 	return privateKey, err
@@ -69,12 +117,18 @@ func DecodePrivateKey(bytes []byte) (*ecdsa.PrivateKey, error) {
 
 func DecodePublicKey(bytes []byte) (*ecdsa.PublicKey, error) {
 	blockPub, _ := pem.Decode(bytes)
+	if blockPub == nil {
+		return nil, errors.ErrKeyInvalid
+	}
 	x509EncodedPub := blockPub.Bytes
 	genericPublicKey, err := x509.ParsePKIXPublicKey(x509EncodedPub)
 	if err != nil {
 		return nil, err
 	}
-	publicKey := genericPublicKey.(*ecdsa.PublicKey)
+	publicKey, ok := genericPublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.ErrKeyInvalid
+	}
 	return publicKey, nil
 }
 
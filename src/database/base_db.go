@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
@@ -12,13 +13,39 @@ import (
 type IBaseDB interface {
 	Save(prefix []byte, key, val any) error
 	Get(prefix, key []byte, val any) (bool, error)
+	Delete(prefix, key []byte) error
 	Filter(prefix, start []byte) ([][]byte, error)
+	Iterator(prefix []byte, r *Range, reverse bool) IIterator
+	Snapshot() (ISnapshot, error)
 	Size(prefix []byte) (int64, error)
 	StartBatch() IBatch
 	EndBatch(batch IBatch) error
 	Close() error
 }
 
+// Range is the keys of the table from Start to Limit, Start is included and Limit is not, nil is unbounded
+type Range struct {
+	Start []byte
+	Limit []byte
+}
+
+// IIterator walks the key/value pairs of the table lazily, the keys are without the table prefix,
+// the key and the value are only valid until the next call of Next
+type IIterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// ISnapshot reads the database at the time it is taken, it must be released after use
+type ISnapshot interface {
+	Get(prefix, key []byte, val any) (bool, error)
+	Iterator(prefix []byte, r *Range, reverse bool) IIterator
+	Release()
+}
+
 type BaseDB struct {
 	Database *leveldb.DB
 }
@@ -40,32 +67,38 @@ func (db *BaseDB) Save(prefix []byte, key, val any) error {
 }
 
 func (db *BaseDB) Get(prefix, key []byte, val any) (bool, error) {
-	opt := &opt.ReadOptions{}
-	k := makeKey(prefix, key)
-
-	data, err := db.Database.Get(k, opt)
-	if err == leveldb.ErrNotFound {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
+	return get(db.Database, prefix, key, val)
+}
 
-	return true, json.Unmarshal(data, val)
+func (db *BaseDB) Delete(prefix, key []byte) error {
+	opt := &opt.WriteOptions{}
+	return db.Database.Delete(makeKey(prefix, key), opt)
 }
 
+// Filter returns the values of the table from the key start in the key order
 func (db *BaseDB) Filter(prefix, start []byte) ([][]byte, error) {
-	opt := &opt.ReadOptions{}
-	iter := db.Database.NewIterator(util.BytesPrefix(prefix), opt)
+	iter := db.Iterator(prefix, &Range{Start: start}, false)
+	defer iter.Release()
 
 	vals := make([][]byte, 0)
-	for ok := iter.Seek(start); ok; ok = iter.Next() {
-		data := iter.Value()
+	for iter.Next() {
+		// the iterator reuses the buffer of the value
+		data := append([]byte(nil), iter.Value()...)
 		vals = append(vals, data)
 	}
-	iter.Release()
+	return vals, iter.Error()
+}
+
+func (db *BaseDB) Iterator(prefix []byte, r *Range, reverse bool) IIterator {
+	return newIterator(db.Database, prefix, r, reverse)
+}
 
-	return vals, nil
+func (db *BaseDB) Snapshot() (ISnapshot, error) {
+	snapshot, err := db.Database.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &BaseSnapshot{Snapshot: snapshot}, nil
 }
 
 func (db *BaseDB) Size(prefix []byte) (int64, error) {
@@ -82,7 +115,7 @@ func (db *BaseDB) StartBatch() IBatch {
 
 func (db *BaseDB) EndBatch(batch IBatch) error {
 	opt := &opt.WriteOptions{}
-	baseBatch := batch.(BaseBatch)
+	baseBatch := batch.(*BaseBatch)
 	return db.Database.Write(baseBatch.Batch, opt)
 }
 
@@ -90,8 +123,99 @@ func (db *BaseDB) Close() error {
 	return db.Database.Close()
 }
 
+type BaseSnapshot struct {
+	Snapshot *leveldb.Snapshot
+}
+
+func (snapshot *BaseSnapshot) Get(prefix, key []byte, val any) (bool, error) {
+	return get(snapshot.Snapshot, prefix, key, val)
+}
+
+func (snapshot *BaseSnapshot) Iterator(prefix []byte, r *Range, reverse bool) IIterator {
+	return newIterator(snapshot.Snapshot, prefix, r, reverse)
+}
+
+func (snapshot *BaseSnapshot) Release() {
+	snapshot.Snapshot.Release()
+}
+
+// reader is either the database or its snapshot
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func get(r reader, prefix, key []byte, val any) (bool, error) {
+	opt := &opt.ReadOptions{}
+	data, err := r.Get(makeKey(prefix, key), opt)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, unmarshal(data, val)
+}
+
+type BaseIterator struct {
+	iter    iterator.Iterator
+	prefix  int
+	reverse bool
+	started bool
+}
+
+func newIterator(r reader, prefix []byte, rng *Range, reverse bool) IIterator {
+	// the table prefix with the separator, otherwise the table Block covers the table BlockTree
+	slice := util.BytesPrefix(makeKey(prefix, nil))
+	if rng != nil && rng.Start != nil {
+		slice.Start = makeKey(prefix, rng.Start)
+	}
+	if rng != nil && rng.Limit != nil {
+		slice.Limit = makeKey(prefix, rng.Limit)
+	}
+
+	opt := &opt.ReadOptions{}
+	return &BaseIterator{
+		iter:    r.NewIterator(slice, opt),
+		prefix:  len(prefix) + 1,
+		reverse: reverse,
+	}
+}
+
+func (it *BaseIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		if it.reverse {
+			return it.iter.Last()
+		}
+		return it.iter.First()
+	}
+	if it.reverse {
+		return it.iter.Prev()
+	}
+	return it.iter.Next()
+}
+
+func (it *BaseIterator) Key() []byte {
+	return it.iter.Key()[it.prefix:]
+}
+
+func (it *BaseIterator) Value() []byte {
+	return it.iter.Value()
+}
+
+func (it *BaseIterator) Error() error {
+	return it.iter.Error()
+}
+
+func (it *BaseIterator) Release() {
+	it.iter.Release()
+}
+
 type IBatch interface {
 	Save(prefix []byte, key, val any) error
+	Delete(prefix, key []byte) error
 }
 
 type BaseBatch struct {
@@ -115,6 +239,11 @@ func (batch BaseBatch) Save(prefix []byte, key, val any) error {
 	return nil
 }
 
+func (batch BaseBatch) Delete(prefix, key []byte) error {
+	batch.Batch.Delete(makeKey(prefix, key))
+	return nil
+}
+
 func serialize(v any) ([]byte, error) {
 	if data, ok := v.([]byte); ok {
 		return data, nil
@@ -122,6 +251,18 @@ func serialize(v any) ([]byte, error) {
 	return json.Marshal(v)
 }
 
+// Raw reads the value as it is saved, e.g. the value saved by []byte is not JSON
+type Raw []byte
+
+// unmarshal decodes the JSON value, or copies the value to *Raw
+func unmarshal(data []byte, val any) error {
+	if raw, ok := val.(*Raw); ok {
+		*raw = append([]byte(nil), data...)
+		return nil
+	}
+	return json.Unmarshal(data, val)
+}
+
 func makeKey(prefix, key []byte) []byte {
 	return bytes.Join([][]byte{prefix, key}, []byte("-"))
 }
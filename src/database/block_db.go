@@ -2,124 +2,619 @@ package database
 
 import (
 	"Bitcoin/src/collection"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/infra"
 	"Bitcoin/src/model"
+	"encoding/binary"
 	"encoding/json"
-
-	"github.com/syndtr/goleveldb/leveldb"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
 )
 
 const (
 	BlockTable        = "Block"
-	BlockIndexTable   = "BlockIndex"
+	BlockTreeTable    = "BlockTree"
 	BlockContentTable = "BlockContent"
+	BlockHeaderTable  = "BlockHeader"
 	TxTable           = "Transaction"
+	UndoTable         = "Undo"
+	MetaTable         = "Meta"
+	// the hashes of the block index keyed by the number then the hash, to find the blocks of a number
+	BlockNumberTable = "BlockNumber"
+
+	// the key of the last sequence given to the block index
+	BlockSeqKey = "BlockSeq"
+	// the key of the highest number the block bodies are pruned to
+	PrunedKey = "Pruned"
+	// the key of the length of the records of the block bodies, updated when they are saved or pruned
+	BodySizeKey = "BodySize"
 )
 
 type IBlockDB interface {
 	SaveBlock(block *model.Block) error
 	GetBlock(hash []byte, includeBody bool) (*model.Block, error)
-	FilterBlock(prevBlockHash []byte) ([]*model.Block, error)
+	SaveHeader(header *model.Block) error
+	GetHeader(hash []byte) (*model.Block, error)
+	SaveIndex(index *model.BlockIndex) error
+	GetIndex(hash []byte) (*model.BlockIndex, error)
+	ListIndex() ([]*model.BlockIndex, error)
+	ListHashes(number uint64) ([][]byte, error)
+	Reindex() error
+	PruneBlocks(blocks []*model.Block, txs [][]byte, number uint64) (int64, error)
+	GetPruned() (uint64, error)
+	BodySize() (int64, error)
+	GetUndo(hash []byte) (*model.BlockUndo, error)
+	ConnectBlock(block *model.Block, utxo map[string]*model.Out) error
+	SaveUtxo(utxo map[string]*model.Out, best []byte) error
+	LoadUtxo() (map[string]*model.Out, []byte, error)
 	Size() (int64, error)
 	SaveTx(tx *model.Transaction) error
 	GetTx(hash []byte) (*model.Transaction, error)
+	Migrate() (int, error)
 	Close() error
 }
 
 type BlockDB struct {
 	IBaseDB
+	// compresses the records of the blocks, the contents and the transactions by snappy
+	Compress bool
+	// serializes the updates of the block index
+	lock sync.Mutex
 }
 
-func NewBlockDB(db *leveldb.DB) IBlockDB {
-	basedb := &BaseDB{Database: db}
-	blockdb := &BlockDB{IBaseDB: basedb}
+func NewBlockDB(basedb IBaseDB, compress bool) IBlockDB {
+	blockdb := &BlockDB{IBaseDB: basedb, Compress: compress}
 	return blockdb
 }
 
+// SaveBlock saves the block with its transactions and the outputs spent by it,
+// and marks its index data valid in the same batch
 func (db *BlockDB) SaveBlock(block *model.Block) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
 	batch := db.StartBatch()
+	if err := db.saveBlock(batch, block); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
+}
 
-	if err := batch.Save([]byte(BlockTable), block.Hash, block); err != nil {
+func (db *BlockDB) saveBlock(batch IBatch, block *model.Block) error {
+	if err := batch.Save([]byte(BlockTable), block.Hash, db.encode(model.EncodeBlockRecord(block))); err != nil {
 		return err
 	}
 
-	if err := batch.Save([]byte(BlockIndexTable), block.Prevhash, block.Hash); err != nil {
+	if err := db.saveIndex(batch, block, model.BlockDataValid); err != nil {
 		return err
 	}
 
-	if err := batch.Save([]byte(BlockContentTable), block.RootHash, block.Body); err != nil {
+	var size int64
+	if err := db.saveRecord(batch, BlockContentTable, block.RootHash, db.encode(model.EncodeContentRecord(block.GetTxs())), &size); err != nil {
 		return err
 	}
 
-	for _, tx := range block.Body.GetVals() {
-		if err := batch.Save([]byte(TxTable), tx.Hash, tx); err != nil {
+	for _, tx := range block.GetTxs() {
+		if err := db.saveRecord(batch, TxTable, tx.Hash, db.encode(model.EncodeTxRecord(tx)), &size); err != nil {
 			return err
 		}
 	}
 
-	return db.EndBatch(batch)
+	undo, err := db.makeUndo(block)
+	if err != nil {
+		return err
+	}
+	if undo != nil {
+		data, err := json.Marshal(undo)
+		if err != nil {
+			return err
+		}
+		if err := db.saveRecord(batch, UndoTable, block.Hash, data, &size); err != nil {
+			return err
+		}
+	}
+	return db.addBodySize(batch, size)
+}
+
+// saveRecord puts the record of the block body on the batch, the size is added by the change of its length
+func (db *BlockDB) saveRecord(batch IBatch, table string, key, data []byte, size *int64) error {
+	var old Raw
+	if _, err := db.Get([]byte(table), key, &old); err != nil {
+		return err
+	}
+	*size += int64(len(data) - len(old))
+	return batch.Save([]byte(table), key, data)
+}
+
+// addBodySize puts the size of the block bodies added by the delta on the batch
+func (db *BlockDB) addBodySize(batch IBatch, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	size, err := db.BodySize()
+	if err != nil {
+		return err
+	}
+	return batch.Save([]byte(MetaTable), []byte(BodySizeKey), size+delta)
+}
+
+func (db *BlockDB) GetUndo(hash []byte) (*model.BlockUndo, error) {
+	var undo model.BlockUndo
+	has, err := db.Get([]byte(UndoTable), hash, &undo)
+	if !has || err != nil {
+		return nil, err
+	}
+	return &undo, nil
+}
+
+// makeUndo collects the outputs spent by the block, they are set on the inputs by the validation,
+// otherwise found in the block or the saved transactions.
+// The block spending an unknown output can't be connected, so it has no undo
+func (db *BlockDB) makeUndo(block *model.Block) (*model.BlockUndo, error) {
+	undo := &model.BlockUndo{PrevOuts: make([]*model.Out, 0)}
+	txs := make(map[string]*model.Transaction)
+	for _, tx := range block.GetTxs() {
+		for _, in := range tx.Ins {
+			out := in.PrevOut
+			if out == nil {
+				prevTx := txs[string(in.PrevHash)]
+				if prevTx == nil {
+					var err error
+					if prevTx, err = db.GetTx(in.PrevHash); err != nil {
+						return nil, err
+					}
+				}
+				if prevTx == nil || in.Index >= uint32(len(prevTx.Outs)) {
+					return nil, nil
+				}
+				out = prevTx.Outs[in.Index]
+			}
+			undo.PrevOuts = append(undo.PrevOuts, out)
+		}
+		txs[string(tx.Hash)] = tx
+	}
+	return undo, nil
 }
 
 func (db *BlockDB) GetBlock(hash []byte, includeBody bool) (*model.Block, error) {
-	var block model.Block
-	has, err := db.Get([]byte(BlockTable), hash, &block)
+	var data Raw
+	has, err := db.Get([]byte(BlockTable), hash, &data)
 	if !has || err != nil {
 		return nil, err
 	}
+	block, err := decodeBlock(data)
+	if err != nil {
+		return nil, err
+	}
 
 	if includeBody {
-		var content collection.MerkleTree[*model.Transaction]
+		var content Raw
 		has, err = db.Get([]byte(BlockContentTable), block.RootHash, &content)
-		if !has || err != nil {
+		if err != nil {
 			return nil, err
 		}
-
-		for i := 0; i < len(content.Table[0]); i++ {
-			txhash := content.Table[0][i].Hash
-
-			tx, err := db.GetTx(txhash)
+		if !has {
+			pruned, err := db.GetPruned()
 			if err != nil {
 				return nil, err
 			}
-			content.Table[0][i].Val = tx
+			if block.Number <= pruned {
+				return nil, errors.ErrBlockPruned
+			}
+			return nil, nil
+		}
+
+		if block.Body, err = db.decodeContent(content); err != nil {
+			return nil, err
 		}
-		block.Body = &content
 	}
 
-	return &block, nil
+	return block, nil
 }
 
-func (db *BlockDB) FilterBlock(prevBlockHash []byte) ([]*model.Block, error) {
-	datalist, err := db.Filter([]byte(BlockIndexTable), prevBlockHash)
+// encode tags the binary record, and compresses it if required
+func (db *BlockDB) encode(record []byte) []byte {
+	return encodeRecord(record, db.Compress)
+}
+
+func decodeBlock(data []byte) (*model.Block, error) {
+	record, legacy, err := decodeRecord(data)
 	if err != nil {
 		return nil, err
 	}
+	if legacy {
+		var block model.Block
+		return &block, json.Unmarshal(record, &block)
+	}
+	return model.DecodeBlockRecord(record)
+}
 
-	blocks := make([]*model.Block, len(datalist))
+func decodeTx(data []byte) (*model.Transaction, error) {
+	record, legacy, err := decodeRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		var tx model.Transaction
+		return &tx, json.Unmarshal(record, &tx)
+	}
+	return model.DecodeTxRecord(record)
+}
+
+// decodeContent rebuilds the merkle tree from the transactions of the record,
+// the JSON record only has the hashes, its transactions are looked up
+func (db *BlockDB) decodeContent(data []byte) (*collection.MerkleTree[*model.Transaction], error) {
+	record, legacy, err := decodeRecord(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !legacy {
+		txs, err := model.DecodeContentRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		return collection.BuildTree(txs)
+	}
+
+	var content collection.MerkleTree[*model.Transaction]
+	if err := json.Unmarshal(record, &content); err != nil {
+		return nil, err
+	}
+	for _, leaf := range content.Table[0] {
+		tx, err := db.GetTx(leaf.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil {
+			return nil, errors.ErrTxNotFound
+		}
+		leaf.Val = tx
+	}
+	return &content, nil
+}
+
+// SaveHeader saves the header whose body is not downloaded yet, with its index
+func (db *BlockDB) SaveHeader(header *model.Block) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	batch := db.StartBatch()
+
+	if err := batch.Save([]byte(BlockHeaderTable), header.Hash, header); err != nil {
+		return err
+	}
+
+	if err := db.saveIndex(batch, header, model.BlockHeaderValid); err != nil {
+		return err
+	}
+
+	return db.EndBatch(batch)
+}
+
+// GetHeader finds the header of either a saved block or a saved header
+func (db *BlockDB) GetHeader(hash []byte) (*model.Block, error) {
+	block, err := db.GetBlock(hash, false)
+	if block != nil || err != nil {
+		return block, err
+	}
+
+	var header model.Block
+	has, err := db.Get([]byte(BlockHeaderTable), hash, &header)
+	if !has || err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// SaveIndex updates the index, e.g. the block is found invalid
+func (db *BlockDB) SaveIndex(index *model.BlockIndex) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.Save([]byte(BlockTreeTable), index.Hash, index)
+}
+
+func (db *BlockDB) GetIndex(hash []byte) (*model.BlockIndex, error) {
+	var index model.BlockIndex
+	has, err := db.Get([]byte(BlockTreeTable), hash, &index)
+	if !has || err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// ListIndex returns the index of all known headers and blocks
+func (db *BlockDB) ListIndex() ([]*model.BlockIndex, error) {
+	datalist, err := db.Filter([]byte(BlockTreeTable), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]*model.BlockIndex, len(datalist))
 	for i, data := range datalist {
-		var block model.Block
-		if err = json.Unmarshal(data, &block); err != nil {
+		var index model.BlockIndex
+		if err = json.Unmarshal(data, &index); err != nil {
 			return nil, err
 		}
-		blocks[i] = &block
+		indexes[i] = &index
 	}
+	return indexes, nil
+}
 
+// ListHashes returns the hashes of the blocks and the headers of the number in the index
+func (db *BlockDB) ListHashes(number uint64) ([][]byte, error) {
+	iter := db.Iterator([]byte(BlockNumberTable), &Range{Start: numberKey(number, nil), Limit: numberKey(number+1, nil)}, false)
+	defer iter.Release()
+
+	hashes := make([][]byte, 0)
+	for iter.Next() {
+		hashes = append(hashes, append([]byte(nil), iter.Value()...))
+	}
+	return hashes, iter.Error()
+}
+
+// numberKey puts the number before the hash in the big endian, so the keys are in the order of the numbers
+func numberKey(number uint64, hash []byte) []byte {
+	key := binary.BigEndian.AppendUint64(nil, number)
+	return append(key, hash...)
+}
+
+// Reindex rebuilds the block index from the saved blocks and headers, the blocks are indexed data valid,
+// the blocks of the same number keep the order they are seen if they are indexed before
+func (db *BlockDB) Reindex() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	blocks, err := db.listBlocks(BlockTable)
+	if err != nil {
+		return err
+	}
+	headers, err := db.listBlocks(BlockHeaderTable)
+	if err != nil {
+		return err
+	}
+	oldIndexes, err := db.ListIndex()
+	if err != nil {
+		return err
+	}
+
+	batch := db.StartBatch()
+	seqs := make(map[string]uint64, len(oldIndexes))
+	for _, index := range oldIndexes {
+		seqs[string(index.Hash)] = index.Seq
+		if err := batch.Delete([]byte(BlockTreeTable), index.Hash); err != nil {
+			return err
+		}
+		if err := batch.Delete([]byte(BlockNumberTable), numberKey(index.Number, index.Hash)); err != nil {
+			return err
+		}
+	}
+
+	statuses := make(map[string]model.BlockStatus, len(blocks)+len(headers))
+	all := make([]*model.Block, 0, len(blocks)+len(headers))
+	for _, header := range headers {
+		statuses[string(header.Hash)] = model.BlockHeaderValid
+		all = append(all, header)
+	}
+	for _, block := range blocks {
+		if _, ok := statuses[string(block.Hash)]; !ok {
+			all = append(all, block)
+		}
+		statuses[string(block.Hash)] = model.BlockDataValid
+	}
+
+	// the parents are indexed before their children
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Number != all[j].Number {
+			return all[i].Number < all[j].Number
+		}
+		return seqOf(seqs, all[i].Hash) < seqOf(seqs, all[j].Hash)
+	})
+
+	indexes := make(map[string]*model.BlockIndex, len(all))
+	for i, block := range all {
+		work := infra.Work(block.Bits)
+		if parent := indexes[string(block.Prevhash)]; parent != nil {
+			work = new(big.Int).Add(parent.Work, work)
+		}
+		index := &model.BlockIndex{
+			Hash:     block.Hash,
+			Prevhash: block.Prevhash,
+			Number:   block.Number,
+			Work:     work,
+			Status:   statuses[string(block.Hash)],
+			Seq:      uint64(i + 1),
+		}
+		indexes[string(block.Hash)] = index
+		if err := batch.Save([]byte(BlockTreeTable), block.Hash, index); err != nil {
+			return err
+		}
+		if err := batch.Save([]byte(BlockNumberTable), numberKey(block.Number, block.Hash), block.Hash); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Save([]byte(MetaTable), []byte(BlockSeqKey), uint64(len(all))); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
+}
+
+// seqOf returns the sequence of the block indexed before, the blocks not indexed are after them
+func seqOf(seqs map[string]uint64, hash []byte) uint64 {
+	if seq, ok := seqs[string(hash)]; ok {
+		return seq
+	}
+	return math.MaxUint64
+}
+
+// listBlocks returns the headers saved in the table of the blocks or the headers
+func (db *BlockDB) listBlocks(table string) ([]*model.Block, error) {
+	datalist, err := db.Filter([]byte(table), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*model.Block, len(datalist))
+	for i, data := range datalist {
+		if blocks[i], err = decodeBlock(data); err != nil {
+			return nil, err
+		}
+	}
 	return blocks, nil
 }
 
+// PruneBlocks deletes the bodies and the undo of the blocks, and the transactions,
+// the bodies of the blocks are pruned up to the number since then. It returns the size of the records deleted
+func (db *BlockDB) PruneBlocks(blocks []*model.Block, txs [][]byte, number uint64) (int64, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var size int64
+	batch := db.StartBatch()
+	deleted := make(map[string]bool)
+	deleteRecord := func(table string, key []byte) error {
+		// the same transaction may be in the blocks of two chains
+		k := string(makeKey([]byte(table), key))
+		if deleted[k] {
+			return nil
+		}
+		deleted[k] = true
+
+		var data Raw
+		if _, err := db.Get([]byte(table), key, &data); err != nil {
+			return err
+		}
+		size += int64(len(data))
+		return batch.Delete([]byte(table), key)
+	}
+
+	for _, block := range blocks {
+		if err := deleteRecord(BlockContentTable, block.RootHash); err != nil {
+			return 0, err
+		}
+		if err := deleteRecord(UndoTable, block.Hash); err != nil {
+			return 0, err
+		}
+	}
+	for _, hash := range txs {
+		if err := deleteRecord(TxTable, hash); err != nil {
+			return 0, err
+		}
+	}
+	if err := batch.Save([]byte(MetaTable), []byte(PrunedKey), number); err != nil {
+		return 0, err
+	}
+	if err := db.addBodySize(batch, -size); err != nil {
+		return 0, err
+	}
+	return size, db.EndBatch(batch)
+}
+
+// GetPruned returns the highest number the block bodies are pruned to, 0 if no block is pruned
+func (db *BlockDB) GetPruned() (uint64, error) {
+	var number uint64
+	if _, err := db.Get([]byte(MetaTable), []byte(PrunedKey), &number); err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+// BodySize returns the length of the records of the block bodies with their transactions and undo,
+// the size of the storage is approximate and not reduced until the deleted records are compacted
+func (db *BlockDB) BodySize() (int64, error) {
+	var size int64
+	if _, err := db.Get([]byte(MetaTable), []byte(BodySizeKey), &size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// countBodySize sums the length of the records of the block bodies by scanning their tables
+func (db *BlockDB) countBodySize() (int64, error) {
+	var total int64
+	for _, table := range []string{BlockContentTable, TxTable, UndoTable} {
+		iter := db.Iterator([]byte(table), nil, false)
+		for iter.Next() {
+			total += int64(len(iter.Value()))
+		}
+		err := iter.Error()
+		iter.Release()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// saveIndex puts the index of the block with the status on the batch, the work is accumulated on its parent,
+// the index keeps the sequence of the first time the block is seen
+func (db *BlockDB) saveIndex(batch IBatch, block *model.Block, status model.BlockStatus) error {
+	index, err := db.GetIndex(block.Hash)
+	if err != nil {
+		return err
+	}
+
+	if index == nil {
+		work := infra.Work(block.Bits)
+		parent, err := db.GetIndex(block.Prevhash)
+		if err != nil {
+			return err
+		}
+		if parent != nil {
+			work = new(big.Int).Add(parent.Work, work)
+		}
+
+		var seq uint64
+		if _, err := db.Get([]byte(MetaTable), []byte(BlockSeqKey), &seq); err != nil {
+			return err
+		}
+		seq++
+		if err := batch.Save([]byte(MetaTable), []byte(BlockSeqKey), seq); err != nil {
+			return err
+		}
+
+		index = &model.BlockIndex{Hash: block.Hash, Prevhash: block.Prevhash, Number: block.Number, Work: work, Seq: seq}
+		if err := batch.Save([]byte(BlockNumberTable), numberKey(block.Number, block.Hash), block.Hash); err != nil {
+			return err
+		}
+	}
+
+	// the failed block stays failed, and the stored block is not downgraded by its header
+	if index.Failed() || index.Status == model.BlockDataValid && status == model.BlockHeaderValid {
+		return nil
+	}
+	index.Status = status
+	return batch.Save([]byte(BlockTreeTable), block.Hash, index)
+}
+
 func (db *BlockDB) Size() (int64, error) {
 	return db.IBaseDB.Size([]byte(BlockTable))
 }
 
 func (db *BlockDB) SaveTx(tx *model.Transaction) error {
-	return db.Save([]byte(TxTable), tx.Hash, tx)
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var size int64
+	batch := db.StartBatch()
+	if err := db.saveRecord(batch, TxTable, tx.Hash, db.encode(model.EncodeTxRecord(tx)), &size); err != nil {
+		return err
+	}
+	if err := db.addBodySize(batch, size); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
 }
 
 func (db *BlockDB) GetTx(hash []byte) (*model.Transaction, error) {
-	var tx model.Transaction
-	has, err := db.Get([]byte(TxTable), hash, &tx)
+	var data Raw
+	has, err := db.Get([]byte(TxTable), hash, &data)
 	if !has || err != nil {
 		return nil, err
 	}
-
-	return &tx, nil
+	return decodeTx(data)
 }
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"Bitcoin/src/errors"
+	"sort"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	LevelDBEngine = "leveldb"
+	MemoryEngine  = "memory"
+
+	DefaultEngine = LevelDBEngine
+)
+
+// Engine opens the storage at the path, the path may be ignored by the engine not on the disk
+type Engine func(path string) (IBaseDB, error)
+
+var (
+	engineLock sync.RWMutex
+	engines    = map[string]Engine{
+		LevelDBEngine: openLevelDB,
+		MemoryEngine:  openMemoryDB,
+	}
+)
+
+// Register adds the storage engine with the name, the engine with the same name is replaced
+func Register(name string, engine Engine) {
+	engineLock.Lock()
+	defer engineLock.Unlock()
+
+	engines[name] = engine
+}
+
+// Engines returns the names of the registered storage engines in order
+func Engines() []string {
+	engineLock.RLock()
+	defer engineLock.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the storage with the engine of the name, the empty name is the default engine
+func Open(name, path string) (IBaseDB, error) {
+	if name == "" {
+		name = DefaultEngine
+	}
+
+	engineLock.RLock()
+	engine, ok := engines[name]
+	engineLock.RUnlock()
+	if !ok {
+		return nil, errors.ErrStorageEngineUnknown
+	}
+	return engine(path)
+}
+
+func openLevelDB(path string) (IBaseDB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BaseDB{Database: db}, nil
+}
+
+func openMemoryDB(path string) (IBaseDB, error) {
+	return NewMemoryDB(), nil
+}
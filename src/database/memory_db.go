@@ -0,0 +1,250 @@
+package database
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// MemoryDB keeps the keys in the memory in the key order, nothing is written to the disk
+type MemoryDB struct {
+	lock  sync.RWMutex
+	items map[string][]byte
+	keys  []string
+}
+
+func NewMemoryDB() IBaseDB {
+	return &MemoryDB{items: make(map[string][]byte)}
+}
+
+func (db *MemoryDB) Save(prefix []byte, key, val any) error {
+	batch := db.StartBatch()
+	if err := batch.Save(prefix, key, val); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
+}
+
+func (db *MemoryDB) Get(prefix, key []byte, val any) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return getMemory(db.items, prefix, key, val)
+}
+
+func (db *MemoryDB) Delete(prefix, key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.delete(string(makeKey(prefix, key)))
+	return nil
+}
+
+// Filter returns the values of the table from the key start in the key order
+func (db *MemoryDB) Filter(prefix, start []byte) ([][]byte, error) {
+	iter := db.Iterator(prefix, &Range{Start: start}, false)
+	defer iter.Release()
+
+	vals := make([][]byte, 0)
+	for iter.Next() {
+		vals = append(vals, iter.Value())
+	}
+	return vals, iter.Error()
+}
+
+// Iterator walks the keys of the table at the time it is made
+func (db *MemoryDB) Iterator(prefix []byte, r *Range, reverse bool) IIterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return newMemoryIterator(db.items, db.keys, prefix, r, reverse)
+}
+
+// Snapshot copies the keys, the later writes are not seen by it
+func (db *MemoryDB) Snapshot() (ISnapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	items := make(map[string][]byte, len(db.items))
+	for key, val := range db.items {
+		items[key] = val
+	}
+	keys := append([]string(nil), db.keys...)
+	return &MemorySnapshot{items: items, keys: keys}, nil
+}
+
+// Size returns the size of the keys and the values of the table
+func (db *MemoryDB) Size(prefix []byte) (int64, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var size int64
+	for i := sort.SearchStrings(db.keys, string(prefix)); i < len(db.keys); i++ {
+		if !bytes.HasPrefix([]byte(db.keys[i]), prefix) {
+			break
+		}
+		size += int64(len(db.keys[i]) + len(db.items[db.keys[i]]))
+	}
+	return size, nil
+}
+
+func (db *MemoryDB) StartBatch() IBatch {
+	return &MemoryBatch{}
+}
+
+// EndBatch applies the updates of the batch at once
+func (db *MemoryDB) EndBatch(batch IBatch) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for _, item := range batch.(*MemoryBatch).items {
+		if item.deleted {
+			db.delete(item.key)
+		} else {
+			db.put(item.key, item.val)
+		}
+	}
+	return nil
+}
+
+func (db *MemoryDB) Close() error {
+	return nil
+}
+
+func (db *MemoryDB) put(key string, val []byte) {
+	if _, ok := db.items[key]; !ok {
+		i := sort.SearchStrings(db.keys, key)
+		db.keys = append(db.keys, "")
+		copy(db.keys[i+1:], db.keys[i:])
+		db.keys[i] = key
+	}
+	db.items[key] = val
+}
+
+func (db *MemoryDB) delete(key string) {
+	if _, ok := db.items[key]; !ok {
+		return
+	}
+	delete(db.items, key)
+	i := sort.SearchStrings(db.keys, key)
+	db.keys = append(db.keys[:i], db.keys[i+1:]...)
+}
+
+type MemorySnapshot struct {
+	items map[string][]byte
+	keys  []string
+}
+
+func (snapshot *MemorySnapshot) Get(prefix, key []byte, val any) (bool, error) {
+	return getMemory(snapshot.items, prefix, key, val)
+}
+
+func (snapshot *MemorySnapshot) Iterator(prefix []byte, r *Range, reverse bool) IIterator {
+	return newMemoryIterator(snapshot.items, snapshot.keys, prefix, r, reverse)
+}
+
+func (snapshot *MemorySnapshot) Release() {
+	snapshot.items, snapshot.keys = nil, nil
+}
+
+func getMemory(items map[string][]byte, prefix, key []byte, val any) (bool, error) {
+	data, ok := items[string(makeKey(prefix, key))]
+	if !ok {
+		return false, nil
+	}
+	return true, unmarshal(data, val)
+}
+
+type MemoryIterator struct {
+	keys   []string
+	vals   [][]byte
+	prefix int
+	pos    int
+}
+
+// newMemoryIterator copies the keys of the range, the keys are sorted
+func newMemoryIterator(items map[string][]byte, keys []string, prefix []byte, r *Range, reverse bool) IIterator {
+	table := string(makeKey(prefix, nil))
+	start, limit := table, ""
+	if r != nil && r.Start != nil {
+		start = string(makeKey(prefix, r.Start))
+	}
+	if r != nil && r.Limit != nil {
+		limit = string(makeKey(prefix, r.Limit))
+	}
+
+	iter := &MemoryIterator{prefix: len(table), pos: -1}
+	for i := sort.SearchStrings(keys, start); i < len(keys); i++ {
+		key := keys[i]
+		if len(key) < len(table) || key[:len(table)] != table || limit != "" && key >= limit {
+			break
+		}
+		iter.keys = append(iter.keys, key)
+		iter.vals = append(iter.vals, items[key])
+	}
+
+	if reverse {
+		for i, j := 0, len(iter.keys)-1; i < j; i, j = i+1, j-1 {
+			iter.keys[i], iter.keys[j] = iter.keys[j], iter.keys[i]
+			iter.vals[i], iter.vals[j] = iter.vals[j], iter.vals[i]
+		}
+	}
+	return iter
+}
+
+func (iter *MemoryIterator) Next() bool {
+	if iter.pos+1 >= len(iter.keys) {
+		iter.pos = len(iter.keys)
+		return false
+	}
+	iter.pos++
+	return true
+}
+
+func (iter *MemoryIterator) Key() []byte {
+	return []byte(iter.keys[iter.pos][iter.prefix:])
+}
+
+func (iter *MemoryIterator) Value() []byte {
+	return iter.vals[iter.pos]
+}
+
+func (iter *MemoryIterator) Error() error {
+	return nil
+}
+
+func (iter *MemoryIterator) Release() {
+	iter.keys, iter.vals = nil, nil
+}
+
+type memoryBatchItem struct {
+	key     string
+	val     []byte
+	deleted bool
+}
+
+type MemoryBatch struct {
+	items []*memoryBatchItem
+}
+
+func (batch *MemoryBatch) Save(prefix []byte, key, val any) error {
+	keydata, err := serialize(key)
+	if err != nil {
+		return err
+	}
+
+	valdata, err := serialize(val)
+	if err != nil {
+		return err
+	}
+
+	// the caller may reuse the buffer of the value
+	valdata = append([]byte(nil), valdata...)
+	batch.items = append(batch.items, &memoryBatchItem{key: string(makeKey(prefix, keydata)), val: valdata})
+	return nil
+}
+
+func (batch *MemoryBatch) Delete(prefix, key []byte) error {
+	batch.items = append(batch.items, &memoryBatchItem{key: string(makeKey(prefix, key)), deleted: true})
+	return nil
+}
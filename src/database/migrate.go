@@ -0,0 +1,146 @@
+package database
+
+import (
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+)
+
+// the records rewritten by one batch of the migration
+const migrateBatchSize = 1000
+
+// Migrate rewrites the JSON records of the blocks, the contents and the transactions in the binary format once,
+// the records already binary are kept, so the interrupted migration is continued by the next one.
+// The block index saved before is keyed by the number too, and the size of the block bodies is counted.
+// It returns the number of the records rewritten
+func (db *BlockDB) Migrate() (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var format uint64
+	if _, err := db.Get([]byte(MetaTable), []byte(FormatKey), &format); err != nil {
+		return 0, err
+	}
+	if format >= SizeFormat {
+		return 0, nil
+	}
+
+	total := 0
+	if format < RecordFormat {
+		// the transactions are rewritten before the contents, which look them up
+		for _, table := range []string{BlockTable, TxTable, BlockContentTable} {
+			n, err := db.migrateTable(table)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+		if err := db.Save([]byte(MetaTable), []byte(FormatKey), RecordFormat); err != nil {
+			return total, err
+		}
+	}
+
+	if format < NumberFormat {
+		if err := db.migrateNumbers(); err != nil {
+			return total, err
+		}
+		if err := db.Save([]byte(MetaTable), []byte(FormatKey), NumberFormat); err != nil {
+			return total, err
+		}
+	}
+
+	size, err := db.countBodySize()
+	if err != nil {
+		return total, err
+	}
+	if err := db.Save([]byte(MetaTable), []byte(BodySizeKey), size); err != nil {
+		return total, err
+	}
+	return total, db.Save([]byte(MetaTable), []byte(FormatKey), SizeFormat)
+}
+
+// migrateNumbers keys the block index by the number, saving the key again is harmless
+func (db *BlockDB) migrateNumbers() error {
+	indexes, err := db.ListIndex()
+	if err != nil {
+		return err
+	}
+
+	batch := db.StartBatch()
+	for i, index := range indexes {
+		if err := batch.Save([]byte(BlockNumberTable), numberKey(index.Number, index.Hash), index.Hash); err != nil {
+			return err
+		}
+		if (i+1)%migrateBatchSize == 0 {
+			if err := db.EndBatch(batch); err != nil {
+				return err
+			}
+			batch = db.StartBatch()
+		}
+	}
+	return db.EndBatch(batch)
+}
+
+func (db *BlockDB) migrateTable(table string) (int, error) {
+	snapshot, err := db.Snapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer snapshot.Release()
+
+	iter := snapshot.Iterator([]byte(table), nil, false)
+	defer iter.Release()
+
+	total := 0
+	batch := db.StartBatch()
+	for iter.Next() {
+		if len(iter.Value()) == 0 || iter.Value()[0] != recordJSON {
+			continue
+		}
+
+		record, err := db.migrateRecord(table, iter.Value())
+		if err != nil {
+			return total, err
+		}
+		key := append([]byte(nil), iter.Key()...)
+		if err := batch.Save([]byte(table), key, db.encode(record)); err != nil {
+			return total, err
+		}
+
+		total++
+		if total%migrateBatchSize == 0 {
+			if err := db.EndBatch(batch); err != nil {
+				return total, err
+			}
+			batch = db.StartBatch()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return total, err
+	}
+	return total, db.EndBatch(batch)
+}
+
+// migrateRecord converts the JSON record of the table to the binary record
+func (db *BlockDB) migrateRecord(table string, data []byte) ([]byte, error) {
+	switch table {
+	case BlockTable:
+		block, err := decodeBlock(data)
+		if err != nil {
+			return nil, err
+		}
+		return model.EncodeBlockRecord(block), nil
+	case TxTable:
+		tx, err := decodeTx(data)
+		if err != nil {
+			return nil, err
+		}
+		return model.EncodeTxRecord(tx), nil
+	case BlockContentTable:
+		content, err := db.decodeContent(data)
+		if err != nil {
+			return nil, err
+		}
+		return model.EncodeContentRecord(content.GetVals()), nil
+	}
+	return nil, errors.ErrRecordUnknown
+}
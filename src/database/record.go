@@ -0,0 +1,50 @@
+package database
+
+import (
+	"Bitcoin/src/errors"
+
+	"github.com/golang/snappy"
+)
+
+// the records of the blocks, the contents and the transactions start with the tag of their format,
+// the records saved before are JSON, which start with '{'
+const (
+	recordRaw    byte = 1
+	recordSnappy byte = 2
+	recordJSON   byte = '{'
+
+	// the key of the format of the records in the data dir
+	FormatKey = "Format"
+	// the records are binary since the format
+	RecordFormat uint64 = 1
+	// the block index is keyed by the number too since the format
+	NumberFormat uint64 = 2
+	// the size of the block bodies is counted in the meta since the format
+	SizeFormat uint64 = 3
+)
+
+// encodeRecord tags the binary record, and compresses it if required
+func encodeRecord(data []byte, compress bool) []byte {
+	if compress {
+		return append([]byte{recordSnappy}, snappy.Encode(nil, data)...)
+	}
+	return append([]byte{recordRaw}, data...)
+}
+
+// decodeRecord returns the binary record without the tag, or the JSON record and true
+func decodeRecord(data []byte) ([]byte, bool, error) {
+	if len(data) == 0 {
+		return nil, false, errors.ErrRecordUnknown
+	}
+
+	switch data[0] {
+	case recordRaw:
+		return data[1:], false, nil
+	case recordSnappy:
+		record, err := snappy.Decode(nil, data[1:])
+		return record, false, err
+	case recordJSON:
+		return data, true, nil
+	}
+	return nil, false, errors.ErrRecordUnknown
+}
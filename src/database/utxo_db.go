@@ -0,0 +1,88 @@
+package database
+
+import (
+	"Bitcoin/src/model"
+	"encoding/json"
+)
+
+const (
+	UtxoTable = "Utxo"
+
+	// the key of the block the utxo is at
+	BestBlockKey = "BestBlock"
+)
+
+// utxoEntry keeps the key with the output, the table is listed without the keys
+type utxoEntry struct {
+	Key string
+	Out *model.Out
+}
+
+// ConnectBlock saves the block extending the best block with the utxo changed by it in one batch,
+// a nil output of the changes means the output is spent
+func (db *BlockDB) ConnectBlock(block *model.Block, utxo map[string]*model.Out) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	batch := db.StartBatch()
+	if err := db.saveBlock(batch, block); err != nil {
+		return err
+	}
+	if err := saveUtxo(batch, utxo, block.Hash); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
+}
+
+// SaveUtxo saves the utxo changes and the best block they lead to in one batch, a nil best block clears it
+func (db *BlockDB) SaveUtxo(utxo map[string]*model.Out, best []byte) error {
+	batch := db.StartBatch()
+	if err := saveUtxo(batch, utxo, best); err != nil {
+		return err
+	}
+	return db.EndBatch(batch)
+}
+
+// LoadUtxo returns the utxo and the best block it is at, the best block is nil if the utxo is never saved
+func (db *BlockDB) LoadUtxo() (map[string]*model.Out, []byte, error) {
+	var best []byte
+	has, err := db.Get([]byte(MetaTable), []byte(BestBlockKey), &best)
+	if !has || err != nil {
+		return map[string]*model.Out{}, nil, err
+	}
+
+	datalist, err := db.Filter([]byte(UtxoTable), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	utxo := make(map[string]*model.Out, len(datalist))
+	for _, data := range datalist {
+		var entry utxoEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, nil, err
+		}
+		utxo[entry.Key] = entry.Out
+	}
+	return utxo, best, nil
+}
+
+func saveUtxo(batch IBatch, utxo map[string]*model.Out, best []byte) error {
+	for key, out := range utxo {
+		var err error
+		if out == nil {
+			err = batch.Delete([]byte(UtxoTable), []byte(key))
+		} else {
+			err = batch.Save([]byte(UtxoTable), []byte(key), &utxoEntry{Key: key, Out: out})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if best == nil {
+		return batch.Delete([]byte(MetaTable), []byte(BestBlockKey))
+	}
+	// the raw bytes are not saved as json, so save the pointer
+	return batch.Save([]byte(MetaTable), []byte(BestBlockKey), &best)
+}
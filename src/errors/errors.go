@@ -17,7 +17,12 @@ var (
 	ErrInLenOutOfIndex        = errors.New("transaction input out of index of prev transaction outputs")
 	ErrInTooLate              = errors.New("transaction input is later than prev transaction")
 	ErrInSigInvalid           = errors.New("transaction input signature invalid")
+	ErrInDoubleSpend          = errors.New("transaction input already spent")
+	ErrInPubkeyMismatch       = errors.New("transaction input pubkey mismatch with the address of prev output")
+	ErrOutPointInvalid        = errors.New("invalid output point")
 	ErrOutLenMismatch         = errors.New("transaction output length mismatch")
+	ErrEncodingVersion        = errors.New("unsupported encoding version")
+	ErrEncodingMalformed      = errors.New("malformed encoding")
 	ErrMerkleInvalid          = errors.New("invalid merkle tree")
 	ErrBlockExist             = errors.New("block already exists")
 	ErrBlockNotFound          = errors.New("block not found")
@@ -25,9 +30,38 @@ var (
 	ErrBlockContentInvalid    = errors.New("invalid block content")
 	ErrBlockNumberInvalid     = errors.New("invalid block number")
 	ErrBlockNoValidHash       = errors.New("no valid block hash")
+	ErrBlockBitsMismatch      = errors.New("block bits mismatch with the expected target")
+	ErrBlockIntervalMismatch  = errors.New("block total interval mismatch with the expected one")
+	ErrBlockRewardMismatch    = errors.New("coinbase value mismatch with the block reward and fees")
+	ErrGenesisInvalid         = errors.New("genesis block mismatch with its specification")
+	ErrGenesisMismatch        = errors.New("stored genesis block mismatch with the configured one")
 	ErrPrevBlockNotFound      = errors.New("prev block not found")
 	ErrBlockTooLate           = errors.New("block too late")
+	ErrBlockInvalid           = errors.New("block or its ancestor is invalid")
+	ErrBlockUndoNotFound      = errors.New("block undo not found")
+	ErrBlockUndoMismatch      = errors.New("block undo mismatch with the block inputs")
+	ErrBlockPruned            = errors.New("block body is pruned")
+	ErrPeerVersionTooOld      = errors.New("peer protocol version too old")
+	ErrPeerNetworkMismatch    = errors.New("peer network mismatch")
+	ErrPeerGenesisMismatch    = errors.New("peer genesis block mismatch")
+	ErrPeerNotFound           = errors.New("peer not found")
+	ErrPeerNotHandshaked      = errors.New("peer not handshaked")
+	ErrPeerAddrMismatch       = errors.New("peer address mismatch with the connection")
+	ErrInvTypeUnknown         = errors.New("unknown inventory type")
+	ErrBlockDownloadTimeout   = errors.New("block download timeout")
+	ErrBlockDownloadFailed    = errors.New("no peer to download the block from")
 	ErrServerCancelMining     = errors.New("server cancel the mining")
 	ErrServerStopping         = errors.New("server stopping")
 	ErrAccountNotEnoughValues = errors.New("account not enough values")
+	ErrAddressInvalid         = errors.New("invalid address")
+	ErrAddressChecksum        = errors.New("address checksum mismatch")
+	ErrAddressNetworkMismatch = errors.New("address belongs to another network")
+	ErrNetworkUnknown         = errors.New("unknown network")
+	ErrKeyInvalid             = errors.New("key is not an ECDSA key in PEM")
+	ErrKeystoreVersion        = errors.New("unsupported keystore version")
+	ErrKeystorePassword       = errors.New("wrong keystore password")
+	ErrWalletNotEnoughValues  = errors.New("wallet not enough values")
+	ErrWalletValueOverflow    = errors.New("wallet value overflow")
+	ErrStorageEngineUnknown   = errors.New("unknown storage engine")
+	ErrRecordUnknown          = errors.New("unknown record format")
 )
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"Bitcoin/src/model"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+var (
+	outs  = flag.String("outs", "outs.json", "the path of the outputs file of the genesis transaction")
+	level = flag.Uint64("level", 8, "the difficulty level of the genesis block")
+	out   = flag.String("out", "genesis.json", "the path of the generated genesis file")
+)
+
+// mine the genesis block and emit its specification, the file is shared by all nodes of the network
+func main() {
+	flag.Parse()
+
+	data, err := os.ReadFile(*outs)
+	if err != nil {
+		log.Fatalf("read outputs file error: %v", err)
+	}
+
+	var genesisOuts []*model.Out
+	if err := json.Unmarshal(data, &genesisOuts); err != nil {
+		log.Fatalf("unmarshal outputs error: %v", err)
+	}
+
+	genesis, err := model.MineGenesis(context.Background(), genesisOuts, *level, time.Now())
+	if err != nil {
+		log.Fatalf("mine genesis error: %v", err)
+	}
+
+	data, err = json.MarshalIndent(genesis, "", "    ")
+	if err != nil {
+		log.Fatalf("marshal genesis error: %v", err)
+	}
+
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("write genesis file error: %v", err)
+	}
+	log.Printf("mined genesis block: %x", genesis.Hash)
+}
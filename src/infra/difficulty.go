@@ -1,29 +1,101 @@
 package infra
 
 import (
-	"math"
+	"math/big"
 )
 
-func ComputeDifficulty(hash []byte) float64 {
-	var n float64 = 0
+// the target is a 256-bit integer, a block hash meets the target if it is not greater than the target,
+// on the wire the target is encoded as the compact "bits" like bitcoin:
+// the high byte is the size of the target in bytes, the low 3 bytes are the most significant bytes
+// and 0x00800000 is the sign bit
 
-	for i := 0; i < len(hash); i++ {
-		n = n + float64(hash[i])
-		n = n * math.Pow(2, 8) //TODO: slow
+var (
+	MaxTarget = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+func HashToBig(hash []byte) *big.Int {
+	return new(big.Int).SetBytes(hash)
+}
+
+func CompactToBig(bits uint32) *big.Int {
+	mantissa := bits & 0x007fffff
+	negative := bits&0x00800000 != 0
+	exponent := uint(bits >> 24)
+
+	var n *big.Int
+	if exponent <= 3 {
+		mantissa >>= 8 * (3 - exponent)
+		n = big.NewInt(int64(mantissa))
+	} else {
+		n = big.NewInt(int64(mantissa))
+		n.Lsh(n, 8*(exponent-3))
 	}
 
+	if negative {
+		n = n.Neg(n)
+	}
 	return n
 }
 
-func MakeDifficulty(level uint64) []byte {
-	difficulty := make([]byte, 32)
-	for i := 0; i < 32; i++ {
-		difficulty[i] = 255
+// BigToCompact rounds the target down to the precision of the compact encoding
+func BigToCompact(n *big.Int) uint32 {
+	if n.Sign() == 0 {
+		return 0
+	}
+
+	var mantissa uint32
+	exponent := uint(len(n.Bytes()))
+	if exponent <= 3 {
+		mantissa = uint32(new(big.Int).Abs(n).Bits()[0])
+		mantissa <<= 8 * (3 - exponent)
+	} else {
+		tn := new(big.Int).Abs(n)
+		mantissa = uint32(tn.Rsh(tn, 8*(exponent-3)).Bits()[0])
+	}
+
+	// the mantissa is signed, so move the sign bit into the exponent
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		exponent++
+	}
+
+	bits := uint32(exponent<<24) | mantissa
+	if n.Sign() < 0 {
+		bits |= 0x00800000
+	}
+	return bits
+}
+
+// MakeTarget returns the target of the hashes with level leading zero bits
+func MakeTarget(level uint64) *big.Int {
+	if level >= 256 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Rsh(MaxTarget, uint(level))
+}
+
+func MakeBits(level uint64) uint32 {
+	return BigToCompact(MakeTarget(level))
+}
+
+// ValidTarget checks the bits is a positive target of 256 bits
+func ValidTarget(bits uint32) bool {
+	target := CompactToBig(bits)
+	return target.Sign() > 0 && target.Cmp(MaxTarget) <= 0
+}
+
+func CheckProofOfWork(hash []byte, bits uint32) bool {
+	if !ValidTarget(bits) {
+		return false
 	}
-	for z := uint64(0); z < level; z++ {
-		p := z / 8
-		q := 7 - z%8
-		difficulty[p] = difficulty[p] ^ (1 << q)
+	return HashToBig(hash).Cmp(CompactToBig(bits)) <= 0
+}
+
+// Work returns the expected number of hashes to meet the target of the bits, which is 2^256 / (target + 1)
+func Work(bits uint32) *big.Int {
+	target := CompactToBig(bits)
+	if target.Sign() <= 0 {
+		return big.NewInt(0)
 	}
-	return difficulty
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), target.Add(target, big.NewInt(1)))
 }
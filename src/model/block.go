@@ -7,10 +7,12 @@ import (
 	"Bitcoin/src/infra"
 	"Bitcoin/src/protocol"
 	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 	"time"
 
@@ -23,7 +25,7 @@ type Block struct {
 	Prevhash      []byte
 	RootHash      []byte
 	Nonce         uint32
-	Difficulty    float64
+	Bits          uint32
 	Time          time.Time
 	Body          *collection.MerkleTree[*Transaction]
 	TotalInterval uint64
@@ -36,7 +38,7 @@ type jBlock struct {
 	Prevhash      string    `json:"prevhash,omitempty"`
 	RootHash      string    `json:"roothash,omitempty"`
 	Nonce         uint32    `json:"nonce,omitempty"`
-	Difficulty    string    `json:"difficulty,omitempty"`
+	Bits          string    `json:"bits,omitempty"`
 	Timestamp     time.Time `json:"timestamp,omitempty"`
 	TotalInterval uint64
 	Miner         string
@@ -49,7 +51,7 @@ func (block *Block) MarshalJSON() ([]byte, error) {
 		Prevhash:      hex.EncodeToString(block.Prevhash),
 		RootHash:      hex.EncodeToString(block.RootHash),
 		Nonce:         block.Nonce,
-		Difficulty:    fmt.Sprintf("%.0f", block.Difficulty),
+		Bits:          fmt.Sprintf("%08x", block.Bits),
 		Timestamp:     block.Time,
 		TotalInterval: block.TotalInterval,
 		Miner:         block.Miner,
@@ -81,12 +83,12 @@ func (block *Block) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	difficulty, err := strconv.ParseFloat(s.Difficulty, 64)
+	bits, err := strconv.ParseUint(s.Bits, 16, 32)
 	if err != nil {
 		return err
 	}
 
-	block.Difficulty = difficulty
+	block.Bits = uint32(bits)
 	block.Nonce = s.Nonce
 	block.Time = s.Timestamp
 	block.TotalInterval = s.TotalInterval
@@ -95,24 +97,32 @@ func (block *Block) UnmarshalJSON(data []byte) error {
 }
 
 func BlockFrom(request *protocol.BlockReq) (*Block, error) {
-	var block Block
 	var tree collection.MerkleTree[*Transaction]
 
 	err := json.Unmarshal(request.Content, &tree)
 	if err != nil {
 		return nil, err
 	}
+	block := HeaderFrom(request)
 	block.Body = &tree
 
-	automapper.MapLoose(request, &block)
-	block.Time = time.UnixMilli(request.Timestamp)
-	return &block, nil
+	// the content only has the hashes, the transactions are sent beside it
+	if len(tree.Table) > 0 {
+		leaves := tree.Table[0]
+		if len(request.Txs) != len(leaves) {
+			return nil, errors.ErrBlockContentInvalid
+		}
+		for i, leaf := range leaves {
+			tx := TransactionFrom(request.Txs[i])
+			tx.BlockHash = block.Hash
+			leaf.Val = tx
+		}
+	}
+	return block, nil
 }
 
 func BlockTo(block *Block) (*protocol.BlockReq, error) {
-	var request protocol.BlockReq
-	automapper.MapLoose(block, &request)
-	request.Timestamp = block.Time.UnixMilli()
+	request := HeaderTo(block)
 
 	body, err := json.Marshal(block.Body)
 	if err != nil {
@@ -120,10 +130,36 @@ func BlockTo(block *Block) (*protocol.BlockReq, error) {
 	}
 
 	request.Content = body
-	return &request, nil
+
+	if block.Body != nil {
+		for _, tx := range block.GetTxs() {
+			request.Txs = append(request.Txs, TransactionTo(tx))
+		}
+	}
+	return request, nil
+}
+
+// HeaderFrom converts the request to the block without the body
+func HeaderFrom(request *protocol.BlockReq) *Block {
+	var block Block
+	automapper.MapLoose(request, &block)
+	block.Time = time.UnixMilli(request.Timestamp)
+	return &block
+}
+
+// HeaderTo converts the block to the request without the content and the transactions
+func HeaderTo(block *Block) *protocol.BlockReq {
+	var request protocol.BlockReq
+	automapper.MapLoose(block, &request)
+	request.Timestamp = block.Time.UnixMilli()
+	return &request
 }
 
 func (block *Block) FindHash(ctx context.Context) ([]byte, error) {
+	// encode the header once and only patch the nonce for each try
+	header := EncodeHeader(block)
+	offset := headerNonceOffset(block)
+
 	var nonce uint32
 	//return err if not find valid hash
 	for nonce = 1; nonce < math.MaxUint32; nonce++ {
@@ -132,15 +168,11 @@ func (block *Block) FindHash(ctx context.Context) ([]byte, error) {
 			return nil, err
 		}
 
-		block.Nonce = nonce
-
-		hash, err := block.ComputeHash()
-		if err != nil {
-			return nil, err
-		}
+		binary.BigEndian.PutUint32(header[offset:], nonce)
+		hash := cryptography.HashBytes(header)
 
-		actual := infra.ComputeDifficulty(hash)
-		if actual <= block.Difficulty {
+		if infra.CheckProofOfWork(hash, block.Bits) {
+			block.Nonce = nonce
 			return hash, nil
 		}
 	}
@@ -150,38 +182,36 @@ func (block *Block) FindHash(ctx context.Context) ([]byte, error) {
 }
 
 func (block *Block) ComputeHash() ([]byte, error) {
-	//TODO: more general way to compute hash, use tag and no need assign the value of each field
-	newblock := &Block{
-		Number:     block.Number,
-		Prevhash:   block.Prevhash,
-		RootHash:   block.RootHash,
-		Nonce:      block.Nonce,
-		Difficulty: block.Difficulty,
-		Time:       block.Time,
-	}
-
-	return cryptography.Hash(newblock)
+	return cryptography.HashBytes(EncodeHeader(block)), nil
 }
 
 func (block *Block) GetTxs() []*Transaction {
 	return block.Body.GetVals()
 }
 
-func (block *Block) GetNextDifficulty(blocksPerDifficulty, expectBlockInterval uint64) float64 {
-	difficulty := block.Difficulty
-	if block.Number%blocksPerDifficulty == 0 {
-		avgInterval := block.TotalInterval / (blocksPerDifficulty)
-		factor := float64((avgInterval / expectBlockInterval))
-		if factor > 4 {
-			factor = 4
-		}
-		if factor < 0.25 {
-			factor = 0.25
-		}
-		difficulty = block.Difficulty * factor
+// GetNextBits retargets after every blocksPerDifficulty blocks by the ratio of the actual interval to the expected one,
+// the block interval is in seconds and the total interval is in milliseconds
+func (block *Block) GetNextBits(blocksPerDifficulty, expectBlockInterval uint64) uint32 {
+	if block.Number%blocksPerDifficulty != 0 {
+		return block.Bits
+	}
+
+	expect := blocksPerDifficulty * expectBlockInterval * 1000
+	actual := block.TotalInterval
+	if actual < expect/4 {
+		actual = expect / 4
+	}
+	if actual > expect*4 {
+		actual = expect * 4
 	}
 
-	return difficulty
+	target := infra.CompactToBig(block.Bits)
+	target.Mul(target, new(big.Int).SetUint64(actual))
+	target.Div(target, new(big.Int).SetUint64(expect))
+	if target.Cmp(infra.MaxTarget) > 0 {
+		target = infra.MaxTarget
+	}
+	return infra.BigToCompact(target)
 }
 
 func (block *Block) GetNextReward(initReward, blocksPerRewrad uint64) uint64 {
@@ -192,42 +222,7 @@ func (block *Block) GetNextTotalInterval(t time.Time, blocksPerDifficulty uint64
 	if block.Number%blocksPerDifficulty == 0 {
 		return 0
 	} else {
-		return block.TotalInterval + uint64(t.Sub(block.Time).Milliseconds())
-	}
-}
-
-func MakeGenesisBlock(outs []*Out, level uint64) (*Block, error) {
-	now := time.Now().UTC()
-
-	tx := &Transaction{
-		OutLen:    uint32(len(outs)),
-		Outs:      outs,
-		Timestamp: now,
-	}
-	txHash, err := tx.ComputeHash()
-	if err != nil {
-		return nil, err
-	}
-	tx.Hash = txHash
-
-	tree, err := collection.BuildTree[*Transaction]([]*Transaction{tx})
-	if err != nil {
-		return nil, err
-	}
-
-	block := &Block{
-		Number:     1,
-		RootHash:   tree.Table[len(tree.Table)-1][0].Hash,
-		Difficulty: infra.ComputeDifficulty(infra.MakeDifficulty(level)),
-		Time:       now,
-		Body:       tree,
+		// the time is sent and hashed in milliseconds
+		return block.TotalInterval + uint64(t.UnixMilli()-block.Time.UnixMilli())
 	}
-
-	hash, err := block.FindHash(context.Background())
-	if err != nil {
-		return nil, err
-	}
-	block.Hash = hash
-
-	return block, nil
 }
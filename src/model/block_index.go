@@ -0,0 +1,42 @@
+package model
+
+import "math/big"
+
+type BlockStatus uint8
+
+const (
+	// the header is valid, the body is not stored yet
+	BlockHeaderValid BlockStatus = iota + 1
+	// the block is stored with the valid body
+	BlockDataValid
+	// the block failed the validation
+	BlockInvalid
+	// an ancestor of the block failed the validation
+	BlockFailedChild
+)
+
+// BlockIndex is the entry of the block tree, every known header or block has one
+type BlockIndex struct {
+	Hash     []byte
+	Prevhash []byte
+	Number   uint64
+	// the accumulated work of the chain ending with the block
+	Work   *big.Int
+	Status BlockStatus
+	// the order the block is first seen
+	Seq uint64
+}
+
+func (index *BlockIndex) Failed() bool {
+	return index.Status == BlockInvalid || index.Status == BlockFailedChild
+}
+
+// ChainTip is a block without children in the block tree
+type ChainTip struct {
+	Hash   []byte
+	Number uint64
+	Work   *big.Int
+	// the blocks between the tip and the main chain
+	BranchLen uint64
+	Status    string
+}
@@ -1,6 +1,23 @@
 package model
 
+import "math/big"
+
 type Chain struct {
 	Length        uint64
 	LastBlockHash []byte
+	// the accumulated work of the blocks on the chain
+	Work *big.Int
+	// the order the tip is seen, the tip seen first wins if the work is the same
+	Seen uint64
+}
+
+// Better reports whether the chain has more work than the other
+func (chain *Chain) Better(other *Chain) bool {
+	if other == nil {
+		return true
+	}
+	if c := chain.Work.Cmp(other.Work); c != 0 {
+		return c > 0
+	}
+	return chain.Seen < other.Seen
 }
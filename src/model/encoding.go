@@ -0,0 +1,223 @@
+package model
+
+import (
+	"Bitcoin/src/errors"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// the binary encoding of block headers and transactions, which is the input of their hashes:
+// integers are big endian with fixed size, byte slices and lists are prefixed by their uint32 length,
+// timestamps are unix milliseconds, and every record starts with the encoding version
+const (
+	EncodingVersion uint8 = 1
+)
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) writeUint8(v uint8) {
+	e.buf.WriteByte(v)
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (e *encoder) writeUint64(v uint64) {
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+func (e *encoder) writeTime(t time.Time) {
+	e.writeUint64(uint64(t.UnixMilli()))
+}
+
+func (e *encoder) writeBytes(v []byte) {
+	e.writeUint32(uint32(len(v)))
+	e.buf.Write(v)
+}
+
+func (e *encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+type decoder struct {
+	reader *bytes.Reader
+	err    error
+}
+
+func newDecoder(data []byte) *decoder {
+	return &decoder{reader: bytes.NewReader(data)}
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > d.reader.Len() {
+		d.err = errors.ErrEncodingMalformed
+		return nil
+	}
+	v := make([]byte, n)
+	if _, err := io.ReadFull(d.reader, v); err != nil {
+		d.err = errors.ErrEncodingMalformed
+		return nil
+	}
+	return v
+}
+
+func (d *decoder) readUint8() uint8 {
+	v := d.read(1)
+	if v == nil {
+		return 0
+	}
+	return v[0]
+}
+
+func (d *decoder) readUint32() uint32 {
+	v := d.read(4)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(v)
+}
+
+func (d *decoder) readUint64() uint64 {
+	v := d.read(8)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+func (d *decoder) readTime() time.Time {
+	return time.UnixMilli(int64(d.readUint64())).UTC()
+}
+
+func (d *decoder) readBytes() []byte {
+	return d.read(int(d.readUint32()))
+}
+
+func (d *decoder) readVersion() {
+	if version := d.readUint8(); d.err == nil && version != EncodingVersion {
+		d.err = errors.ErrEncodingVersion
+	}
+}
+
+// finish reports the first error, the data must be consumed completely
+func (d *decoder) finish() error {
+	if d.err == nil && d.reader.Len() > 0 {
+		d.err = errors.ErrEncodingMalformed
+	}
+	return d.err
+}
+
+// EncodeHeader encodes the fields of the block covered by its hash
+func EncodeHeader(block *Block) []byte {
+	e := &encoder{}
+	encodeHeader(e, block)
+	return e.Bytes()
+}
+
+// headerNonceOffset returns the offset of the nonce in the encoded header
+func headerNonceOffset(block *Block) int {
+	return 1 + 8 + 4 + len(block.Prevhash) + 4 + len(block.RootHash)
+}
+
+func encodeHeader(e *encoder, block *Block) {
+	e.writeUint8(EncodingVersion)
+	e.writeUint64(block.Number)
+	e.writeBytes(block.Prevhash)
+	e.writeBytes(block.RootHash)
+	e.writeUint32(block.Nonce)
+	e.writeUint32(block.Bits)
+	e.writeTime(block.Time)
+}
+
+func DecodeHeader(data []byte) (*Block, error) {
+	d := newDecoder(data)
+	d.readVersion()
+
+	block := &Block{}
+	block.Number = d.readUint64()
+	block.Prevhash = d.readBytes()
+	block.RootHash = d.readBytes()
+	block.Nonce = d.readUint32()
+	block.Bits = d.readUint32()
+	block.Time = d.readTime()
+
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// EncodeTx encodes the fields of the transaction covered by its hash
+func EncodeTx(tx *Transaction) []byte {
+	e := &encoder{}
+	encodeTx(e, tx, true)
+	return e.Bytes()
+}
+
+func encodeTx(e *encoder, tx *Transaction, withSignature bool) {
+	e.writeUint8(EncodingVersion)
+	e.writeUint32(tx.InLen)
+	e.writeUint32(tx.OutLen)
+
+	e.writeUint32(uint32(len(tx.Ins)))
+	for _, in := range tx.Ins {
+		e.writeBytes(in.PrevHash)
+		e.writeUint32(in.Index)
+		e.writeBytes(in.Pubkey)
+		if withSignature {
+			e.writeBytes(in.Signature)
+		} else {
+			e.writeBytes(nil)
+		}
+	}
+
+	e.writeUint32(uint32(len(tx.Outs)))
+	for _, out := range tx.Outs {
+		e.writeBytes(out.Address)
+		e.writeUint64(out.Value)
+	}
+
+	e.writeTime(tx.Timestamp)
+}
+
+func DecodeTx(data []byte) (*Transaction, error) {
+	d := newDecoder(data)
+	d.readVersion()
+
+	tx := &Transaction{}
+	tx.InLen = d.readUint32()
+	tx.OutLen = d.readUint32()
+
+	n := d.readUint32()
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		in := &In{}
+		in.PrevHash = d.readBytes()
+		in.Index = d.readUint32()
+		in.Pubkey = d.readBytes()
+		in.Signature = d.readBytes()
+		tx.Ins = append(tx.Ins, in)
+	}
+
+	n = d.readUint32()
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		out := &Out{}
+		out.Address = d.readBytes()
+		out.Value = d.readUint64()
+		tx.Outs = append(tx.Outs, out)
+	}
+
+	tx.Timestamp = d.readTime()
+
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
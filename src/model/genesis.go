@@ -0,0 +1,135 @@
+package model
+
+import (
+	"Bitcoin/src/collection"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/infra"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Genesis is the specification of the genesis block, every node of a network must share the same one
+type Genesis struct {
+	Timestamp time.Time
+	Outs      []*Out
+	Level     uint64
+	Nonce     uint32
+	Hash      []byte
+}
+
+type jGenesis struct {
+	Timestamp int64  `json:"timestamp"`
+	Outs      []*Out `json:"outs"`
+	Level     uint64 `json:"level"`
+	Nonce     uint32 `json:"nonce"`
+	Hash      string `json:"hash"`
+}
+
+func (genesis *Genesis) MarshalJSON() ([]byte, error) {
+	var jgenesis = jGenesis{
+		Timestamp: genesis.Timestamp.UnixMilli(),
+		Outs:      genesis.Outs,
+		Level:     genesis.Level,
+		Nonce:     genesis.Nonce,
+		Hash:      hex.EncodeToString(genesis.Hash),
+	}
+	return json.Marshal(jgenesis)
+}
+
+func (genesis *Genesis) UnmarshalJSON(data []byte) error {
+	var jgenesis jGenesis
+	err := json.Unmarshal(data, &jgenesis)
+	if err != nil {
+		return err
+	}
+
+	genesis.Hash, err = hex.DecodeString(jgenesis.Hash)
+	if err != nil {
+		return err
+	}
+
+	genesis.Timestamp = time.UnixMilli(jgenesis.Timestamp).UTC()
+	genesis.Outs = jgenesis.Outs
+	genesis.Level = jgenesis.Level
+	genesis.Nonce = jgenesis.Nonce
+	return nil
+}
+
+// MakeGenesisBlock builds the genesis block from the specification,
+// the block must have the expected hash and satisfy the difficulty of the level
+func MakeGenesisBlock(genesis *Genesis) (*Block, error) {
+	block, err := makeGenesisBlock(genesis.Outs, genesis.Level, genesis.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	block.Nonce = genesis.Nonce
+
+	hash, err := block.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hash, genesis.Hash) {
+		return nil, errors.ErrGenesisInvalid
+	}
+	if !infra.CheckProofOfWork(hash, block.Bits) {
+		return nil, errors.ErrGenesisInvalid
+	}
+
+	block.Hash = hash
+	return block, nil
+}
+
+// MineGenesis finds the nonce of the genesis block and returns its specification
+func MineGenesis(ctx context.Context, outs []*Out, level uint64, t time.Time) (*Genesis, error) {
+	block, err := makeGenesisBlock(outs, level, t)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := block.FindHash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := &Genesis{
+		Timestamp: block.Time,
+		Outs:      outs,
+		Level:     level,
+		Nonce:     block.Nonce,
+		Hash:      hash,
+	}
+	return genesis, nil
+}
+
+func makeGenesisBlock(outs []*Out, level uint64, t time.Time) (*Block, error) {
+	// the encoding only keeps milliseconds
+	t = time.UnixMilli(t.UnixMilli()).UTC()
+
+	tx := &Transaction{
+		OutLen:    uint32(len(outs)),
+		Outs:      outs,
+		Timestamp: t,
+	}
+	txHash, err := tx.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	tx.Hash = txHash
+
+	tree, err := collection.BuildTree[*Transaction]([]*Transaction{tx})
+	if err != nil {
+		return nil, err
+	}
+
+	block := &Block{
+		Number:   1,
+		RootHash: tree.Table[len(tree.Table)-1][0].Hash,
+		Bits:     infra.MakeBits(level),
+		Time:     t,
+		Body:     tree,
+	}
+	return block, nil
+}
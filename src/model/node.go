@@ -2,17 +2,116 @@ package model
 
 import (
 	"Bitcoin/src/bitcoin/client"
+	"Bitcoin/src/collection"
+	"Bitcoin/src/protocol"
 )
 
 const (
 	//node
 	MaxFailedCount = 10
+	// the hashes remembered as known by each peer
+	MaxKnownItems = 10000
+
+	// the version of the peer protocol, the peers older than MinProtocolVersion are refused
+	ProtocolVersion    uint32 = 1
+	MinProtocolVersion uint32 = 1
+
+	// the features announced in the handshake
+	FeatureRelay = "relay"
+	FeatureQuery = "query"
 )
 
+var Features = []string{FeatureRelay, FeatureQuery}
+
+// Handshake is the identity and the chain state exchanged by the peers before talking to each other
+type Handshake struct {
+	Version  uint32
+	Network  string
+	Genesis  []byte
+	Height   uint64
+	Features []string
+	Addr     string
+}
+
+func HandshakeFrom(req *protocol.HandshakeReq) *Handshake {
+	return &Handshake{
+		Version:  req.Version,
+		Network:  req.Network,
+		Genesis:  req.Genesis,
+		Height:   req.Height,
+		Features: req.Features,
+		Addr:     req.Node,
+	}
+}
+
+func HandshakeTo(handshake *Handshake) *protocol.HandshakeReq {
+	return &protocol.HandshakeReq{
+		Version:  handshake.Version,
+		Network:  handshake.Network,
+		Genesis:  handshake.Genesis,
+		Height:   handshake.Height,
+		Features: handshake.Features,
+		Node:     handshake.Addr,
+	}
+}
+
+func HandshakeFromReply(reply *protocol.HandshakeReply, addr string) *Handshake {
+	return &Handshake{
+		Version:  reply.Version,
+		Network:  reply.Network,
+		Genesis:  reply.Genesis,
+		Height:   reply.Height,
+		Features: reply.Features,
+		Addr:     addr,
+	}
+}
+
+func HandshakeToReply(handshake *Handshake) *protocol.HandshakeReply {
+	return &protocol.HandshakeReply{
+		Version:  handshake.Version,
+		Network:  handshake.Network,
+		Genesis:  handshake.Genesis,
+		Height:   handshake.Height,
+		Features: handshake.Features,
+	}
+}
+
+// Node is a peer, the blocks and transactions are only relayed to it after the handshake
 type Node struct {
-	Addr   string
-	Client client.IBitcoinClient
-	Failed int
+	Addr       string
+	Client     client.IBitcoinClient
+	Failed     int
+	Handshaked bool
+	Version    uint32
+	Network    string
+	Genesis    []byte
+	Height     uint64
+	Features   []string
+	// the hashes the peer announced or was announced, they are not announced to it again
+	Known *collection.SeenSet
+}
+
+func NewNode(addr string, cli client.IBitcoinClient) *Node {
+	return &Node{Addr: addr, Client: cli, Known: collection.NewSeenSet(MaxKnownItems)}
+}
+
+// Accept stores the handshake of the node, which is validated by the caller
+func (node *Node) Accept(handshake *Handshake) {
+	node.Handshaked = true
+	node.Version = handshake.Version
+	node.Network = handshake.Network
+	node.Genesis = handshake.Genesis
+	node.Height = handshake.Height
+	node.Features = handshake.Features
+}
+
+func (node *Node) HasFeature(feature string) bool {
+	for _, f := range node.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
 }
 
 func (node *Node) UpdateState(err error) bool {
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"time"
+)
+
+// the records saved in the database are encoded like the hashed fields with the fields not covered by the hashes,
+// the timestamps keep the nanoseconds, so a record decodes to the value saved
+
+// EncodeBlockRecord encodes the block without its body
+func EncodeBlockRecord(block *Block) []byte {
+	e := &encoder{}
+	e.writeUint8(EncodingVersion)
+	e.writeBytes(block.Hash)
+	e.writeUint64(block.Number)
+	e.writeBytes(block.Prevhash)
+	e.writeBytes(block.RootHash)
+	e.writeUint32(block.Nonce)
+	e.writeUint32(block.Bits)
+	writeNanoTime(e, block.Time)
+	e.writeUint64(block.TotalInterval)
+	e.writeBytes([]byte(block.Miner))
+	return e.Bytes()
+}
+
+func DecodeBlockRecord(data []byte) (*Block, error) {
+	d := newDecoder(data)
+	d.readVersion()
+
+	block := &Block{}
+	block.Hash = d.readBytes()
+	block.Number = d.readUint64()
+	block.Prevhash = d.readBytes()
+	block.RootHash = d.readBytes()
+	block.Nonce = d.readUint32()
+	block.Bits = d.readUint32()
+	block.Time = readNanoTime(d)
+	block.TotalInterval = d.readUint64()
+	block.Miner = string(d.readBytes())
+
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// EncodeTxRecord encodes the transaction with its hash and the hash of its block
+func EncodeTxRecord(tx *Transaction) []byte {
+	e := &encoder{}
+	e.writeUint8(EncodingVersion)
+	encodeTxRecord(e, tx)
+	return e.Bytes()
+}
+
+func encodeTxRecord(e *encoder, tx *Transaction) {
+	e.writeBytes(tx.Hash)
+	e.writeBytes(tx.BlockHash)
+	e.writeUint32(tx.InLen)
+	e.writeUint32(tx.OutLen)
+
+	e.writeUint32(uint32(len(tx.Ins)))
+	for _, in := range tx.Ins {
+		e.writeBytes(in.PrevHash)
+		e.writeUint32(in.Index)
+		e.writeBytes(in.Pubkey)
+		e.writeBytes(in.Signature)
+	}
+
+	e.writeUint32(uint32(len(tx.Outs)))
+	for _, out := range tx.Outs {
+		e.writeBytes(out.Address)
+		e.writeUint64(out.Value)
+	}
+
+	writeNanoTime(e, tx.Timestamp)
+}
+
+func DecodeTxRecord(data []byte) (*Transaction, error) {
+	d := newDecoder(data)
+	d.readVersion()
+
+	tx := decodeTxRecord(d)
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func decodeTxRecord(d *decoder) *Transaction {
+	tx := &Transaction{}
+	tx.Hash = d.readBytes()
+	tx.BlockHash = d.readBytes()
+	tx.InLen = d.readUint32()
+	tx.OutLen = d.readUint32()
+
+	n := d.readUint32()
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		in := &In{}
+		in.PrevHash = d.readBytes()
+		in.Index = d.readUint32()
+		in.Pubkey = d.readBytes()
+		in.Signature = d.readBytes()
+		tx.Ins = append(tx.Ins, in)
+	}
+
+	n = d.readUint32()
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		out := &Out{}
+		out.Address = d.readBytes()
+		out.Value = d.readUint64()
+		tx.Outs = append(tx.Outs, out)
+	}
+
+	tx.Timestamp = readNanoTime(d)
+	return tx
+}
+
+// EncodeContentRecord encodes the transactions of the block in the order of the leaves,
+// the merkle tree is rebuilt from them, so the block is read without looking up its transactions
+func EncodeContentRecord(txs []*Transaction) []byte {
+	e := &encoder{}
+	e.writeUint8(EncodingVersion)
+	e.writeUint32(uint32(len(txs)))
+	for _, tx := range txs {
+		encodeTxRecord(e, tx)
+	}
+	return e.Bytes()
+}
+
+func DecodeContentRecord(data []byte) ([]*Transaction, error) {
+	d := newDecoder(data)
+	d.readVersion()
+
+	n := d.readUint32()
+	txs := make([]*Transaction, 0)
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		txs = append(txs, decodeTxRecord(d))
+	}
+
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// writeNanoTime writes the seconds and the nanoseconds apart, the zero time is out of the range of UnixNano
+func writeNanoTime(e *encoder, t time.Time) {
+	e.writeUint64(uint64(t.Unix()))
+	e.writeUint32(uint32(t.Nanosecond()))
+}
+
+func readNanoTime(d *decoder) time.Time {
+	sec := int64(d.readUint64())
+	nsec := int64(d.readUint32())
+	return time.Unix(sec, nsec).UTC()
+}
@@ -2,19 +2,25 @@ package model
 
 import (
 	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
 	"Bitcoin/src/protocol"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/peteprogrammer/go-automapper"
 )
 
+// In spends the output of the prev transaction, the hash of its pubkey must be the address of the output
 type In struct {
 	PrevHash  []byte
 	PrevOut   *Out
 	Index     uint32
+	Pubkey    []byte
 	Signature []byte
 }
 
@@ -22,10 +28,12 @@ func (in *In) MarshalJSON() ([]byte, error) {
 	var s = struct {
 		PrevHash  string `json:"prevHash,omitempty"`
 		Index     uint32 `json:"index,omitempty"`
+		Pubkey    string `json:"pubkey,omitempty"`
 		Signature string `json:"signature,omitempty"`
 	}{
 		PrevHash:  hex.EncodeToString(in.PrevHash),
 		Index:     in.Index,
+		Pubkey:    base64.RawStdEncoding.EncodeToString(in.Pubkey),
 		Signature: base64.RawStdEncoding.EncodeToString(in.Signature),
 	}
 	return json.Marshal(s)
@@ -35,6 +43,7 @@ func (in *In) UnmarshalJSON(data []byte) error {
 	var s struct {
 		PrevHash  string `json:"prevHash,omitempty"`
 		Index     uint32 `json:"index,omitempty"`
+		Pubkey    string `json:"pubkey,omitempty"`
 		Signature string `json:"signature,omitempty"`
 	}
 
@@ -48,6 +57,11 @@ func (in *In) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	in.Pubkey, err = base64.RawStdEncoding.DecodeString(s.Pubkey)
+	if err != nil {
+		return err
+	}
+
 	in.Signature, err = base64.RawStdEncoding.DecodeString(s.Signature)
 	if err != nil {
 		return err
@@ -57,26 +71,31 @@ func (in *In) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+func (in *In) OutPoint() OutPoint {
+	return OutPoint{Hash: in.PrevHash, Index: in.Index}
+}
+
+// Out is locked to the address, which is the hash of the pubkey, see cryptography.AddressHash
 type Out struct {
-	Pubkey []byte `json:"pubkey,omitempty"`
-	Value  uint64 `json:"value,omitempty"`
+	Address []byte `json:"address,omitempty"`
+	Value   uint64 `json:"value,omitempty"`
 }
 
 func (out *Out) MarshalJSON() ([]byte, error) {
 	var s = struct {
-		Pubkey string `json:"pubkey,omitempty"`
-		Value  uint64 `json:"value,omitempty"`
+		Address string `json:"address,omitempty"`
+		Value   uint64 `json:"value,omitempty"`
 	}{
-		Pubkey: base64.RawStdEncoding.EncodeToString(out.Pubkey),
-		Value:  out.Value,
+		Address: hex.EncodeToString(out.Address),
+		Value:   out.Value,
 	}
 	return json.Marshal(s)
 }
 
 func (out *Out) UnmarshalJSON(data []byte) error {
 	var s struct {
-		Pubkey string `json:"pubkey,omitempty"`
-		Value  uint64 `json:"value,omitempty"`
+		Address string `json:"address,omitempty"`
+		Value   uint64 `json:"value,omitempty"`
 	}
 
 	err := json.Unmarshal(data, &s)
@@ -84,7 +103,7 @@ func (out *Out) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	out.Pubkey, err = base64.RawStdEncoding.DecodeString(s.Pubkey)
+	out.Address, err = hex.DecodeString(s.Address)
 	if err != nil {
 		return err
 	}
@@ -94,7 +113,39 @@ func (out *Out) UnmarshalJSON(data []byte) error {
 }
 
 func (out *Out) DeepClone() *Out {
-	return &Out{Pubkey: []byte(out.Pubkey), Value: out.Value}
+	return &Out{Address: []byte(out.Address), Value: out.Value}
+}
+
+// OutPoint identifies an output by the hash of its transaction and its index
+type OutPoint struct {
+	Hash  []byte
+	Index uint32
+}
+
+func (point OutPoint) Key() string {
+	return fmt.Sprintf("%x:%d", point.Hash, point.Index)
+}
+
+// ParseOutPoint parses the key made by OutPoint.Key
+func ParseOutPoint(key string) (OutPoint, error) {
+	var point OutPoint
+	hash, index, found := strings.Cut(key, ":")
+	if !found {
+		return point, errors.ErrOutPointInvalid
+	}
+
+	var err error
+	point.Hash, err = hex.DecodeString(hash)
+	if err != nil {
+		return point, err
+	}
+
+	n, err := strconv.ParseUint(index, 10, 32)
+	if err != nil {
+		return point, err
+	}
+	point.Index = uint32(n)
+	return point, nil
 }
 
 type Transaction struct {
@@ -174,37 +225,26 @@ func TransactionTo(tx *Transaction) *protocol.TransactionReq {
 }
 
 func (tx *Transaction) ComputeHash() ([]byte, error) {
-	//TODO: more general way to compute hash, use tag and no need assign the value of each field
-	ins := make([]*In, len(tx.Ins))
-	for i := 0; i < len(tx.Ins); i++ {
-		in := tx.Ins[i]
-		ins[i] = &In{
-			PrevHash:  in.PrevHash,
-			Index:     in.Index,
-			Signature: in.Signature,
-		}
-	}
-
-	newtx := &Transaction{
-		InLen:     tx.InLen,
-		OutLen:    tx.OutLen,
-		Ins:       ins,
-		Outs:      tx.Outs,
-		Timestamp: tx.Timestamp,
-	}
+	return cryptography.HashBytes(EncodeTx(tx)), nil
+}
 
-	return cryptography.Hash(newtx)
+// SignatureHash computes the digest signed by the inputs,
+// it commits to the inputs, outputs and timestamp of the transaction with the signatures blanked
+func (tx *Transaction) SignatureHash() ([]byte, error) {
+	e := &encoder{}
+	encodeTx(e, tx, false)
+	return cryptography.HashBytes(e.Bytes()), nil
 }
 
-func MakeCoinbaseTx(pubkey []byte, val uint64) (*Transaction, error) {
+func MakeCoinbaseTx(address []byte, val uint64) (*Transaction, error) {
 	tx := &Transaction{
 		InLen:  0,
 		OutLen: 1,
 		Ins:    []*In{},
 		Outs: []*Out{
 			{
-				Pubkey: pubkey,
-				Value:  val,
+				Address: address,
+				Value:   val,
 			},
 		},
 		Timestamp: time.Now(),
@@ -0,0 +1,27 @@
+package model
+
+import "Bitcoin/src/errors"
+
+// BlockUndo keeps the outputs spent by the block in the order of its transactions and inputs,
+// they are restored to the utxo when the block is disconnected
+type BlockUndo struct {
+	PrevOuts []*Out
+}
+
+// Restore puts the spent outputs back on the inputs of the block
+func (undo *BlockUndo) Restore(block *Block) error {
+	i := 0
+	for _, tx := range block.GetTxs() {
+		for _, in := range tx.Ins {
+			if i >= len(undo.PrevOuts) {
+				return errors.ErrBlockUndoMismatch
+			}
+			in.PrevOut = undo.PrevOuts[i]
+			i++
+		}
+	}
+	if i != len(undo.PrevOuts) {
+		return errors.ErrBlockUndoMismatch
+	}
+	return nil
+}
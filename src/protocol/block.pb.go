@@ -14,7 +14,7 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.1
+// 	protoc-gen-go v1.31.0
 // 	protoc        v3.6.1
 // source: block.proto
 
@@ -39,15 +39,18 @@ type BlockReq struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Number     uint64  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
-	Hash       []byte  `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
-	Prevhash   []byte  `protobuf:"bytes,3,opt,name=prevhash,proto3" json:"prevhash,omitempty"`
-	RootHash   []byte  `protobuf:"bytes,4,opt,name=rootHash,proto3" json:"rootHash,omitempty"`
-	Nonce      uint32  `protobuf:"varint,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
-	Difficulty float64 `protobuf:"fixed64,6,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
-	Timestamp  int64   `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Content    []byte  `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
-	Node       string  `protobuf:"bytes,9,opt,name=node,proto3" json:"node,omitempty"`
+	Number    uint64 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Hash      []byte `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	Prevhash  []byte `protobuf:"bytes,3,opt,name=prevhash,proto3" json:"prevhash,omitempty"`
+	RootHash  []byte `protobuf:"bytes,4,opt,name=rootHash,proto3" json:"rootHash,omitempty"`
+	Nonce     uint32 `protobuf:"varint,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp int64  `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Content   []byte `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
+	Node      string `protobuf:"bytes,9,opt,name=node,proto3" json:"node,omitempty"`
+	Bits      uint32 `protobuf:"varint,10,opt,name=bits,proto3" json:"bits,omitempty"`
+	// the transactions of the block in the order of the leaves of the content
+	Txs           []*TransactionReq `protobuf:"bytes,11,rep,name=txs,proto3" json:"txs,omitempty"`
+	TotalInterval uint64            `protobuf:"varint,12,opt,name=total_interval,json=totalInterval,proto3" json:"total_interval,omitempty"`
 }
 
 func (x *BlockReq) Reset() {
@@ -117,13 +120,6 @@ func (x *BlockReq) GetNonce() uint32 {
 	return 0
 }
 
-func (x *BlockReq) GetDifficulty() float64 {
-	if x != nil {
-		return x.Difficulty
-	}
-	return 0
-}
-
 func (x *BlockReq) GetTimestamp() int64 {
 	if x != nil {
 		return x.Timestamp
@@ -145,6 +141,27 @@ func (x *BlockReq) GetNode() string {
 	return ""
 }
 
+func (x *BlockReq) GetBits() uint32 {
+	if x != nil {
+		return x.Bits
+	}
+	return 0
+}
+
+func (x *BlockReq) GetTxs() []*TransactionReq {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+func (x *BlockReq) GetTotalInterval() uint64 {
+	if x != nil {
+		return x.TotalInterval
+	}
+	return 0
+}
+
 // The response message containing the greetings
 type BlockReply struct {
 	state         protoimpl.MessageState
@@ -200,6 +217,7 @@ type GetBlocksReq struct {
 	unknownFields protoimpl.UnknownFields
 
 	Blockhashes [][]byte `protobuf:"bytes,1,rep,name=blockhashes,proto3" json:"blockhashes,omitempty"`
+	Node        string   `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
 }
 
 func (x *GetBlocksReq) Reset() {
@@ -241,6 +259,13 @@ func (x *GetBlocksReq) GetBlockhashes() [][]byte {
 	return nil
 }
 
+func (x *GetBlocksReq) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
 // The response message containing the greetings
 type GetBlocksReply struct {
 	state         protoimpl.MessageState
@@ -297,37 +322,157 @@ func (x *GetBlocksReply) GetEnd() uint64 {
 	return 0
 }
 
+// The block locator, the hashes of the chain from the tip back to the genesis block.
+type GetHeadersReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Locator [][]byte `protobuf:"bytes,1,rep,name=locator,proto3" json:"locator,omitempty"`
+	Node    string   `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+}
+
+func (x *GetHeadersReq) Reset() {
+	*x = GetHeadersReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetHeadersReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHeadersReq) ProtoMessage() {}
+
+func (x *GetHeadersReq) ProtoReflect() protoreflect.Message {
+	mi := &file_block_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHeadersReq.ProtoReflect.Descriptor instead.
+func (*GetHeadersReq) Descriptor() ([]byte, []int) {
+	return file_block_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetHeadersReq) GetLocator() [][]byte {
+	if x != nil {
+		return x.Locator
+	}
+	return nil
+}
+
+func (x *GetHeadersReq) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+// The headers after the first block of the locator on the main chain, without the content.
+type GetHeadersReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Headers []*BlockReq `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty"`
+}
+
+func (x *GetHeadersReply) Reset() {
+	*x = GetHeadersReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetHeadersReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHeadersReply) ProtoMessage() {}
+
+func (x *GetHeadersReply) ProtoReflect() protoreflect.Message {
+	mi := &file_block_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHeadersReply.ProtoReflect.Descriptor instead.
+func (*GetHeadersReply) Descriptor() ([]byte, []int) {
+	return file_block_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetHeadersReply) GetHeaders() []*BlockReq {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
 var File_block_proto protoreflect.FileDescriptor
 
 var file_block_proto_rawDesc = []byte{
 	0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0xf0, 0x01, 0x0a, 0x08, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x52, 0x65, 0x71, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04,
-	0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68,
-	0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x76, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x68, 0x61, 0x73, 0x68, 0x12, 0x1a, 0x0a, 0x08,
-	0x72, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
-	0x72, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
-	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x1e,
-	0x0a, 0x0a, 0x64, 0x69, 0x66, 0x66, 0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01,
-	0x28, 0x01, 0x52, 0x0a, 0x64, 0x69, 0x66, 0x66, 0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x12, 0x1c,
-	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07,
-	0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63,
-	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x09,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x22, 0x24, 0x0a, 0x0a, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75,
-	0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
-	0x22, 0x30, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71,
-	0x12, 0x20, 0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x68, 0x61, 0x73, 0x68,
-	0x65, 0x73, 0x22, 0x4e, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x1a, 0x11, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc9, 0x02, 0x0a, 0x08, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68,
+	0x61, 0x73, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x76, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x68, 0x61, 0x73, 0x68, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x08, 0x72, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64,
+	0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x62, 0x69, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x62, 0x69, 0x74,
+	0x73, 0x12, 0x2a, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x52, 0x03, 0x74, 0x78, 0x73, 0x12, 0x25, 0x0a,
+	0x0e, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x4a, 0x04, 0x08, 0x06, 0x10, 0x07, 0x52, 0x0a, 0x64, 0x69, 0x66, 0x66,
+	0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x22, 0x24, 0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x44, 0x0a, 0x0c,
+	0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x12, 0x20, 0x0a, 0x0b,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f,
+	0x64, 0x65, 0x22, 0x4e, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52,
 	0x65, 0x70, 0x6c, 0x79, 0x12, 0x2a, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01,
 	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
 	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73,
 	0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x65,
-	0x6e, 0x64, 0x32, 0x80, 0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x36, 0x0a, 0x08,
+	0x6e, 0x64, 0x22, 0x3d, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x71, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x07, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64,
+	0x65, 0x22, 0x3f, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x2c, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x32, 0xc4, 0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x36, 0x0a, 0x08,
 	0x4e, 0x65, 0x77, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x1a, 0x14, 0x2e, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x70,
@@ -335,13 +480,18 @@ var file_block_proto_rawDesc = []byte{
 	0x73, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47, 0x65, 0x74,
 	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x5f, 0x0a, 0x1b, 0x69, 0x6f, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2e, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x77,
-	0x6f, 0x72, 0x6c, 0x64, 0x42, 0x0f, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x57, 0x6f, 0x72, 0x6c, 0x64,
-	0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2d, 0x68, 0x74, 0x74, 0x70, 0x73, 0x3a, 0x2f,
-	0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x6c, 0x6c, 0x61,
-	0x6e, 0x6d, 0x61, 0x38, 0x38, 0x2f, 0x42, 0x69, 0x74, 0x63, 0x6f, 0x69, 0x6e, 0x2f, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47,
+	0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x19, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x5f, 0x0a, 0x1b, 0x69, 0x6f, 0x2e,
+	0x67, 0x72, 0x70, 0x63, 0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2e, 0x68, 0x65,
+	0x6c, 0x6c, 0x6f, 0x77, 0x6f, 0x72, 0x6c, 0x64, 0x42, 0x0f, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x57,
+	0x6f, 0x72, 0x6c, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2d, 0x68, 0x74, 0x74,
+	0x70, 0x73, 0x3a, 0x2f, 0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x61, 0x6c, 0x6c, 0x61, 0x6e, 0x6d, 0x61, 0x38, 0x38, 0x2f, 0x42, 0x69, 0x74, 0x63, 0x6f, 0x69,
+	0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -356,24 +506,31 @@ func file_block_proto_rawDescGZIP() []byte {
 	return file_block_proto_rawDescData
 }
 
-var file_block_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_block_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_block_proto_goTypes = []interface{}{
-	(*BlockReq)(nil),       // 0: protocol.BlockReq
-	(*BlockReply)(nil),     // 1: protocol.BlockReply
-	(*GetBlocksReq)(nil),   // 2: protocol.GetBlocksReq
-	(*GetBlocksReply)(nil), // 3: protocol.GetBlocksReply
+	(*BlockReq)(nil),        // 0: protocol.BlockReq
+	(*BlockReply)(nil),      // 1: protocol.BlockReply
+	(*GetBlocksReq)(nil),    // 2: protocol.GetBlocksReq
+	(*GetBlocksReply)(nil),  // 3: protocol.GetBlocksReply
+	(*GetHeadersReq)(nil),   // 4: protocol.GetHeadersReq
+	(*GetHeadersReply)(nil), // 5: protocol.GetHeadersReply
+	(*TransactionReq)(nil),  // 6: protocol.TransactionReq
 }
 var file_block_proto_depIdxs = []int32{
-	0, // 0: protocol.GetBlocksReply.blocks:type_name -> protocol.BlockReq
-	0, // 1: protocol.Block.NewBlock:input_type -> protocol.BlockReq
-	2, // 2: protocol.Block.GetBlocks:input_type -> protocol.GetBlocksReq
-	1, // 3: protocol.Block.NewBlock:output_type -> protocol.BlockReply
-	3, // 4: protocol.Block.GetBlocks:output_type -> protocol.GetBlocksReply
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	6, // 0: protocol.BlockReq.txs:type_name -> protocol.TransactionReq
+	0, // 1: protocol.GetBlocksReply.blocks:type_name -> protocol.BlockReq
+	0, // 2: protocol.GetHeadersReply.headers:type_name -> protocol.BlockReq
+	0, // 3: protocol.Block.NewBlock:input_type -> protocol.BlockReq
+	2, // 4: protocol.Block.GetBlocks:input_type -> protocol.GetBlocksReq
+	4, // 5: protocol.Block.GetHeaders:input_type -> protocol.GetHeadersReq
+	1, // 6: protocol.Block.NewBlock:output_type -> protocol.BlockReply
+	3, // 7: protocol.Block.GetBlocks:output_type -> protocol.GetBlocksReply
+	5, // 8: protocol.Block.GetHeaders:output_type -> protocol.GetHeadersReply
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_block_proto_init() }
@@ -381,6 +538,7 @@ func file_block_proto_init() {
 	if File_block_proto != nil {
 		return
 	}
+	file_transaction_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_block_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BlockReq); i {
@@ -430,6 +588,30 @@ func file_block_proto_init() {
 				return nil
 			}
 		}
+		file_block_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetHeadersReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetHeadersReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -437,7 +619,7 @@ func file_block_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_block_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -25,6 +25,7 @@ type BlockClient interface {
 	// find a new block
 	NewBlock(ctx context.Context, in *BlockReq, opts ...grpc.CallOption) (*BlockReply, error)
 	GetBlocks(ctx context.Context, in *GetBlocksReq, opts ...grpc.CallOption) (*GetBlocksReply, error)
+	GetHeaders(ctx context.Context, in *GetHeadersReq, opts ...grpc.CallOption) (*GetHeadersReply, error)
 }
 
 type blockClient struct {
@@ -53,6 +54,15 @@ func (c *blockClient) GetBlocks(ctx context.Context, in *GetBlocksReq, opts ...g
 	return out, nil
 }
 
+func (c *blockClient) GetHeaders(ctx context.Context, in *GetHeadersReq, opts ...grpc.CallOption) (*GetHeadersReply, error) {
+	out := new(GetHeadersReply)
+	err := c.cc.Invoke(ctx, "/protocol.Block/GetHeaders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BlockServer is the server API for Block service.
 // All implementations must embed UnimplementedBlockServer
 // for forward compatibility
@@ -60,6 +70,7 @@ type BlockServer interface {
 	// find a new block
 	NewBlock(context.Context, *BlockReq) (*BlockReply, error)
 	GetBlocks(context.Context, *GetBlocksReq) (*GetBlocksReply, error)
+	GetHeaders(context.Context, *GetHeadersReq) (*GetHeadersReply, error)
 	mustEmbedUnimplementedBlockServer()
 }
 
@@ -73,6 +84,9 @@ func (UnimplementedBlockServer) NewBlock(context.Context, *BlockReq) (*BlockRepl
 func (UnimplementedBlockServer) GetBlocks(context.Context, *GetBlocksReq) (*GetBlocksReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlocks not implemented")
 }
+func (UnimplementedBlockServer) GetHeaders(context.Context, *GetHeadersReq) (*GetHeadersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHeaders not implemented")
+}
 func (UnimplementedBlockServer) mustEmbedUnimplementedBlockServer() {}
 
 // UnsafeBlockServer may be embedded to opt out of forward compatibility for this service.
@@ -122,6 +136,24 @@ func _Block_GetBlocks_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Block_GetHeaders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHeadersReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockServer).GetHeaders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Block/GetHeaders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockServer).GetHeaders(ctx, req.(*GetHeadersReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Block_ServiceDesc is the grpc.ServiceDesc for Block service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -137,6 +169,10 @@ var Block_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlocks",
 			Handler:    _Block_GetBlocks_Handler,
 		},
+		{
+			MethodName: "GetHeaders",
+			Handler:    _Block_GetHeaders_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "block.proto",
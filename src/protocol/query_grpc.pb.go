@@ -0,0 +1,321 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.6.1
+// source: query.proto
+
+package protocol
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QueryClient interface {
+	GetChainInfo(ctx context.Context, in *GetChainInfoReq, opts ...grpc.CallOption) (*GetChainInfoReply, error)
+	GetBlockByHash(ctx context.Context, in *GetBlockByHashReq, opts ...grpc.CallOption) (*BlockReq, error)
+	GetBlockByNumber(ctx context.Context, in *GetBlockByNumberReq, opts ...grpc.CallOption) (*BlockReq, error)
+	GetTransaction(ctx context.Context, in *GetTransactionReq, opts ...grpc.CallOption) (*GetTransactionReply, error)
+	GetBalance(ctx context.Context, in *GetBalanceReq, opts ...grpc.CallOption) (*GetBalanceReply, error)
+	ListUnspent(ctx context.Context, in *ListUnspentReq, opts ...grpc.CallOption) (*ListUnspentReply, error)
+	ListChainTips(ctx context.Context, in *ListChainTipsReq, opts ...grpc.CallOption) (*ListChainTipsReply, error)
+}
+
+type queryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueryClient(cc grpc.ClientConnInterface) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) GetChainInfo(ctx context.Context, in *GetChainInfoReq, opts ...grpc.CallOption) (*GetChainInfoReply, error) {
+	out := new(GetChainInfoReply)
+	err := c.cc.Invoke(ctx, "/protocol.Query/GetChainInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetBlockByHash(ctx context.Context, in *GetBlockByHashReq, opts ...grpc.CallOption) (*BlockReq, error) {
+	out := new(BlockReq)
+	err := c.cc.Invoke(ctx, "/protocol.Query/GetBlockByHash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetBlockByNumber(ctx context.Context, in *GetBlockByNumberReq, opts ...grpc.CallOption) (*BlockReq, error) {
+	out := new(BlockReq)
+	err := c.cc.Invoke(ctx, "/protocol.Query/GetBlockByNumber", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetTransaction(ctx context.Context, in *GetTransactionReq, opts ...grpc.CallOption) (*GetTransactionReply, error) {
+	out := new(GetTransactionReply)
+	err := c.cc.Invoke(ctx, "/protocol.Query/GetTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetBalance(ctx context.Context, in *GetBalanceReq, opts ...grpc.CallOption) (*GetBalanceReply, error) {
+	out := new(GetBalanceReply)
+	err := c.cc.Invoke(ctx, "/protocol.Query/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) ListUnspent(ctx context.Context, in *ListUnspentReq, opts ...grpc.CallOption) (*ListUnspentReply, error) {
+	out := new(ListUnspentReply)
+	err := c.cc.Invoke(ctx, "/protocol.Query/ListUnspent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) ListChainTips(ctx context.Context, in *ListChainTipsReq, opts ...grpc.CallOption) (*ListChainTipsReply, error) {
+	out := new(ListChainTipsReply)
+	err := c.cc.Invoke(ctx, "/protocol.Query/ListChainTips", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServer is the server API for Query service.
+// All implementations must embed UnimplementedQueryServer
+// for forward compatibility
+type QueryServer interface {
+	GetChainInfo(context.Context, *GetChainInfoReq) (*GetChainInfoReply, error)
+	GetBlockByHash(context.Context, *GetBlockByHashReq) (*BlockReq, error)
+	GetBlockByNumber(context.Context, *GetBlockByNumberReq) (*BlockReq, error)
+	GetTransaction(context.Context, *GetTransactionReq) (*GetTransactionReply, error)
+	GetBalance(context.Context, *GetBalanceReq) (*GetBalanceReply, error)
+	ListUnspent(context.Context, *ListUnspentReq) (*ListUnspentReply, error)
+	ListChainTips(context.Context, *ListChainTipsReq) (*ListChainTipsReply, error)
+	mustEmbedUnimplementedQueryServer()
+}
+
+// UnimplementedQueryServer must be embedded to have forward compatible implementations.
+type UnimplementedQueryServer struct {
+}
+
+func (UnimplementedQueryServer) GetChainInfo(context.Context, *GetChainInfoReq) (*GetChainInfoReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChainInfo not implemented")
+}
+func (UnimplementedQueryServer) GetBlockByHash(context.Context, *GetBlockByHashReq) (*BlockReq, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHash not implemented")
+}
+func (UnimplementedQueryServer) GetBlockByNumber(context.Context, *GetBlockByNumberReq) (*BlockReq, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByNumber not implemented")
+}
+func (UnimplementedQueryServer) GetTransaction(context.Context, *GetTransactionReq) (*GetTransactionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedQueryServer) GetBalance(context.Context, *GetBalanceReq) (*GetBalanceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedQueryServer) ListUnspent(context.Context, *ListUnspentReq) (*ListUnspentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUnspent not implemented")
+}
+func (UnimplementedQueryServer) ListChainTips(context.Context, *ListChainTipsReq) (*ListChainTipsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListChainTips not implemented")
+}
+func (UnimplementedQueryServer) mustEmbedUnimplementedQueryServer() {}
+
+// UnsafeQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QueryServer will
+// result in compilation errors.
+type UnsafeQueryServer interface {
+	mustEmbedUnimplementedQueryServer()
+}
+
+func RegisterQueryServer(s grpc.ServiceRegistrar, srv QueryServer) {
+	s.RegisterService(&Query_ServiceDesc, srv)
+}
+
+func _Query_GetChainInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChainInfoReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetChainInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/GetChainInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetChainInfo(ctx, req.(*GetChainInfoReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetBlockByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockByHashReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBlockByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/GetBlockByHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBlockByHash(ctx, req.(*GetBlockByHashReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetBlockByNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockByNumberReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBlockByNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/GetBlockByNumber",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBlockByNumber(ctx, req.(*GetBlockByNumberReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/GetTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetTransaction(ctx, req.(*GetTransactionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBalance(ctx, req.(*GetBalanceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_ListUnspent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUnspentReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ListUnspent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/ListUnspent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ListUnspent(ctx, req.(*ListUnspentReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_ListChainTips_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChainTipsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ListChainTips(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.Query/ListChainTips",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ListChainTips(ctx, req.(*ListChainTipsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Query_ServiceDesc is the grpc.ServiceDesc for Query service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Query_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protocol.Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetChainInfo",
+			Handler:    _Query_GetChainInfo_Handler,
+		},
+		{
+			MethodName: "GetBlockByHash",
+			Handler:    _Query_GetBlockByHash_Handler,
+		},
+		{
+			MethodName: "GetBlockByNumber",
+			Handler:    _Query_GetBlockByNumber_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Query_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Query_GetBalance_Handler,
+		},
+		{
+			MethodName: "ListUnspent",
+			Handler:    _Query_ListUnspent_Handler,
+		},
+		{
+			MethodName: "ListChainTips",
+			Handler:    _Query_ListChainTips_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "query.proto",
+}
@@ -14,12 +14,13 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/syndtr/goleveldb/leveldb"
 	"google.golang.org/grpc"
 )
 
 var (
-	CONFIG = flag.String("config", "config.yml", "the path of config file")
+	CONFIG      = flag.String("config", "config.yml", "the path of config file")
+	REINDEX     = flag.Bool("reindex", false, "rebuild the block index, the chain tips and the utxo from the saved blocks")
+	CHECKBLOCKS = flag.Uint64("checkblocks", 6, "the number of the last blocks verified on startup, 0 to skip")
 )
 
 func main() {
@@ -30,24 +31,37 @@ func main() {
 		log.Fatalf("read config error: %v", err)
 	}
 
-	db, err := leveldb.OpenFile(cfg.DataDir, nil)
+	db, err := database.Open(cfg.StorageEngine, cfg.DataDir)
 	if err != nil {
-		log.Fatalf("failed to open db: %v", err)
+		log.Fatalf("failed to open db with the storage engine %s: %v", cfg.StorageEngine, err)
 	}
 
-	blockdb := database.NewBlockDB(db)
+	blockdb := database.NewBlockDB(db, cfg.Compression == config.SnappyCompression)
 
-	server, err := server.NewBitcoinServer(cfg, blockdb)
+	migrated, err := blockdb.Migrate()
+	if err != nil {
+		log.Fatalf("failed to migrate db: %v", err)
+	}
+	if migrated > 0 {
+		log.Printf("migrated %d records to the binary format", migrated)
+	}
+
+	server, err := server.NewBitcoinServer(cfg, blockdb, *REINDEX)
 	if err != nil {
 		log.Fatalf("failed to create server: %v", err)
 	}
 
+	if err := server.VerifyChain(*CHECKBLOCKS); err != nil {
+		log.Fatalf("failed to verify chain, restart with -reindex: %v", err)
+	}
+
 	wg := &sync.WaitGroup{}
 
 	go server.MineBlock(wg)
 	go server.BroadcastTx()
 	go server.BroadcastBlock()
 	go server.SyncBlocks(wg)
+	go server.HandshakeNodes()
 
 	listener, err := net.Listen("tcp", cfg.Endpoint)
 	if err != nil {
@@ -57,6 +71,8 @@ func main() {
 	register := grpc.NewServer()
 	protocol.RegisterTransactionServer(register, server)
 	protocol.RegisterBlockServer(register, server)
+	protocol.RegisterQueryServer(register, server)
+	protocol.RegisterNodeServer(register, server)
 	log.Printf("server listening at %v", listener.Addr())
 
 	go gracefulShutdown(register, server)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"Bitcoin/src/collection"
+	"Bitcoin/src/config"
 	"Bitcoin/src/database"
 	"Bitcoin/src/errors"
 	"Bitcoin/src/infra"
@@ -9,44 +10,313 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"sort"
 )
 
 const (
-	MaxBlocksPerGetBlockReq = 100
-	Genesis                 = "Genesis"
+	MaxBlocksPerGetBlockReq    = 100
+	MaxHeadersPerGetHeadersReq = 2000
+	// the locator has the hashes of the last blocks one by one, then the step doubles
+	LocatorDenseHashes = 10
 )
 
 //TODO: more test cases
 
 type BlockService struct {
 	database.IBlockDB
+	cfg *config.Config
 }
 
-func NewBlockService(blockDB database.IBlockDB) *BlockService {
+func NewBlockService(cfg *config.Config, blockDB database.IBlockDB) *BlockService {
 	return &BlockService{
 		IBlockDB: blockDB,
+		cfg:      cfg,
 	}
 }
 
+// GetBlocks returns the blocks after the first block of blockhashes on the chain ending with lastBlockHash,
+// and the number of the last block of the chain
 func (s *BlockService) GetBlocks(lastBlockHash []byte, blockhashes [][]byte, ctxs []context.Context) ([]*model.Block, uint64, error) {
-	for _, blockHash := range blockhashes {
-		ancestors, err := s.ancestors(lastBlockHash, blockHash, ctxs)
+	tip, headers, err := s.after(lastBlockHash, blockhashes, MaxBlocksPerGetBlockReq, ctxs)
+	if headers == nil || err != nil {
+		return nil, 0, err
+	}
+
+	blocks := make([]*model.Block, len(headers))
+	for i, header := range headers {
+		block, err := s.GetBlock(header.Hash, true)
 		if err != nil {
 			return nil, 0, err
 		}
+		if block == nil {
+			return nil, 0, errors.ErrBlockNotFound
+		}
+		blocks[i] = block
+	}
+	return blocks, tip.Number, nil
+}
+
+// GetHeaders returns the headers after the first block of the locator on the chain ending with lastBlockHash
+func (s *BlockService) GetHeaders(lastBlockHash []byte, locator [][]byte, ctxs []context.Context) ([]*model.Block, error) {
+	_, headers, err := s.after(lastBlockHash, locator, MaxHeadersPerGetHeadersReq, ctxs)
+	return headers, err
+}
+
+// GetLocator returns the hashes of the chain from lastBlockHash back to the genesis block,
+// dense near the tip and exponentially sparse further, so the peer finds the fork point with few hashes
+func (s *BlockService) GetLocator(lastBlockHash []byte) ([][]byte, error) {
+	locator := make([][]byte, 0)
+
+	header, err := s.GetHeader(lastBlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var step uint64 = 1
+	for header != nil {
+		locator = append(locator, header.Hash)
+		if header.Number <= 1 {
+			break
+		}
+
+		if len(locator) >= LocatorDenseHashes {
+			step *= 2
+		}
+		var number uint64 = 1
+		if header.Number > step+1 {
+			number = header.Number - step
+		}
+
+		for header != nil && header.Number > number {
+			header, err = s.GetHeader(header.Prevhash)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return locator, nil
+}
+
+// ValidateHeader validates the header against its parent header, the body is not needed
+func (s *BlockService) ValidateHeader(header *model.Block) error {
+	hash, err := validateHash[*model.Block](header.Hash, header)
+	if err != nil {
+		return err
+	}
+
+	err = validateTimestamp(header.Time)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkFailed(header); err != nil {
+		return err
+	}
+
+	existHeader, err := s.GetHeader(hash)
+	if err != nil {
+		return err
+	}
+	if existHeader != nil {
+		return errors.ErrBlockExist
+	}
+
+	prevHeader, err := s.GetHeader(header.Prevhash)
+	if err != nil {
+		return err
+	}
+	if prevHeader == nil {
+		return errors.ErrPrevBlockNotFound
+	}
+
+	return s.validateHeader(header, prevHeader)
+}
+
+// AddHeader validates and saves the header, the body is downloaded after the header chain has the most work
+func (s *BlockService) AddHeader(header *model.Block) error {
+	if err := s.ValidateHeader(header); err != nil {
+		return err
+	}
+	return s.SaveHeader(header)
+}
+
+// BranchWork returns the work of the chains ending with the two blocks after their fork point
+func (s *BlockService) BranchWork(hash, otherHash []byte) (*big.Int, *big.Int, error) {
+	work, otherWork := big.NewInt(0), big.NewInt(0)
+
+	header, err := s.GetHeader(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	otherHeader, err := s.GetHeader(otherHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for header != nil && otherHeader != nil && !bytes.Equal(header.Hash, otherHeader.Hash) {
+		if header.Number >= otherHeader.Number {
+			work.Add(work, infra.Work(header.Bits))
+			header, err = s.GetHeader(header.Prevhash)
+		} else {
+			otherWork.Add(otherWork, infra.Work(otherHeader.Bits))
+			otherHeader, err = s.GetHeader(otherHeader.Prevhash)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if header == nil || otherHeader == nil {
+		return nil, nil, errors.ErrBlockNotFound
+	}
+	return work, otherWork, nil
+}
+
+// MarkInvalid marks the block invalid and its known descendants failed, they are refused since then
+func (s *BlockService) MarkInvalid(block *model.Block) error {
+	index, err := s.GetIndex(block.Hash)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		index = &model.BlockIndex{Hash: block.Hash, Prevhash: block.Prevhash, Number: block.Number, Work: infra.Work(block.Bits)}
+	}
+	index.Status = model.BlockInvalid
+	if err := s.SaveIndex(index); err != nil {
+		return err
+	}
+
+	indexes, err := s.ListIndex()
+	if err != nil {
+		return err
+	}
+	children := make(map[string][]*model.BlockIndex)
+	for _, child := range indexes {
+		children[string(child.Prevhash)] = append(children[string(child.Prevhash)], child)
+	}
 
-		if ancestors != nil {
-			var end uint64
-			if len(ancestors) > 0 {
-				end = ancestors[len(ancestors)-1].Number
+	parents := [][]byte{block.Hash}
+	for len(parents) > 0 {
+		for _, child := range children[string(parents[0])] {
+			child.Status = model.BlockFailedChild
+			if err := s.SaveIndex(child); err != nil {
+				return err
 			}
-			return ancestors[:MaxBlocksPerGetBlockReq], end, nil
+			parents = append(parents, child.Hash)
 		}
+		parents = parents[1:]
 	}
-	return nil, 0, nil
+	return nil
+}
+
+// ChainTips returns the chains ending with the stored blocks without stored children
+func (s *BlockService) ChainTips() ([]*model.Chain, error) {
+	indexes, err := s.ListIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]bool)
+	for _, index := range indexes {
+		if index.Status == model.BlockDataValid {
+			parents[string(index.Prevhash)] = true
+		}
+	}
+
+	chains := make([]*model.Chain, 0)
+	for _, index := range indexes {
+		if index.Status == model.BlockDataValid && !parents[string(index.Hash)] {
+			chains = append(chains, &model.Chain{LastBlockHash: index.Hash, Length: index.Number, Work: index.Work, Seen: index.Seq})
+		}
+	}
+	return chains, nil
+}
+
+// ListChainTips returns the blocks without children in the block tree like getchaintips of bitcoind,
+// the status is one of active, valid-fork, headers-only and invalid
+func (s *BlockService) ListChainTips(mainTip []byte) ([]*model.ChainTip, error) {
+	indexes, err := s.ListIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[string]*model.BlockIndex, len(indexes))
+	parents := make(map[string]bool)
+	for _, index := range indexes {
+		byHash[string(index.Hash)] = index
+		parents[string(index.Prevhash)] = true
+	}
+
+	mainChain := make(map[string]bool)
+	for index := byHash[string(mainTip)]; index != nil; index = byHash[string(index.Prevhash)] {
+		mainChain[string(index.Hash)] = true
+	}
+
+	tips := make([]*model.ChainTip, 0)
+	for _, index := range indexes {
+		// the main tip may have the headers after it
+		if parents[string(index.Hash)] && !bytes.Equal(index.Hash, mainTip) {
+			continue
+		}
+
+		tip := &model.ChainTip{Hash: index.Hash, Number: index.Number, Work: index.Work}
+		for ancestor := index; ancestor != nil && !mainChain[string(ancestor.Hash)]; ancestor = byHash[string(ancestor.Prevhash)] {
+			tip.BranchLen++
+		}
+
+		switch {
+		case bytes.Equal(index.Hash, mainTip):
+			tip.Status = "active"
+		case index.Failed():
+			tip.Status = "invalid"
+		case index.Status == model.BlockHeaderValid:
+			tip.Status = "headers-only"
+		default:
+			tip.Status = "valid-fork"
+		}
+		tips = append(tips, tip)
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Number != tips[j].Number {
+			return tips[i].Number > tips[j].Number
+		}
+		return bytes.Compare(tips[i].Hash, tips[j].Hash) < 0
+	})
+	return tips, nil
+}
+
+// MissingBodies returns the hashes of the headers without the body on the chain ending with the header,
+// ordered from the oldest
+func (s *BlockService) MissingBodies(hash []byte) ([][]byte, error) {
+	hashes := make([][]byte, 0)
+	for {
+		block, err := s.GetBlock(hash, false)
+		if err != nil {
+			return nil, err
+		}
+		if block != nil {
+			break
+		}
+
+		header, err := s.GetHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errors.ErrBlockNotFound
+		}
+		hashes = append(hashes, header.Hash)
+		hash = header.Prevhash
+	}
+
+	for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+		hashes[i], hashes[j] = hashes[j], hashes[i]
+	}
+	return hashes, nil
 }
 
 func (s *BlockService) Validate(block *model.Block) error {
@@ -60,6 +330,10 @@ func (s *BlockService) Validate(block *model.Block) error {
 		return err
 	}
 
+	if err := s.checkFailed(block); err != nil {
+		return err
+	}
+
 	existBlock, err := s.GetBlock(hash, false)
 	if err != nil {
 		return err
@@ -75,88 +349,329 @@ func (s *BlockService) Validate(block *model.Block) error {
 	if prevBlock == nil {
 		return errors.ErrPrevBlockNotFound
 	}
+
+	err = s.validateHeader(block, prevBlock)
+	if err != nil {
+		return err
+	}
+
+	err = validateRootHash(block.RootHash, block.Body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// VerifyBlock checks the saved block again: the hash, the merkle root and the header against its parent,
+// the outputs spent by it are restored from its undo to verify its transactions
+func (s *BlockService) VerifyBlock(block *model.Block) error {
+	if _, err := validateHash[*model.Block](block.Hash, block); err != nil {
+		return err
+	}
+	if err := validateRootHash(block.RootHash, block.Body); err != nil {
+		return err
+	}
+
+	prevBlock, err := s.GetBlock(block.Prevhash, false)
+	if err != nil {
+		return err
+	}
+	if prevBlock == nil {
+		return errors.ErrPrevBlockNotFound
+	}
+	if err := s.validateHeader(block, prevBlock); err != nil {
+		return err
+	}
+	return s.restoreUndo(block)
+}
+
+// Prune deletes the bodies of the blocks deeper than the prune depth from the main tip, from the oldest until
+// the bodies are under the prune size if it is set. The transactions with the unspent outputs are kept to validate
+// the transactions spending them
+func (s *BlockService) Prune(mainTip []byte, unspent func(point model.OutPoint) bool) error {
+	if s.cfg.PruneDepth == 0 {
+		return nil
+	}
+
+	tip, err := s.GetHeader(mainTip)
+	if err != nil || tip == nil || tip.Number <= s.cfg.PruneDepth {
+		return err
+	}
+	last := tip.Number - s.cfg.PruneDepth
+	pruned, err := s.GetPruned()
+	if err != nil || pruned >= last {
+		return err
+	}
+
+	var size, target int64
+	if s.cfg.PruneSize > 0 {
+		target = int64(s.cfg.PruneSize) << 20
+		if size, err = s.BodySize(); err != nil {
+			return err
+		}
+	}
+
+	// the blocks of all the chains from the number pruned to
+	for number := pruned + 1; number <= last; number++ {
+		if s.cfg.PruneSize > 0 && size <= target {
+			break
+		}
+
+		hashes, err := s.ListHashes(number)
+		if err != nil {
+			return err
+		}
+
+		blocks := make([]*model.Block, 0, len(hashes))
+		txs := make([][]byte, 0)
+		for _, hash := range hashes {
+			index, err := s.GetIndex(hash)
+			if err != nil {
+				return err
+			}
+			if index == nil || index.Status != model.BlockDataValid {
+				continue
+			}
+
+			block, err := s.GetBlock(hash, true)
+			if err != nil {
+				return err
+			}
+			if block == nil {
+				continue
+			}
+			blocks = append(blocks, block)
+
+			for _, tx := range block.GetTxs() {
+				if !hasUnspent(tx, unspent) {
+					txs = append(txs, tx.Hash)
+				}
+			}
+		}
+
+		deleted, err := s.PruneBlocks(blocks, txs, number)
+		if err != nil {
+			return err
+		}
+		size -= deleted
+	}
+	return nil
+}
+
+func hasUnspent(tx *model.Transaction, unspent func(point model.OutPoint) bool) bool {
+	for i := range tx.Outs {
+		if unspent(model.OutPoint{Hash: tx.Hash, Index: uint32(i)}) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBlockByNumber finds the block of the number on the chain ending with lastBlockHash by the number index
+func (s *BlockService) GetBlockByNumber(lastBlockHash []byte, number uint64, includeBody bool) (*model.Block, error) {
+	tip, err := s.GetHeader(lastBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if tip == nil || tip.Number < number {
+		return nil, errors.ErrBlockNotFound
+	}
+
+	hash, err := s.ancestor(tip, number)
+	if err != nil {
+		return nil, err
+	}
+	block, err := s.GetBlock(hash, includeBody)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+	return block, nil
+}
+
+// GetReward returns the reward of the block, which is decided by its parent
+func (s *BlockService) GetReward(block *model.Block) (uint64, error) {
+	prevBlock, err := s.GetBlock(block.Prevhash, false)
+	if err != nil {
+		return 0, err
+	}
+	if prevBlock == nil {
+		return 0, errors.ErrPrevBlockNotFound
+	}
+	return prevBlock.GetNextReward(s.cfg.InitRewrad, s.cfg.BlocksPerRewrad), nil
+}
+
+// validateHeader checks the fields of the header against its parent
+func (s *BlockService) validateHeader(block, prevBlock *model.Block) error {
 	if block.Number != prevBlock.Number+1 {
 		return errors.ErrBlockNumberInvalid
 	}
-	if prevBlock.Time.Compare(block.Time) > 0 {
+	if prevBlock.Time.UnixMilli() > block.Time.UnixMilli() {
 		return errors.ErrBlockTooLate
 	}
 
-	err = validateDifficulty(block.Hash, block.Difficulty)
+	err := s.validateContext(block, prevBlock)
 	if err != nil {
 		return err
 	}
 
-	err = validateRootHash(block.RootHash, block.Body)
-	if err != nil {
-		return err
+	return validateDifficulty(block.Hash, block.Bits)
+}
+
+// checkFailed refuses the block marked invalid or descending from an invalid block
+func (s *BlockService) checkFailed(block *model.Block) error {
+	for _, hash := range [][]byte{block.Hash, block.Prevhash} {
+		index, err := s.GetIndex(hash)
+		if err != nil {
+			return err
+		}
+		if index != nil && index.Failed() {
+			return errors.ErrBlockInvalid
+		}
+	}
+	return nil
+}
+
+// validateContext checks the fields of the block decided by its parent and the consensus params
+func (s *BlockService) validateContext(block, prevBlock *model.Block) error {
+	bits := prevBlock.GetNextBits(s.cfg.BlocksPerDifficulty, s.cfg.BlockInterval)
+	if block.Bits != bits {
+		log.Printf("block bits %08x mismatch with the expected %08x", block.Bits, bits)
+		return errors.ErrBlockBitsMismatch
+	}
+
+	totalInterval := prevBlock.GetNextTotalInterval(block.Time, s.cfg.BlocksPerDifficulty)
+	if block.TotalInterval != totalInterval {
+		log.Printf("block total interval %d mismatch with the expected %d", block.TotalInterval, totalInterval)
+		return errors.ErrBlockIntervalMismatch
 	}
 
 	return nil
 }
 
+// GetBlocksOfChain returns the blocks of applyChain after the fork point ordered from the fork point,
+// and the blocks of rollbackChain after the fork point ordered from the tip
 func (s *BlockService) GetBlocksOfChain(applyChain, rollbackChain *model.Chain) ([]*model.Block, []*model.Block, error) {
 	applyBlocks := make([]*model.Block, 0)
 	rollbackBlocks := make([]*model.Block, 0)
 
-	applyBlock, err := s.GetBlock(applyChain.LastBlockHash, true)
+	applyBlock, err := s.getChainBlock(applyChain.LastBlockHash)
 	if err != nil {
 		return nil, nil, err
 	}
-	rollbackBlock, err := s.GetBlock(rollbackChain.LastBlockHash, true)
+	rollbackBlock, err := s.getChainBlock(rollbackChain.LastBlockHash)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// walk down to the same height, then walk down both chains until the fork point
+	for applyBlock.Number > rollbackBlock.Number {
+		applyBlocks = append(applyBlocks, applyBlock)
+		if applyBlock, err = s.getChainBlock(applyBlock.Prevhash); err != nil {
+			return nil, nil, err
+		}
+	}
+	for rollbackBlock.Number > applyBlock.Number {
+		rollbackBlocks = append(rollbackBlocks, rollbackBlock)
+		if rollbackBlock, err = s.getChainBlock(rollbackBlock.Prevhash); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	for !bytes.Equal(rollbackBlock.Hash, applyBlock.Hash) {
 		rollbackBlocks = append(rollbackBlocks, rollbackBlock)
 		applyBlocks = append(applyBlocks, applyBlock)
 
-		applyBlock, err = s.GetBlock(applyBlock.Prevhash, true)
-		if err != nil {
+		if applyBlock, err = s.getChainBlock(applyBlock.Prevhash); err != nil {
 			return nil, nil, err
 		}
-		rollbackBlock, err = s.GetBlock(rollbackBlock.Prevhash, true)
-		if err != nil {
+		if rollbackBlock, err = s.getChainBlock(rollbackBlock.Prevhash); err != nil {
 			return nil, nil, err
 		}
 	}
+
+	// the outputs spent by the rolled back blocks are restored from their undo
+	for _, block := range rollbackBlocks {
+		if err := s.restoreUndo(block); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// apply the blocks from the fork point
+	for i, j := 0, len(applyBlocks)-1; i < j; i, j = i+1, j-1 {
+		applyBlocks[i], applyBlocks[j] = applyBlocks[j], applyBlocks[i]
+	}
 	return applyBlocks, rollbackBlocks, nil
 }
 
-func (s *BlockService) TryAddGenesis(dir string, level uint64) error {
-	size, err := s.Size()
+// getChainBlock returns the block with its body, the chain is broken if it is not saved
+func (s *BlockService) getChainBlock(hash []byte) (*model.Block, error) {
+	block, err := s.GetBlock(hash, true)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+	return block, nil
+}
+
+func (s *BlockService) restoreUndo(block *model.Block) error {
+	undo, err := s.GetUndo(block.Hash)
 	if err != nil {
 		return err
 	}
+	if undo == nil {
+		return errors.ErrBlockUndoNotFound
+	}
+	return undo.Restore(block)
+}
 
-	if size == 0 {
-		data, err := os.ReadFile(fmt.Sprintf("%s/%s", dir, Genesis))
-		if err != nil {
-			return err
-		}
+// TryAddGenesis saves the genesis block of the specification file on an empty database,
+// otherwise the stored genesis block must be the same one
+func (s *BlockService) TryAddGenesis(path string) (*model.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-		var outs []*model.Out
-		if err := json.Unmarshal(data, &outs); err != nil {
-			return err
-		}
+	var genesis model.Genesis
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, err
+	}
 
-		block, err := model.MakeGenesisBlock(outs, level)
-		if err != nil {
-			return err
-		}
+	block, err := model.MakeGenesisBlock(&genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := s.Size()
+	if err != nil {
+		return nil, err
+	}
 
+	if size == 0 {
 		if err = s.SaveBlock(block); err != nil {
-			return err
+			return nil, err
 		}
+		return block, nil
 	}
 
-	return nil
+	stored, err := s.GetBlock(block.Hash, false)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Number != 1 {
+		return nil, errors.ErrGenesisMismatch
+	}
+	return block, nil
 }
 
-func validateDifficulty(hash []byte, difficulty float64) error {
-	actual := infra.ComputeDifficulty(hash)
-	if actual > difficulty {
+func validateDifficulty(hash []byte, bits uint32) error {
+	if !infra.CheckProofOfWork(hash, bits) {
 		return errors.ErrBlockNonceInvalid
 	}
 	return nil
@@ -173,6 +688,23 @@ func validateRootHash(roothash []byte, tree *collection.MerkleTree[*model.Transa
 		return errors.ErrBlockContentInvalid
 	}
 
+	// the leaves must be the hashes of the transactions, otherwise the tree commits to nothing
+	for _, leaf := range tree.Table[0] {
+		if leaf.Val == nil {
+			log.Printf("content has no transaction of leaf %x", leaf.Hash)
+			return errors.ErrBlockContentInvalid
+		}
+
+		hash, err := leaf.Val.ComputeHash()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, leaf.Hash) || !bytes.Equal(hash, leaf.Val.Hash) {
+			log.Printf("transaction hash mismatch with leaf %x", leaf.Hash)
+			return errors.ErrBlockContentInvalid
+		}
+	}
+
 	if !bytes.Equal(roothash, tree.Table[len(tree.Table)-1][0].Hash) {
 		log.Printf("content hash mismatch with root hash")
 		return errors.ErrBlockContentInvalid
@@ -181,23 +713,105 @@ func validateRootHash(roothash []byte, tree *collection.MerkleTree[*model.Transa
 	return nil
 }
 
-func (s *BlockService) ancestors(lastBlockHash, ancestor []byte, ctxs []context.Context) ([]*model.Block, error) {
-	ancestors := make([]*model.Block, 0)
-	for !bytes.Equal(ancestor, lastBlockHash) {
-		for _, ctx := range ctxs {
-			err := context.Cause(ctx)
-			if err != nil {
+// after finds the fork point, the first block of the hashes on the chain ending with lastBlockHash, by walking
+// the chain down from the tip once. It returns the tip and at most max headers after the fork point ordered
+// from the oldest, nil if none of the hashes is on the chain
+func (s *BlockService) after(lastBlockHash []byte, hashes [][]byte, max int, ctxs []context.Context) (*model.Block, []*model.Block, error) {
+	tip, err := s.GetHeader(lastBlockHash)
+	if tip == nil || err != nil {
+		return nil, nil, err
+	}
+
+	// the hashes are ordered from the newest, so the first one on the chain is met first
+	known := make(map[string]bool, len(hashes))
+	var lowest uint64
+	for _, hash := range hashes {
+		header, err := s.GetHeader(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if header == nil || header.Number > tip.Number {
+			continue
+		}
+		if len(known) == 0 || header.Number < lowest {
+			lowest = header.Number
+		}
+		known[string(hash)] = true
+	}
+	if len(known) == 0 {
+		return nil, nil, nil
+	}
+
+	// the path keeps the headers above the fork point from the newest, only the last max of them are returned
+	path := make([]*model.Block, 0)
+	for header := tip; !known[string(header.Hash)]; {
+		if err := checkContexts(ctxs); err != nil {
+			return nil, nil, err
+		}
+		if header.Number <= lowest {
+			return nil, nil, nil
+		}
+
+		path = append(path, header)
+		if len(path) >= 2*max {
+			path = append([]*model.Block(nil), path[len(path)-max:]...)
+		}
+
+		prev, err := s.GetHeader(header.Prevhash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if prev == nil {
+			return nil, nil, errors.ErrBlockNotFound
+		}
+		header = prev
+	}
+
+	headers := make([]*model.Block, 0, len(path))
+	for i := len(path) - 1; i >= 0 && len(headers) < max; i-- {
+		headers = append(headers, path[i])
+	}
+	return tip, headers, nil
+}
+
+// ancestor returns the hash of the block of the number on the chain ending with the tip. All the chains pass the
+// number with only one block in the index, so it walks down from the nearest such number instead of the tip
+func (s *BlockService) ancestor(tip *model.Block, number uint64) ([]byte, error) {
+	header := tip
+	for n := number; n < tip.Number; n++ {
+		hashes, err := s.ListHashes(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(hashes) == 1 {
+			if n == number {
+				return hashes[0], nil
+			}
+			if header, err = s.GetHeader(hashes[0]); err != nil {
 				return nil, err
 			}
+			break
 		}
+	}
 
-		//TODO: split the GetBlock api to GetBlockHeader and GetBlockContent, not include body here
-		block, err := s.GetBlock(lastBlockHash, true)
+	for header != nil && header.Number > number {
+		prev, err := s.GetHeader(header.Prevhash)
 		if err != nil {
 			return nil, err
 		}
-		ancestors = append([]*model.Block{block}, ancestors...)
-		lastBlockHash = block.Prevhash
+		header = prev
+	}
+	if header == nil {
+		return nil, errors.ErrBlockNotFound
+	}
+	return header.Hash, nil
+}
+
+func checkContexts(ctxs []context.Context) error {
+	for _, ctx := range ctxs {
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
 	}
-	return nil, nil
+	return nil
 }
@@ -1,36 +1,30 @@
 package service
 
 import (
-	"Bitcoin/src/collection"
+	"Bitcoin/src/database"
 	"Bitcoin/src/model"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/fs"
-	"os"
+	"sort"
 	"sync"
 )
 
-const (
-	Stat = "stat"
-)
-
 //TODO: test cases
 //TODO: remove too old branches
 
 type ChainService struct {
 	*UtxoService
-	chains *collection.SortedSet[string, uint64, *model.Chain]
+	// the tips of all chains by the last block hash
+	chains map[string]*model.Chain
 	lock   sync.Mutex
 }
 
-func NewChainService(utxo map[string]uint64) *ChainService {
+func NewChainService(db database.IBlockDB, utxo map[string]*model.Out) *ChainService {
 	utxoService := &UtxoService{
+		db:   db,
 		utxo: utxo,
 	}
 	return &ChainService{
 		UtxoService: utxoService,
-		chains:      collection.NewSortedSet[string, uint64, *model.Chain](),
+		chains:      make(map[string]*model.Chain),
 		lock:        sync.Mutex{},
 	}
 }
@@ -39,91 +33,78 @@ func (s *ChainService) GetMainChain() *model.Chain {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	return s.chains.Max()
+	return s.mainChain()
 }
 
-func (s *ChainService) GetChainHashes(m, n int) [][]byte {
+// SetChains replaces the tips, e.g. the ones derived from the block index at startup
+func (s *ChainService) SetChains(chains []*model.Chain) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	blocks := s.chains.TopMax(m, n)
-	blockHashes := make([][]byte, len(blocks))
-	for i := 0; i < len(blocks); i++ {
-		blockHashes[i] = blocks[i].LastBlockHash
+	s.chains = make(map[string]*model.Chain, len(chains))
+	for _, chain := range chains {
+		s.chains[string(chain.LastBlockHash)] = chain
 	}
-	return blockHashes
 }
 
-func (s *ChainService) ApplyChain(block *model.Block) (*model.Chain, *model.Chain) {
+// ApplyChain puts the block of the index on top of its chain, it returns the chain to apply and the chain to rollback:
+// only the apply chain if the block extends the main chain, both if the block makes its chain the new main chain,
+// none if the block is on a side chain
+func (s *ChainService) ApplyChain(index *model.BlockIndex) (*model.Chain, *model.Chain) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	chain := s.chains.Get(string(block.Prevhash), block.Number-1)
+	mainChain := s.mainChain()
+
+	chain := s.chains[string(index.Prevhash)]
 	if chain != nil {
-		chain.LastBlockHash = block.Hash
-		chain.Length = block.Number
+		delete(s.chains, string(chain.LastBlockHash))
 	} else {
-		s.chains.Insert(string(chain.LastBlockHash), chain.Length, chain)
+		chain = &model.Chain{}
 	}
-
-	mainChain := s.chains.Max()
-	if mainChain != chain {
-		return chain, mainChain
-	}
-
-	return nil, nil
-}
-
-func (s *ChainService) ChainLen() int {
-	return s.chains.Len()
-}
-
-type snapshot struct {
-	Chains []*model.Chain
-	Utxo   map[string]uint64
-}
-
-func (s *ChainService) Load(dir string) ([]*model.Chain, error) {
-	data, err := os.ReadFile(fmt.Sprintf("%s/%s", dir, Stat))
-	if errors.Is(err, fs.ErrNotExist) {
-		return []*model.Chain{}, nil
+	chain.LastBlockHash = index.Hash
+	chain.Length = index.Number
+	chain.Work = index.Work
+	chain.Seen = index.Seq
+	s.chains[string(chain.LastBlockHash)] = chain
+
+	if s.mainChain() != chain {
+		return nil, nil
 	}
-	if err != nil {
-		return nil, err
+	if mainChain == nil || mainChain == chain {
+		return chain, nil
 	}
+	return chain, mainChain
+}
 
-	var snap snapshot
-	if err := json.Unmarshal(data, &snap); err != nil {
-		return nil, err
-	}
+// GetChains returns the tips of all chains, from the one with the most work
+func (s *ChainService) GetChains() []*model.Chain {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	for _, chain := range snap.Chains {
-		s.chains.Insert(string(chain.LastBlockHash), chain.Length, chain)
+	chains := make([]*model.Chain, 0, len(s.chains))
+	for _, chain := range s.chains {
+		chains = append(chains, chain)
 	}
-	s.utxo = snap.Utxo
-	return snap.Chains, nil
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].Better(chains[j])
+	})
+	return chains
 }
 
-func (s *ChainService) Save(dir string) error {
-	snap := snapshot{
-		Chains: s.chains.TopMax(0, s.ChainLen()),
-		Utxo:   s.utxo,
-	}
-
-	data, err := json.Marshal(snap)
-	if err != nil {
-		return err
-	}
+func (s *ChainService) ChainLen() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	file, err := os.Create(fmt.Sprintf("%s/%s", dir, Stat))
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	return len(s.chains)
+}
 
-	if _, err := file.Write(data); err != nil {
-		return err
+func (s *ChainService) mainChain() *model.Chain {
+	var best *model.Chain
+	for _, chain := range s.chains {
+		if chain.Better(best) {
+			best = chain
+		}
 	}
-
-	return nil
+	return best
 }
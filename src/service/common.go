@@ -28,3 +28,16 @@ func validateTimestamp(t time.Time) error {
 	}
 	return nil
 }
+
+// IsConsensusErr reports whether the error proves the block breaks the consensus rules,
+// the block may be fine with the other errors, e.g. it is corrupted on the wire or its parent is missing
+func IsConsensusErr(err error) bool {
+	switch err {
+	case errors.ErrBlockNumberInvalid, errors.ErrBlockTooLate, errors.ErrBlockBitsMismatch,
+		errors.ErrBlockIntervalMismatch, errors.ErrBlockNonceInvalid, errors.ErrBlockRewardMismatch,
+		errors.ErrTxCoinbaseInvalid, errors.ErrTxNotEnoughValues, errors.ErrInSigInvalid,
+		errors.ErrInDoubleSpend, errors.ErrInPubkeyMismatch, errors.ErrInTooLate, errors.ErrInLenOutOfIndex:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"Bitcoin/src/model"
+	"Bitcoin/src/protocol"
+	"log"
+	"time"
+)
+
+const (
+	// the blocks after the first not connected one which can be downloaded ahead
+	BlockDownloadWindow = 1024
+	// the blocks requested from a peer at the same time
+	MaxBlocksInFlightPerPeer = 16
+	// the peer is dropped from the download if it doesn't reply in time
+	BlockDownloadTimeout = 20 * time.Second
+)
+
+type downloadResult struct {
+	addr    string
+	indexes []int
+	blocks  []*protocol.BlockReq
+	err     error
+}
+
+// blockDownload tracks the blocks downloaded from several peers, each peer has at most one request in flight,
+// the blocks are connected in order of the hashes
+type blockDownload struct {
+	hashes   [][]byte
+	indexes  map[string]int
+	addrs    []string
+	inFlight map[string][]int
+	failed   map[int]map[string]bool
+	pending  map[int]bool
+	received map[int]*model.Block
+	next     int
+	results  chan *downloadResult
+}
+
+func newBlockDownload(addrs []string, hashes [][]byte) *blockDownload {
+	indexes := make(map[string]int, len(hashes))
+	for i, hash := range hashes {
+		indexes[string(hash)] = i
+	}
+	return &blockDownload{
+		hashes:   hashes,
+		indexes:  indexes,
+		addrs:    addrs,
+		inFlight: make(map[string][]int),
+		failed:   make(map[int]map[string]bool),
+		pending:  make(map[int]bool),
+		received: make(map[int]*model.Block),
+		// one result per peer at most, the late senders are never blocked
+		results: make(chan *downloadResult, len(addrs)),
+	}
+}
+
+// assign picks the blocks in the window to request from the idle peer
+func (d *blockDownload) assign(addr string) []int {
+	if _, busy := d.inFlight[addr]; busy {
+		return nil
+	}
+
+	end := d.next + BlockDownloadWindow
+	if end > len(d.hashes) {
+		end = len(d.hashes)
+	}
+
+	indexes := make([]int, 0, MaxBlocksInFlightPerPeer)
+	for i := d.next; i < end && len(indexes) < MaxBlocksInFlightPerPeer; i++ {
+		if d.pending[i] || d.received[i] != nil || d.failed[i][addr] {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	for _, i := range indexes {
+		d.pending[i] = true
+	}
+	d.inFlight[addr] = indexes
+	return indexes
+}
+
+// receive keeps the requested blocks, the missing ones are reassigned to the other peers,
+// the peer failing the request is dropped
+func (d *blockDownload) receive(result *downloadResult) {
+	delete(d.inFlight, result.addr)
+	for _, i := range result.indexes {
+		delete(d.pending, i)
+	}
+
+	if result.err != nil {
+		log.Printf("download blocks from %v error: %v", result.addr, result.err)
+		d.drop(result.addr)
+		return
+	}
+
+	for _, blockReq := range result.blocks {
+		block, err := model.BlockFrom(blockReq)
+		if err != nil {
+			log.Printf("block from %v is invalid: %v", result.addr, err)
+			continue
+		}
+		i, has := d.indexes[string(block.Hash)]
+		if !has || i < d.next || d.received[i] != nil {
+			continue
+		}
+		d.received[i] = block
+	}
+
+	for _, i := range result.indexes {
+		if d.received[i] == nil {
+			if d.failed[i] == nil {
+				d.failed[i] = make(map[string]bool)
+			}
+			d.failed[i][result.addr] = true
+		}
+	}
+}
+
+func (d *blockDownload) drop(addr string) {
+	for i, a := range d.addrs {
+		if a == addr {
+			d.addrs = append(d.addrs[:i], d.addrs[i+1:]...)
+			return
+		}
+	}
+}
+
+// pop returns the next block to connect, nil if it's not downloaded yet
+func (d *blockDownload) pop() *model.Block {
+	block := d.received[d.next]
+	if block != nil {
+		delete(d.received, d.next)
+		delete(d.failed, d.next)
+		d.next++
+	}
+	return block
+}
+
+func (d *blockDownload) done() bool {
+	return d.next >= len(d.hashes)
+}
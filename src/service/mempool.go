@@ -3,6 +3,7 @@ package service
 import (
 	"Bitcoin/src/collection"
 	"Bitcoin/src/model"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,12 +20,15 @@ const (
 type MemPool struct {
 	maxTxSize int
 	mempool   *collection.SortedSet[string, uint64, *model.Transaction]
+	// the outputs spent by the transactions in the pool, map to the hash of the spending transaction
+	spends map[string][]byte
 }
 
 func NewMemPool(maxTxSize int) *MemPool {
 	return &MemPool{
 		maxTxSize: maxTxSize,
 		mempool:   collection.NewSortedSet[string, uint64, *model.Transaction](),
+		spends:    make(map[string][]byte),
 	}
 }
 
@@ -32,21 +36,39 @@ func (pool *MemPool) Get(hash []byte) *model.Transaction {
 	return pool.mempool.Get1(string(hash))
 }
 
+// Conflict reports whether tx spends an output which is already spent by another transaction in the pool
+func (pool *MemPool) Conflict(tx *model.Transaction) bool {
+	for _, in := range tx.Ins {
+		hash, has := pool.spends[in.OutPoint().Key()]
+		if has && !bytes.Equal(hash, tx.Hash) {
+			return true
+		}
+	}
+	return false
+}
+
 func (pool *MemPool) Put(tx *model.Transaction) {
 	pool.mempool.Insert(string(tx.Hash), tx.Fee, tx)
+	pool.addSpends(tx)
 	if pool.mempool.Len() > pool.maxTxSize {
 		min := pool.mempool.Min()
 		pool.mempool.Remove(string(min.Hash), min.Fee)
+		pool.removeSpends(min)
 	}
 }
 
 func (pool *MemPool) PopMax() *model.Transaction {
-	return pool.mempool.PopMax()
+	tx := pool.mempool.PopMax()
+	if tx != nil {
+		pool.removeSpends(tx)
+	}
+	return tx
 }
 
 func (pool *MemPool) Remove(txs []*model.Transaction) {
 	for _, tx := range txs {
 		pool.mempool.Remove(string(tx.Hash), tx.Fee)
+		pool.removeSpends(tx)
 	}
 }
 
@@ -54,6 +76,21 @@ func (pool *MemPool) Len() int {
 	return pool.mempool.Len()
 }
 
+func (pool *MemPool) addSpends(tx *model.Transaction) {
+	for _, in := range tx.Ins {
+		pool.spends[in.OutPoint().Key()] = tx.Hash
+	}
+}
+
+func (pool *MemPool) removeSpends(tx *model.Transaction) {
+	for _, in := range tx.Ins {
+		key := in.OutPoint().Key()
+		if bytes.Equal(pool.spends[key], tx.Hash) {
+			delete(pool.spends, key)
+		}
+	}
+}
+
 func (pool *MemPool) Load(dir string) error {
 	data, err := os.ReadFile(fmt.Sprintf("%s/%s", dir, MEMPOOL))
 	if errors.Is(err, fs.ErrNotExist) {
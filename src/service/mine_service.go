@@ -5,6 +5,7 @@ import (
 	"Bitcoin/src/config"
 	"Bitcoin/src/model"
 	"context"
+	"log"
 	"sync"
 	"time"
 )
@@ -43,14 +44,14 @@ func (s *MineService) mineBlock(lastBlock *model.Block, transactions []*model.Tr
 	}
 
 	now := time.Now().UTC()
-	difficulty := lastBlock.GetNextDifficulty(s.cfg.BlocksPerDifficulty, s.cfg.BlockInterval)
+	bits := lastBlock.GetNextBits(s.cfg.BlocksPerDifficulty, s.cfg.BlockInterval)
 	totalInterval := lastBlock.GetNextTotalInterval(now, s.cfg.BlocksPerDifficulty)
 
 	block := &model.Block{
 		Number:        lastBlock.Number + 1,
 		Prevhash:      lastBlock.Hash,
 		RootHash:      content.Table[len(content.Table)-1][0].Hash,
-		Difficulty:    difficulty,
+		Bits:          bits,
 		Time:          now,
 		TotalInterval: totalInterval,
 		Miner:         s.cfg.Server,
@@ -81,14 +82,16 @@ func (s *MineService) fetchTxs(reward uint64) ([]*model.Transaction, error) {
 		tx := s.mempool.PopMax()
 		err := s.txService.ValidateTx(tx, f)
 
+		// the transaction may be invalid since its inputs are spent by the new blocks
 		if err != nil {
-			return nil, err
+			log.Printf("drop transaction %x: %v", tx.Hash, err)
+			continue
 		}
 		totalFee += tx.Fee
 		txmap[string(tx.Hash)] = tx
 	}
 
-	coinbaseTx, err := model.MakeCoinbaseTx(s.cfg.MinerPubkey, reward+totalFee)
+	coinbaseTx, err := model.MakeCoinbaseTx(s.cfg.MinerAddress, reward+totalFee)
 	if err != nil {
 		return nil, err
 	}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"Bitcoin/src/bitcoin/client"
+	"Bitcoin/src/collection"
+	"Bitcoin/src/errors"
 	"Bitcoin/src/model"
 	"Bitcoin/src/protocol"
+	"bytes"
 	"fmt"
 	"log"
 	"math/rand"
@@ -13,41 +16,68 @@ import (
 
 const (
 	MaxBroadcastNodes = 10
+	// the hashes of the blocks and transactions recently seen by this node
+	MaxSeenItems = 100000
 )
 
 //TODO: maybe we can use more complex policy to remove inactive nodes
 
 type NodeService struct {
-	lock     sync.RWMutex
-	nodes    map[string]*model.Node
-	endpoint string
+	lock        sync.RWMutex
+	nodes       map[string]*model.Node
+	handshaking map[string]bool
+	endpoint    string
+	network     string
+	genesis     []byte
+	heightFunc  func() uint64
+	seen        *collection.SeenSet
 }
 
-type sendFunc[Q, R any] func(cli client.IBitcoinClient, req Q) (R, error)
-
 func NewNodeService(endpoint string, bootstraps []string) *NodeService {
 	service := &NodeService{
-		lock:     sync.RWMutex{},
-		nodes:    make(map[string]*model.Node),
-		endpoint: endpoint,
+		lock:        sync.RWMutex{},
+		nodes:       make(map[string]*model.Node),
+		handshaking: make(map[string]bool),
+		endpoint:    endpoint,
+		heightFunc:  func() uint64 { return 0 },
+		seen:        collection.NewSeenSet(MaxSeenItems),
 	}
-	if bootstraps != nil {
-		for _, addr := range bootstraps {
-			service.nodes[addr] = &model.Node{Addr: addr}
-		}
+	for _, addr := range bootstraps {
+		service.nodes[addr] = model.NewNode(addr, client.NewBitcoinClient(addr))
 	}
 	return service
 }
 
+// SetIdentity sets the network, the genesis block and the height of this node announced in the handshakes
+func (service *NodeService) SetIdentity(network string, genesis []byte, heightFunc func() uint64) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	service.network = network
+	service.genesis = genesis
+	service.heightFunc = heightFunc
+}
+
+// AddAddrs adds the unknown nodes and handshakes with the nodes not handshaked yet in background
 func (service *NodeService) AddAddrs(addrs []string) error {
 	nodes, err := toNodes(addrs)
 	if err != nil {
 		return err
 	}
 
-	return service.AddNodes(nodes...)
+	if err := service.AddNodes(nodes...); err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if node.Addr != service.endpoint {
+			go service.Handshake(node.Addr)
+		}
+	}
+	return nil
 }
 
+// AddNodes adds the unknown nodes, the known nodes are kept with their handshake
 func (service *NodeService) AddNodes(nodes ...*model.Node) error {
 	service.lock.Lock()
 	defer service.lock.Unlock()
@@ -56,73 +86,297 @@ func (service *NodeService) AddNodes(nodes ...*model.Node) error {
 		if node == nil {
 			log.Fatalf("node is nil")
 		}
-		service.nodes[node.Addr] = node
+		if _, has := service.nodes[node.Addr]; !has {
+			if node.Known == nil {
+				node.Known = collection.NewSeenSet(model.MaxKnownItems)
+			}
+			service.nodes[node.Addr] = node
+		}
 	}
 	return nil
 }
 
+// LocalHandshake returns the handshake of this node
+func (service *NodeService) LocalHandshake() *model.Handshake {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	return &model.Handshake{
+		Version:  model.ProtocolVersion,
+		Network:  service.network,
+		Genesis:  service.genesis,
+		Height:   service.heightFunc(),
+		Features: model.Features,
+		Addr:     service.endpoint,
+	}
+}
+
+// CheckHandshake refuses the peers of an old protocol version, another network or another genesis block
+func (service *NodeService) CheckHandshake(handshake *model.Handshake) error {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	if handshake.Version < model.MinProtocolVersion {
+		return errors.ErrPeerVersionTooOld
+	}
+	if handshake.Network != service.network {
+		return errors.ErrPeerNetworkMismatch
+	}
+	if !bytes.Equal(handshake.Genesis, service.genesis) {
+		return errors.ErrPeerGenesisMismatch
+	}
+	return nil
+}
+
+// AcceptHandshake handles the handshake from the peer, the peer is added as a handshaked node if accepted
+func (service *NodeService) AcceptHandshake(handshake *model.Handshake) (*model.Handshake, error) {
+	if err := service.CheckHandshake(handshake); err != nil {
+		return nil, err
+	}
+
+	if handshake.Addr != "" && handshake.Addr != service.endpoint {
+		nodes, err := toNodes([]string{handshake.Addr})
+		if err != nil {
+			return nil, err
+		}
+
+		service.lock.Lock()
+		node, has := service.nodes[handshake.Addr]
+		if !has {
+			node = nodes[0]
+			service.nodes[node.Addr] = node
+		}
+		node.Accept(handshake)
+		service.lock.Unlock()
+	}
+
+	return service.LocalHandshake(), nil
+}
+
+// IsHandshaked reports whether the node of the address has finished the handshake
+func (service *NodeService) IsHandshaked(addr string) bool {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	node, has := service.nodes[addr]
+	return has && node.Handshaked
+}
+
+// Handshake sends the handshake to the node, the node is removed if it's refused by either side
+func (service *NodeService) Handshake(addr string) error {
+	service.lock.Lock()
+	node, has := service.nodes[addr]
+	if !has {
+		service.lock.Unlock()
+		return errors.ErrPeerNotFound
+	}
+	if node.Handshaked || service.handshaking[addr] {
+		service.lock.Unlock()
+		return nil
+	}
+	service.handshaking[addr] = true
+	service.lock.Unlock()
+
+	defer func() {
+		service.lock.Lock()
+		delete(service.handshaking, addr)
+		service.lock.Unlock()
+	}()
+
+	reply, err := node.Client.Handshake(model.HandshakeTo(service.LocalHandshake()))
+	if err == nil {
+		handshake := model.HandshakeFromReply(reply, addr)
+		err = service.CheckHandshake(handshake)
+		if err == nil {
+			service.lock.Lock()
+			node.Accept(handshake)
+			service.lock.Unlock()
+			return nil
+		}
+	}
+
+	log.Printf("handshake with %v failed: %v", addr, err)
+	if err == errors.ErrPeerVersionTooOld || err == errors.ErrPeerNetworkMismatch || err == errors.ErrPeerGenesisMismatch || node.UpdateState(err) {
+		service.lock.Lock()
+		delete(service.nodes, addr)
+		service.lock.Unlock()
+	}
+	return err
+}
+
+// HandshakeNodes handshakes with all the nodes not handshaked yet
+func (service *NodeService) HandshakeNodes() {
+	service.lock.RLock()
+	addrs := make([]string, 0, len(service.nodes))
+	for addr, node := range service.nodes {
+		if !node.Handshaked {
+			addrs = append(addrs, addr)
+		}
+	}
+	service.lock.RUnlock()
+
+	wg := &sync.WaitGroup{}
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			service.Handshake(addr)
+			wg.Done()
+		}(addr)
+	}
+	wg.Wait()
+}
+
+// UpdateHeight raises the height of the node, e.g. the node sends a block higher than its handshake
+func (service *NodeService) UpdateHeight(addr string, height uint64) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	node, has := service.nodes[addr]
+	if has && node.Height < height {
+		node.Height = height
+	}
+}
+
+// BestNode returns the handshaked node with the highest chain, nil if no node is higher than the height
+func (service *NodeService) BestNode(height uint64) *model.Node {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	var best *model.Node
+	for _, node := range service.nodes {
+		if node.Handshaked && node.Height > height && (best == nil || node.Height > best.Height) {
+			best = node
+		}
+	}
+	return best
+}
+
+// DownloadNodes returns the node first, then the other handshaked nodes claiming the block of the number
+func (service *NodeService) DownloadNodes(addr string, number uint64) []string {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	addrs := []string{addr}
+	for _, node := range service.nodes {
+		if node.Addr != addr && node.Handshaked && node.Height >= number {
+			addrs = append(addrs, node.Addr)
+		}
+	}
+	return addrs
+}
+
 // TODO: remove?
 func (service *NodeService) GetNode(addr string) *model.Node {
 	return service.nodes[addr]
 }
 
-func (service *NodeService) SendTx(tx *model.Transaction) {
-	send := func(cli client.IBitcoinClient, req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
-		return cli.SendTx(req)
+// MarkSeen marks the block or transaction as seen by this node, false if it's seen already
+func (service *NodeService) MarkSeen(hash []byte) bool {
+	return service.seen.Add(hash)
+}
+
+// Forget forgets the seen block or transaction, e.g. fetching it failed, so it can be fetched again
+func (service *NodeService) Forget(hash []byte) {
+	service.seen.Remove(hash)
+}
+
+// MarkKnown marks the blocks or transactions as known by the peer, they are not announced to it
+func (service *NodeService) MarkKnown(addr string, hashes ...[]byte) {
+	service.lock.RLock()
+	node, has := service.nodes[addr]
+	service.lock.RUnlock()
+	if !has {
+		return
 	}
 
-	req := model.TransactionTo(tx)
-	req.Nodes = service.RandomPickAddrs(MaxBroadcastNodes)
+	for _, hash := range hashes {
+		node.Known.Add(hash)
+	}
+}
 
-	broadcastReq[*protocol.TransactionReq](service, req, send)
+func (service *NodeService) AnnounceTx(tx *model.Transaction) {
+	service.announce(&protocol.InvItem{Type: protocol.InvType_INV_TX, Hash: tx.Hash})
 }
 
-func (service *NodeService) SendBlock(block *model.Block) {
-	send := func(cli client.IBitcoinClient, req *protocol.BlockReq) (*protocol.BlockReply, error) {
-		return cli.SendBlock(req)
-	}
+func (service *NodeService) AnnounceBlock(block *model.Block) {
+	service.announce(&protocol.InvItem{Type: protocol.InvType_INV_BLOCK, Hash: block.Hash})
+}
 
-	blockReq, err := model.BlockTo(block)
-	if err != nil {
-		log.Printf("convert to block request error: %v", err)
-		return
+// GetData fetches the announced blocks and transactions from the node
+func (service *NodeService) GetData(addr string, items []*protocol.InvItem) (*protocol.GetDataReply, error) {
+	service.lock.RLock()
+	node, has := service.nodes[addr]
+	service.lock.RUnlock()
+	if !has {
+		return nil, errors.ErrPeerNotFound
 	}
 
-	broadcastReq[*protocol.BlockReq](service, blockReq, send)
+	reply, err := node.Client.GetData(&protocol.GetDataReq{Items: items, Node: service.endpoint})
+	if node.UpdateState(err) {
+		service.lock.Lock()
+		delete(service.nodes, addr)
+		service.lock.Unlock()
+	}
+	return reply, err
 }
 
-// TODO: test cases
-func (service *NodeService) GetBlocks(blockHashes [][]byte, addr string) ([]*protocol.BlockReq, uint64, error) {
-	req := &protocol.GetBlocksReq{
-		Blockhashes: blockHashes,
+// GetHeaders fetches the headers after the first block of the locator on the main chain of the node
+func (service *NodeService) GetHeaders(locator [][]byte, addr string) ([]*protocol.BlockReq, error) {
+	service.lock.RLock()
+	node, has := service.nodes[addr]
+	service.lock.RUnlock()
+	if !has {
+		return nil, errors.ErrPeerNotFound
 	}
-	node := service.nodes[addr]
-	reply, err := node.Client.GetBlocks(req)
-	removed := node.UpdateState(err)
 
-	if removed {
+	reply, err := node.Client.GetHeaders(&protocol.GetHeadersReq{Locator: locator, Node: service.endpoint})
+	if node.UpdateState(err) {
 		service.lock.Lock()
 		delete(service.nodes, addr)
 		service.lock.Unlock()
 	}
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	return reply.Blocks, reply.End, nil
+	return reply.Headers, nil
 }
 
-func broadcastReq[Q, R any](service *NodeService, req Q, send sendFunc[Q, R]) {
-	deleted := make([]string, 0, len(service.nodes))
+// announce sends the hashes to the relay nodes which don't know them yet, so each block or transaction
+// is announced to a node at most once, the nodes fetch the ones they lack by GetData
+func (service *NodeService) announce(items ...*protocol.InvItem) {
+	for _, item := range items {
+		service.MarkSeen(item.Hash)
+	}
+
+	addrs := service.RandomPickAddrs(MaxBroadcastNodes)
+	deleted := make([]string, 0)
+	lock := sync.Mutex{}
 	wg := &sync.WaitGroup{}
 
-	nodes := service.RandomPickNodes(MaxBroadcastNodes)
-	for _, node := range nodes {
+	for _, node := range service.relayNodes() {
+		unknown := make([]*protocol.InvItem, 0, len(items))
+		for _, item := range items {
+			if node.Known.Add(item.Hash) {
+				unknown = append(unknown, item)
+			}
+		}
+		if len(unknown) == 0 {
+			continue
+		}
+
 		wg.Add(1)
 		go func(n *model.Node) {
-			_, err := send(n.Client, req)
-			removed := n.UpdateState(err)
-			if removed {
+			_, err := n.Client.Inv(&protocol.InvReq{Items: unknown, Node: service.endpoint, Nodes: addrs})
+			if err != nil {
+				// the node doesn't receive them, so announce them again next time
+				for _, item := range unknown {
+					n.Known.Remove(item.Hash)
+				}
+			}
+			if n.UpdateState(err) {
+				lock.Lock()
 				deleted = append(deleted, n.Addr)
+				lock.Unlock()
 			}
 			wg.Done()
 		}(node)
@@ -136,6 +390,20 @@ func broadcastReq[Q, R any](service *NodeService, req Q, send sendFunc[Q, R]) {
 	service.lock.Unlock()
 }
 
+// relayNodes returns the handshaked nodes which relay the blocks and transactions
+func (service *NodeService) relayNodes() []*model.Node {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	nodes := make([]*model.Node, 0, len(service.nodes))
+	for _, node := range service.nodes {
+		if node.Handshaked && node.HasFeature(model.FeatureRelay) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
 func (service *NodeService) RandomPickAddrs(n int) []string {
 	service.lock.RLock()
 	addrs := make([]string, 0, len(service.nodes))
@@ -156,25 +424,6 @@ func (service *NodeService) RandomPickAddrs(n int) []string {
 	return selects
 }
 
-func (service *NodeService) RandomPickNodes(n int) []*model.Node {
-	service.lock.RLock()
-	nodes := make([]*model.Node, 0, len(service.nodes))
-	for _, node := range service.nodes {
-		nodes = append(nodes, node)
-	}
-	if n > len(nodes) {
-		n = len(nodes)
-	}
-	service.lock.RUnlock()
-
-	indics := rand.Perm(n)
-	selects := make([]*model.Node, 0, n)
-	for i := 0; i < n; i++ {
-		selects = append(selects, nodes[indics[i]])
-	}
-	return selects
-}
-
 func toNodes(addrs []string) ([]*model.Node, error) {
 	nodes := make([]*model.Node, len(addrs))
 
@@ -185,11 +434,7 @@ func toNodes(addrs []string) ([]*model.Node, error) {
 			return nil, fmt.Errorf("the format of node %s error: %s", addr, err)
 		}
 
-		node := &model.Node{
-			Addr:   addr,
-			Client: client.NewBitcoinClient(addr),
-		}
-		nodes[i] = node
+		nodes[i] = model.NewNode(addr, client.NewBitcoinClient(addr))
 	}
 	return nodes, nil
 }
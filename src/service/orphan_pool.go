@@ -0,0 +1,203 @@
+package service
+
+import (
+	"Bitcoin/src/model"
+	"sync"
+	"time"
+)
+
+const (
+	MaxOrphanBlocks        = 100
+	MaxOrphanBlocksPerPeer = 10
+	OrphanBlockExpiry      = 20 * time.Minute
+
+	MaxOrphanTxs        = 100
+	MaxOrphanTxsPerPeer = 10
+	OrphanTxExpiry      = 20 * time.Minute
+)
+
+// Orphan is the block or the transaction whose parents are not known yet, and the peer sending it
+type Orphan[T any] struct {
+	Item   T
+	Addr   string
+	expire time.Time
+}
+
+// OrphanPool keeps the orphans for a bounded time, they are added again once their parents arrive,
+// the orphans are keyed by their hashes and waiting for the hashes of their parents
+type OrphanPool[T any] struct {
+	max        int
+	maxPerPeer int
+	expiry     time.Duration
+	hash       func(item T) []byte
+	parents    func(item T) [][]byte
+	orphans    map[string]*Orphan[T]
+	// the missing parent hash, map to the hashes of the orphans waiting for it in the order they are put
+	children map[string][][]byte
+	// the orphans received from each peer
+	peers map[string]int
+	lock  sync.Mutex
+}
+
+func NewOrphanPool[T any](max, maxPerPeer int, expiry time.Duration, hash func(item T) []byte, parents func(item T) [][]byte) *OrphanPool[T] {
+	return &OrphanPool[T]{
+		max:        max,
+		maxPerPeer: maxPerPeer,
+		expiry:     expiry,
+		hash:       hash,
+		parents:    parents,
+		orphans:    make(map[string]*Orphan[T]),
+		children:   make(map[string][][]byte),
+		peers:      make(map[string]int),
+	}
+}
+
+// NewOrphanBlockPool keeps the blocks whose parent is not known yet
+func NewOrphanBlockPool() *OrphanPool[*model.Block] {
+	return NewOrphanPool(MaxOrphanBlocks, MaxOrphanBlocksPerPeer, OrphanBlockExpiry,
+		func(block *model.Block) []byte { return block.Hash },
+		func(block *model.Block) [][]byte { return [][]byte{block.Prevhash} })
+}
+
+// NewOrphanTxPool keeps the transactions spending the outputs of unknown transactions
+func NewOrphanTxPool() *OrphanPool[*model.Transaction] {
+	return NewOrphanPool(MaxOrphanTxs, MaxOrphanTxsPerPeer, OrphanTxExpiry,
+		func(tx *model.Transaction) []byte { return tx.Hash },
+		func(tx *model.Transaction) [][]byte {
+			parents := make([][]byte, len(tx.Ins))
+			for i, in := range tx.Ins {
+				parents[i] = in.PrevHash
+			}
+			return parents
+		})
+}
+
+// Put adds the orphan received from the peer, false if it's already in the pool or the peer sent too many,
+// the orphan expiring first is evicted if the pool is full
+func (pool *OrphanPool[T]) Put(item T, addr string) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	now := time.Now()
+	pool.expire(now)
+
+	hash := string(pool.hash(item))
+	if _, has := pool.orphans[hash]; has {
+		return false
+	}
+	if pool.peers[addr] >= pool.maxPerPeer {
+		return false
+	}
+
+	if len(pool.orphans) >= pool.max {
+		var oldest *Orphan[T]
+		for _, orphan := range pool.orphans {
+			if oldest == nil || orphan.expire.Before(oldest.expire) {
+				oldest = orphan
+			}
+		}
+		pool.remove(pool.hash(oldest.Item))
+	}
+
+	pool.orphans[hash] = &Orphan[T]{Item: item, Addr: addr, expire: now.Add(pool.expiry)}
+	for _, parent := range pool.parentKeys(item) {
+		pool.children[parent] = append(pool.children[parent], []byte(hash))
+	}
+	pool.peers[addr]++
+	return true
+}
+
+func (pool *OrphanPool[T]) Has(hash []byte) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	_, has := pool.orphans[string(hash)]
+	return has
+}
+
+// Take removes and returns the orphan, e.g. it's downloaded or confirmed by a block, nil if it's not in the pool
+func (pool *OrphanPool[T]) Take(hash []byte) *Orphan[T] {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	return pool.remove(hash)
+}
+
+// PopChildren removes and returns the orphans waiting for the parent
+func (pool *OrphanPool[T]) PopChildren(parentHash []byte) []*Orphan[T] {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	hashes := append([][]byte(nil), pool.children[string(parentHash)]...)
+	children := make([]*Orphan[T], 0, len(hashes))
+	for _, hash := range hashes {
+		if orphan := pool.remove(hash); orphan != nil {
+			children = append(children, orphan)
+		}
+	}
+	return children
+}
+
+// Expire removes the orphans expired before now
+func (pool *OrphanPool[T]) Expire(now time.Time) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.expire(now)
+}
+
+func (pool *OrphanPool[T]) Len() int {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	return len(pool.orphans)
+}
+
+func (pool *OrphanPool[T]) expire(now time.Time) {
+	for _, orphan := range pool.orphans {
+		if orphan.expire.Before(now) {
+			pool.remove(pool.hash(orphan.Item))
+		}
+	}
+}
+
+func (pool *OrphanPool[T]) remove(hash []byte) *Orphan[T] {
+	orphan, has := pool.orphans[string(hash)]
+	if !has {
+		return nil
+	}
+	delete(pool.orphans, string(hash))
+
+	for _, parent := range pool.parentKeys(orphan.Item) {
+		siblings := pool.children[parent]
+		for i, sibling := range siblings {
+			if string(sibling) == string(hash) {
+				siblings = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+		if len(siblings) == 0 {
+			delete(pool.children, parent)
+		} else {
+			pool.children[parent] = siblings
+		}
+	}
+
+	if pool.peers[orphan.Addr]--; pool.peers[orphan.Addr] <= 0 {
+		delete(pool.peers, orphan.Addr)
+	}
+	return orphan
+}
+
+// parentKeys returns the parents of the orphan once each, e.g. the transaction spends two outputs of the parent
+func (pool *OrphanPool[T]) parentKeys(item T) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, parent := range pool.parents(item) {
+		if !seen[string(parent)] {
+			seen[string(parent)] = true
+			keys = append(keys, string(parent))
+		}
+	}
+	return keys
+}
@@ -5,10 +5,7 @@ import (
 	"Bitcoin/src/model"
 	"Bitcoin/src/protocol"
 	"log"
-)
-
-const (
-	BatchSizePerGetBlocksReq = 10
+	"time"
 )
 
 type AddBlockFunc func(block *model.Block) error
@@ -16,72 +13,183 @@ type AddBlockFunc func(block *model.Block) error
 type SyncService struct {
 	chainService *ChainService
 	nodeService  *NodeService
+	blockService *BlockService
+	orphanPool   *OrphanPool[*model.Block]
 	addBlockFunc AddBlockFunc
+
+	downloadTimeout time.Duration
 }
 
-func NewSyncService(chainService *ChainService, nodeService *NodeService, addBlockFunc AddBlockFunc) *SyncService {
+func NewSyncService(chainService *ChainService, nodeService *NodeService, blockService *BlockService, orphanPool *OrphanPool[*model.Block], addBlockFunc AddBlockFunc) *SyncService {
 	return &SyncService{
 		chainService: chainService,
 		nodeService:  nodeService,
+		blockService: blockService,
+		orphanPool:   orphanPool,
 		addBlockFunc: addBlockFunc,
+
+		downloadTimeout: BlockDownloadTimeout,
 	}
 }
 
-func (s *SyncService) SyncBlocks(addr string) {
-	blockReqs, end, err := s.getBlocks(addr)
-	for {
+// SetDownloadTimeout sets how long a peer can take to reply the requested blocks
+func (s *SyncService) SetDownloadTimeout(timeout time.Duration) {
+	s.downloadTimeout = timeout
+}
+
+// Sync syncs the blocks from the handshaked node with the highest chain, if it's higher than the main chain
+func (s *SyncService) Sync() {
+	var height uint64
+	if mainChain := s.chainService.GetMainChain(); mainChain != nil {
+		height = mainChain.Length
+	}
+
+	node := s.nodeService.BestNode(height)
+	if node == nil {
+		log.Printf("no node is higher than %d to sync from", height)
+		return
+	}
+	if err := s.SyncBlocks(node.Addr); err != nil {
+		log.Printf("sync blocks from %v error: %v", node.Addr, err)
+	}
+}
+
+// SyncBlocks syncs the headers from the node first, the bodies are only downloaded
+// if the header chain has more work than the main chain
+func (s *SyncService) SyncBlocks(addr string) error {
+	tip, err := s.SyncHeaders(addr)
+	if err != nil {
+		// the valid headers before the error are still worth syncing
+		log.Printf("sync headers from %v error: %v", addr, err)
+	}
+	if tip == nil {
+		return err
+	}
+
+	mainChain := s.chainService.GetMainChain()
+	if mainChain != nil {
+		work, mainWork, err := s.blockService.BranchWork(tip.Hash, mainChain.LastBlockHash)
 		if err != nil {
-			log.Printf("get blocks from %v error: %v", addr, err)
-			break
+			return err
+		}
+		if work.Cmp(mainWork) <= 0 {
+			log.Printf("the header chain of %v has no more work than the main chain", addr)
+			return nil
 		}
+	}
+
+	return s.syncBodies(addr, tip)
+}
+
+// SyncHeaders validates and saves the headers of the main chain of the node after the fork point,
+// it returns the last valid header, nil if there is no new header
+func (s *SyncService) SyncHeaders(addr string) (*model.Block, error) {
+	mainChain := s.chainService.GetMainChain()
+	if mainChain == nil {
+		return nil, nil
+	}
+
+	locator, err := s.blockService.GetLocator(mainChain.LastBlockHash)
+	if err != nil {
+		return nil, err
+	}
 
-		if blockReqs == nil && len(blockReqs) == 0 {
-			break
+	var tip *model.Block
+	for {
+		headerReqs, err := s.nodeService.GetHeaders(locator, addr)
+		if err != nil {
+			return tip, err
 		}
 
-		var block *model.Block
-		for _, blockReq := range blockReqs {
-			block, err = model.BlockFrom(blockReq)
-			if err != nil {
-				break
-			}
-			err = s.addBlockFunc(block)
-			if err == errors.ErrBlockExist {
-				// it's possible that my main chain is not main chain anymore,
-				// so I pull all blocks of the new main chain, the new main chain is side chain previously,
-				// some blocks of the new main chain already exist, so ignore this error
-				continue
-			}
-			if err != nil {
-				break
+		for _, headerReq := range headerReqs {
+			header := model.HeaderFrom(headerReq)
+			// the header exists if the sync was interrupted or the block was relayed
+			if err := s.blockService.AddHeader(header); err != nil && err != errors.ErrBlockExist {
+				return tip, err
 			}
+			tip = header
 		}
 
-		if err != nil {
-			log.Printf("add block from %v error: %v", addr, err)
-			break
+		if len(headerReqs) < MaxHeadersPerGetHeadersReq {
+			return tip, nil
 		}
+		locator = [][]byte{tip.Hash}
+	}
+}
 
-		if block.Number == end {
-			break
-		}
+// syncBodies downloads the bodies of the header chain ending with the tip from the node and the other peers
+func (s *SyncService) syncBodies(addr string, tip *model.Block) error {
+	hashes, err := s.blockService.MissingBodies(tip.Hash)
+	if err != nil || len(hashes) == 0 {
+		return err
+	}
 
-		blockReqs, end, err = s.nodeService.GetBlocks([][]byte{block.Hash}, addr)
+	header, err := s.blockService.GetHeader(hashes[0])
+	if err != nil {
+		return err
 	}
+	return s.downloadBlocks(s.nodeService.DownloadNodes(addr, header.Number), hashes)
 }
 
-func (s *SyncService) getBlocks(addr string) ([]*protocol.BlockReq, uint64, error) {
-	for lo := 0; lo < s.chainService.ChainLen(); lo += BatchSizePerGetBlocksReq {
-		hi := lo + BatchSizePerGetBlocksReq
-		if hi > s.chainService.ChainLen() {
-			hi = s.chainService.ChainLen()
+// downloadBlocks requests the blocks in the download window from the idle peers concurrently,
+// and connects the downloaded blocks in order
+func (s *SyncService) downloadBlocks(addrs []string, hashes [][]byte) error {
+	download := newBlockDownload(addrs, hashes)
+	// the orphans are connected in order without downloading them again
+	for i, hash := range hashes {
+		if orphan := s.orphanPool.Take(hash); orphan != nil {
+			download.received[i] = orphan.Item
+		}
+	}
+
+	for !download.done() {
+		for _, addr := range download.addrs {
+			if indexes := download.assign(addr); indexes != nil {
+				go s.fetchBlocks(download, addr, indexes)
+			}
+		}
+		// all peers are dropped or failed the next blocks
+		if len(download.inFlight) == 0 {
+			return errors.ErrBlockDownloadFailed
+		}
+
+		download.receive(<-download.results)
+		for block := download.pop(); block != nil; block = download.pop() {
+			err := s.addBlockFunc(block)
+			if err != nil && err != errors.ErrBlockExist {
+				return err
+			}
 		}
+	}
+	return nil
+}
+
+// fetchBlocks reports the blocks replied by the peer, or the timeout if the peer is too slow
+func (s *SyncService) fetchBlocks(download *blockDownload, addr string, indexes []int) {
+	items := make([]*protocol.InvItem, len(indexes))
+	for i, index := range indexes {
+		items[i] = &protocol.InvItem{Type: protocol.InvType_INV_BLOCK, Hash: download.hashes[index]}
+	}
 
-		lastBlockHashes := s.chainService.GetChainHashes(lo, hi)
-		blockReqs, end, err := s.nodeService.GetBlocks(lastBlockHashes, addr)
-		if blockReqs != nil || err != nil {
-			return blockReqs, end, err
+	replies := make(chan *downloadResult, 1)
+	go func() {
+		result := &downloadResult{addr: addr, indexes: indexes}
+		reply, err := s.nodeService.GetData(addr, items)
+		if err != nil {
+			result.err = err
+		} else {
+			result.blocks = reply.Blocks
 		}
+		replies <- result
+	}()
+
+	timer := time.NewTimer(s.downloadTimeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-replies:
+		download.results <- result
+	case <-timer.C:
+		download.results <- &downloadResult{addr: addr, indexes: indexes, err: errors.ErrBlockDownloadTimeout}
 	}
-	return nil, 0, nil
 }
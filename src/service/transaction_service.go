@@ -10,12 +10,12 @@ import (
 
 type TransactionService struct {
 	database.IBlockDB
-	utxo map[string]uint64
+	utxo map[string]*model.Out
 }
 
 type GetTxFunc func([]byte) *model.Transaction
 
-func NewTransactionService(db database.IBlockDB, utxo map[string]uint64) *TransactionService {
+func NewTransactionService(db database.IBlockDB, utxo map[string]*model.Out) *TransactionService {
 	service := &TransactionService{
 		IBlockDB: db,
 		utxo:     utxo,
@@ -30,16 +30,26 @@ func (s *TransactionService) ValidateOnChainTxs(txs []*model.Transaction, blockh
 		return txmap[string(hash)]
 	}
 
-	var utxo map[string]uint64 = nil
+	var utxo map[string]*model.Out = nil
 	if isMainChain {
 		utxo = s.utxo
 	}
 
+	if len(txs) == 0 {
+		return errors.ErrTxCoinbaseInvalid
+	}
+
+	// the outputs spent by the previous transactions of this block
+	spent := make(map[string]bool)
+
 	var totalFee uint64 = 0
-	for _, tx := range txs {
-		if err := s.validateTx(tx, blockhash, false, utxo, f); err != nil {
+	for _, tx := range txs[1:] {
+		if err := s.validateTx(tx, blockhash, false, utxo, spent, f); err != nil {
 			return err
 		}
+		for _, in := range tx.Ins {
+			spent[in.OutPoint().Key()] = true
+		}
 		txmap[string(tx.Hash)] = tx
 		totalFee += tx.Fee
 	}
@@ -50,12 +60,87 @@ func (s *TransactionService) ValidateOnChainTxs(txs []*model.Transaction, blockh
 	return nil
 }
 
+// VerifyOnChainTxs checks the saved transactions of the block again, the outputs spent by them must be restored,
+// the utxo is not checked since it is not at the block any more
+func (s *TransactionService) VerifyOnChainTxs(txs []*model.Transaction, blockhash []byte, reward uint64) error {
+	if len(txs) == 0 {
+		return errors.ErrTxCoinbaseInvalid
+	}
+
+	var totalFee uint64 = 0
+	for _, tx := range txs[1:] {
+		fee, err := s.verifyTx(tx, blockhash)
+		if err != nil {
+			return err
+		}
+		totalFee += fee
+	}
+
+	coinbase := txs[0]
+	if _, err := s.verifyTx(coinbase, blockhash); err != nil {
+		return err
+	}
+	if coinbase.InLen != 0 || coinbase.OutLen != 1 {
+		return errors.ErrTxCoinbaseInvalid
+	}
+	if coinbase.Outs[0].Value != totalFee+reward {
+		return errors.ErrBlockRewardMismatch
+	}
+	return nil
+}
+
+// verifyTx checks the saved transaction with the spent outputs on its inputs, it returns the fee
+func (s *TransactionService) verifyTx(tx *model.Transaction, blockhash []byte) (uint64, error) {
+	if _, err := validateHash[*model.Transaction](tx.Hash, tx); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(tx.BlockHash, blockhash) {
+		return 0, errors.ErrTxBlockHashInvalid
+	}
+	if len(tx.Ins) != int(tx.InLen) {
+		return 0, errors.ErrInLenMismatch
+	}
+
+	totalOutput, err := s.validateOutputs(tx)
+	if err != nil {
+		return 0, err
+	}
+	if len(tx.Ins) == 0 {
+		return 0, nil
+	}
+
+	sighash, err := tx.SignatureHash()
+	if err != nil {
+		return 0, err
+	}
+
+	var totalInput uint64 = 0
+	for _, input := range tx.Ins {
+		if input.PrevOut == nil {
+			return 0, errors.ErrPrevTxNotFound
+		}
+		if !bytes.Equal(cryptography.AddressHash(input.Pubkey), input.PrevOut.Address) {
+			return 0, errors.ErrInPubkeyMismatch
+		}
+		valid, err := cryptography.Verify(input.Pubkey, sighash, input.Signature)
+		if !valid || err != nil {
+			return 0, errors.ErrInSigInvalid
+		}
+		totalInput += input.PrevOut.Value
+	}
+
+	if totalInput < totalOutput {
+		return 0, errors.ErrTxNotEnoughValues
+	}
+	return totalInput - totalOutput, nil
+}
+
 func (s *TransactionService) ValidateTx(tx *model.Transaction, f GetTxFunc) error {
-	return s.validateTx(tx, nil, false, s.utxo, f)
+	return s.validateTx(tx, nil, false, s.utxo, nil, f)
 }
 
 func (s *TransactionService) validateCoinbase(tx *model.Transaction, blockhash []byte, val uint64) error {
-	if err := s.validateTx(tx, blockhash, true, nil, nil); err != nil {
+	if err := s.validateTx(tx, blockhash, true, nil, nil, nil); err != nil {
 		return err
 	}
 	if tx.InLen != 0 {
@@ -65,12 +150,12 @@ func (s *TransactionService) validateCoinbase(tx *model.Transaction, blockhash [
 		return errors.ErrTxCoinbaseInvalid
 	}
 	if val != tx.Outs[0].Value {
-		return errors.ErrTxCoinbaseInvalid
+		return errors.ErrBlockRewardMismatch
 	}
 	return nil
 }
 
-func (s *TransactionService) validateTx(tx *model.Transaction, blockhash []byte, coinbase bool, utxo map[string]uint64, f GetTxFunc) error {
+func (s *TransactionService) validateTx(tx *model.Transaction, blockhash []byte, coinbase bool, utxo map[string]*model.Out, spent map[string]bool, f GetTxFunc) error {
 	hash, err := validateHash[*model.Transaction](tx.Hash, tx)
 	if err != nil {
 		return err
@@ -93,18 +178,23 @@ func (s *TransactionService) validateTx(tx *model.Transaction, blockhash []byte,
 		return errors.ErrTxExist
 	}
 
-	var totalInput uint64
-	totalInput, err = s.validateInputs(tx, coinbase, utxo, f)
+	var totalOutput uint64
+	totalOutput, err = s.validateOutputs(tx)
 	if err != nil {
 		return err
 	}
 
-	var totalOutput uint64
-	totalOutput, err = s.validateOutputs(tx)
+	var totalInput uint64
+	totalInput, err = s.validateInputs(tx, coinbase, utxo, spent, f)
 	if err != nil {
 		return err
 	}
 
+	// the outputs of coinbase are the reward and fees, checked by validateCoinbase
+	if coinbase {
+		return nil
+	}
+
 	if totalInput < totalOutput {
 		return errors.ErrTxNotEnoughValues
 	}
@@ -112,7 +202,7 @@ func (s *TransactionService) validateTx(tx *model.Transaction, blockhash []byte,
 	return nil
 }
 
-func (s *TransactionService) validateInputs(tx *model.Transaction, coinbase bool, utxo map[string]uint64, f GetTxFunc) (uint64, error) {
+func (s *TransactionService) validateInputs(tx *model.Transaction, coinbase bool, utxo map[string]*model.Out, spent map[string]bool, f GetTxFunc) (uint64, error) {
 	if len(tx.Ins) != int(tx.InLen) {
 		return 0, errors.ErrInLenMismatch
 	}
@@ -120,9 +210,27 @@ func (s *TransactionService) validateInputs(tx *model.Transaction, coinbase bool
 		return 0, errors.ErrInLenMismatch
 	}
 
+	if coinbase {
+		return 0, nil
+	}
+
+	sighash, err := tx.SignatureHash()
+	if err != nil {
+		return 0, err
+	}
+
+	// the same output can't be spent twice in one transaction
+	points := make(map[string]bool)
+
 	var total uint64 = 0
 	for _, input := range tx.Ins {
-		val, err := s.validateInput(input, tx, utxo, f)
+		key := input.OutPoint().Key()
+		if points[key] {
+			return 0, errors.ErrInDoubleSpend
+		}
+		points[key] = true
+
+		val, err := s.validateInput(input, tx, sighash, utxo, spent, f)
 		if err != nil {
 			return 0, err
 		}
@@ -131,13 +239,22 @@ func (s *TransactionService) validateInputs(tx *model.Transaction, coinbase bool
 	return total, nil
 }
 
-func (s *TransactionService) validateInput(input *model.In, tx *model.Transaction, utxo map[string]uint64, f GetTxFunc) (uint64, error) {
+func (s *TransactionService) validateInput(input *model.In, tx *model.Transaction, sighash []byte, utxo map[string]*model.Out, spent map[string]bool, f GetTxFunc) (uint64, error) {
+	key := input.OutPoint().Key()
+	if spent[key] {
+		return 0, errors.ErrInDoubleSpend
+	}
+
+	onChain := true
 	prevTx, err := s.GetTx(input.PrevHash)
 	if err != nil {
 		return 0, err
 	}
 	if prevTx == nil {
-		prevTx = f(input.PrevHash)
+		onChain = false
+		if f != nil {
+			prevTx = f(input.PrevHash)
+		}
 		if prevTx == nil {
 			return 0, errors.ErrPrevTxNotFound
 		}
@@ -145,17 +262,24 @@ func (s *TransactionService) validateInput(input *model.In, tx *model.Transactio
 	if input.Index >= uint32(len(prevTx.Outs)) {
 		return 0, errors.ErrInLenOutOfIndex
 	}
-	if prevTx.Timestamp.Compare(tx.Timestamp) > 0 {
+	if prevTx.Timestamp.Compare(tx.Timestamp) >= 0 {
 		return 0, errors.ErrInTooLate
 	}
 
+	// the outputs of the pending transactions are not in utxo yet
+	if onChain && utxo != nil {
+		if _, unspent := utxo[key]; !unspent {
+			return 0, errors.ErrInDoubleSpend
+		}
+	}
+
 	input.PrevOut = prevTx.Outs[input.Index].DeepClone()
 
-	if utxo != nil && utxo[string(input.PrevOut.Pubkey)] < input.PrevOut.Value {
-		return 0, errors.ErrAccountNotEnoughValues
+	if !bytes.Equal(cryptography.AddressHash(input.Pubkey), input.PrevOut.Address) {
+		return 0, errors.ErrInPubkeyMismatch
 	}
 
-	valid, err := cryptography.Verify(input.PrevOut.Pubkey, prevTx.Hash, input.Signature)
+	valid, err := cryptography.Verify(input.Pubkey, sighash, input.Signature)
 	if !valid || err != nil {
 		return 0, errors.ErrInSigInvalid
 	}
@@ -1,79 +1,247 @@
 package service
 
 import (
+	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
 	"Bitcoin/src/model"
-	"log"
+	"bytes"
 )
 
 const (
 	UTXO = "utxo"
 )
 
+// BlockListener is notified after the blocks are applied to or rolled back from the utxo
+type BlockListener interface {
+	ApplyBlock(block *model.Block)
+	RollbackBlock(block *model.Block)
+}
+
+// UtxoService keeps the unspent outputs of the main chain, keyed by model.OutPoint.Key(),
+// the changes are saved to the database with the best block before they are applied in memory
 type UtxoService struct {
-	utxo map[string]uint64
+	db        database.IBlockDB
+	utxo      map[string]*model.Out
+	listeners []BlockListener
+}
+
+func (s *UtxoService) AddListener(listener BlockListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Load loads the utxo from the database, it returns the best block the utxo is at, nil if the utxo is never saved
+func (s *UtxoService) Load() ([]byte, error) {
+	utxo, best, err := s.db.LoadUtxo()
+	if err != nil {
+		return nil, err
+	}
+	// the utxo is shared with the transaction service, so keep the map
+	for key, out := range utxo {
+		s.utxo[key] = out
+	}
+	return best, nil
+}
+
+// Reset clears the utxo to apply the blocks from the genesis block again
+func (s *UtxoService) Reset() error {
+	utxo := make(map[string]*model.Out, len(s.utxo))
+	for key := range s.utxo {
+		utxo[key] = nil
+	}
+	if err := s.db.SaveUtxo(utxo, nil); err != nil {
+		return err
+	}
+	s.applyUtxo(utxo)
+	return nil
+}
+
+func (s *UtxoService) GetUtxo(point model.OutPoint) *model.Out {
+	return s.utxo[point.Key()]
 }
 
-func (s *UtxoService) ApplyTx(tx *model.Transaction) {
-	utxo := make(map[string]int64)
-	s.applyTx(utxo, tx)
+// GetBalance sums the unspent outputs locked to the address hash, it returns the value and the number of outputs
+func (s *UtxoService) GetBalance(address []byte) (uint64, int) {
+	var balance uint64
+	var n int
+	for _, out := range s.utxo {
+		if bytes.Equal(out.Address, address) {
+			balance += out.Value
+			n++
+		}
+	}
+	return balance, n
+}
+
+// ListUnspent returns the unspent outputs locked to the address hash, keyed by model.OutPoint.Key()
+func (s *UtxoService) ListUnspent(address []byte) map[string]*model.Out {
+	outs := make(map[string]*model.Out)
+	for key, out := range s.utxo {
+		if bytes.Equal(out.Address, address) {
+			outs[key] = out
+		}
+	}
+	return outs
+}
+
+func (s *UtxoService) ApplyTx(tx *model.Transaction) error {
+	utxo := make(map[string]*model.Out)
+	if err := s.applyTx(utxo, tx); err != nil {
+		return err
+	}
+	s.applyUtxo(utxo)
+	return nil
+}
+
+// ConnectBlock saves the block extending the main chain together with the utxo changed by it
+func (s *UtxoService) ConnectBlock(block *model.Block) error {
+	utxo := make(map[string]*model.Out)
+	if _, err := s.applyBlocks(utxo, block); err != nil {
+		return err
+	}
+	if err := s.db.ConnectBlock(block, utxo); err != nil {
+		return err
+	}
 	s.applyUtxo(utxo)
+
+	for _, listener := range s.listeners {
+		listener.ApplyBlock(block)
+	}
+	return nil
 }
 
-// TODO: test case: apply all or none
-func (s *UtxoService) ApplyBlock(block *model.Block) {
-	utxo := make(map[string]int64)
-	s.applyBlocks(utxo, block)
+// ApplyBlock applies the saved block extending the main chain
+func (s *UtxoService) ApplyBlock(block *model.Block) error {
+	utxo := make(map[string]*model.Out)
+	if _, err := s.applyBlocks(utxo, block); err != nil {
+		return err
+	}
+	if err := s.db.SaveUtxo(utxo, block.Hash); err != nil {
+		return err
+	}
 	s.applyUtxo(utxo)
+
+	for _, listener := range s.listeners {
+		listener.ApplyBlock(block)
+	}
+	return nil
 }
 
-// TODO: test case: apply all or none
-func (s *UtxoService) SwitchBlocks(rollbackBlocks, applyBlocks []*model.Block) {
-	utxo := make(map[string]int64)
-	s.rollbackBlocks(utxo, rollbackBlocks)
-	s.applyBlocks(utxo, applyBlocks...)
+// ValidateBlocks checks the inputs of the apply blocks against the utxo at the fork point, that is the utxo
+// with the rollback blocks rolled back, and the outputs of the apply blocks before each of them.
+// The blocks on a side chain are only checked against it when they are switched to,
+// it returns the first block failing with the error, the utxo is not changed
+func (s *UtxoService) ValidateBlocks(rollbackBlocks, applyBlocks []*model.Block) (*model.Block, error) {
+	utxo := make(map[string]*model.Out)
+	if block, err := s.rollbackBlocks(utxo, rollbackBlocks); err != nil {
+		return block, err
+	}
+	return s.applyBlocks(utxo, applyBlocks...)
+}
+
+// SwitchBlocks rolls back the blocks ordered from the tip, then applies the blocks ordered from the fork point,
+// the utxo is at the last applied block after that, nothing is changed if any block fails
+func (s *UtxoService) SwitchBlocks(rollbackBlocks, applyBlocks []*model.Block) error {
+	if len(rollbackBlocks) == 0 && len(applyBlocks) == 0 {
+		return nil
+	}
+
+	utxo := make(map[string]*model.Out)
+	if _, err := s.rollbackBlocks(utxo, rollbackBlocks); err != nil {
+		return err
+	}
+	if _, err := s.applyBlocks(utxo, applyBlocks...); err != nil {
+		return err
+	}
+
+	var best []byte
+	if len(applyBlocks) > 0 {
+		best = applyBlocks[len(applyBlocks)-1].Hash
+	} else {
+		best = rollbackBlocks[len(rollbackBlocks)-1].Prevhash
+	}
+	if err := s.db.SaveUtxo(utxo, best); err != nil {
+		return err
+	}
 	s.applyUtxo(utxo)
+
+	for _, listener := range s.listeners {
+		for _, block := range rollbackBlocks {
+			listener.RollbackBlock(block)
+		}
+		for _, block := range applyBlocks {
+			listener.ApplyBlock(block)
+		}
+	}
+	return nil
 }
 
-func (s *UtxoService) applyTx(utxo map[string]int64, tx *model.Transaction) {
+// the changes are collected in utxo, a nil value means the output is spent
+func (s *UtxoService) applyTx(utxo map[string]*model.Out, tx *model.Transaction) error {
 	for _, in := range tx.Ins {
-		utxo[string(in.PrevOut.Pubkey)] -= int64(in.PrevOut.Value)
+		key := in.OutPoint().Key()
+		if !s.unspent(utxo, key) {
+			return errors.ErrInDoubleSpend
+		}
+		utxo[key] = nil
 	}
 
-	for _, out := range tx.Outs {
-		utxo[string(out.Pubkey)] += int64(out.Value)
+	for i, out := range tx.Outs {
+		point := model.OutPoint{Hash: tx.Hash, Index: uint32(i)}
+		utxo[point.Key()] = out
 	}
+	return nil
 }
 
-func (s *UtxoService) applyBlocks(utxo map[string]int64, blocks ...*model.Block) {
+// applyBlocks returns the block spending an output not in the utxo
+func (s *UtxoService) applyBlocks(utxo map[string]*model.Out, blocks ...*model.Block) (*model.Block, error) {
 	for _, block := range blocks {
 		for _, tx := range block.GetTxs() {
-			s.applyTx(utxo, tx)
+			if err := s.applyTx(utxo, tx); err != nil {
+				return block, err
+			}
 		}
 	}
+	return nil, nil
 }
 
-func (s *UtxoService) rollbackBlocks(utxo map[string]int64, blocks []*model.Block) {
+// the blocks are ordered from the tip, so the transactions are rolled back in reverse order,
+// it returns the block whose spent outputs are unknown
+func (s *UtxoService) rollbackBlocks(utxo map[string]*model.Out, blocks []*model.Block) (*model.Block, error) {
 	for _, block := range blocks {
-		for _, tx := range block.GetTxs() {
-			for _, in := range tx.Ins {
-				utxo[string(in.PrevOut.Pubkey)] += int64(in.PrevOut.Value)
+		txs := block.GetTxs()
+		for i := len(txs) - 1; i >= 0; i-- {
+			tx := txs[i]
+			for j := range tx.Outs {
+				point := model.OutPoint{Hash: tx.Hash, Index: uint32(j)}
+				utxo[point.Key()] = nil
 			}
 
-			for _, out := range tx.Outs {
-				utxo[string(out.Pubkey)] -= int64(out.Value)
+			for _, in := range tx.Ins {
+				if in.PrevOut == nil {
+					return block, errors.ErrBlockUndoNotFound
+				}
+				utxo[in.OutPoint().Key()] = in.PrevOut
 			}
 		}
 	}
+	return nil, nil
 }
 
-func (s *UtxoService) applyUtxo(utxo map[string]int64) {
-	for addr, val := range utxo {
-		if int64(s.utxo[addr])+val < 0 {
-			log.Fatalf("not enough values for %v, only %d, but need substract %d", addr, s.utxo[addr], val)
-		}
-		s.utxo[addr] += uint64(val)
-		if s.utxo[addr] == 0 {
-			delete(s.utxo, addr)
+func (s *UtxoService) unspent(utxo map[string]*model.Out, key string) bool {
+	out, changed := utxo[key]
+	if changed {
+		return out != nil
+	}
+	_, has := s.utxo[key]
+	return has
+}
+
+func (s *UtxoService) applyUtxo(utxo map[string]*model.Out) {
+	for key, out := range utxo {
+		if out == nil {
+			delete(s.utxo, key)
+		} else {
+			s.utxo[key] = out
 		}
 	}
 }
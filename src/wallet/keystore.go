@@ -0,0 +1,205 @@
+package wallet
+
+import (
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KeystoreName    = "keystore"
+	KeystoreVersion = 1
+	scryptN         = 1 << 15
+	scryptR         = 8
+	scryptP         = 1
+	keyLen          = 32
+	saltLen         = 16
+)
+
+type Key struct {
+	Privkey []byte `json:"privkey"`
+	Pubkey  []byte `json:"pubkey"`
+}
+
+// Address returns the address hash which the outputs to the key are locked to
+func (key *Key) Address() []byte {
+	return cryptography.AddressHash(key.Pubkey)
+}
+
+// KeyStore keeps the keys in a file encrypted by AES-GCM with the key derived from the password by scrypt
+type KeyStore struct {
+	path     string
+	password []byte
+	keys     []*Key
+	lock     sync.Mutex
+}
+
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// OpenKeyStore opens the keystore in the dir, an empty one is created if the file doesn't exist
+func OpenKeyStore(dir, password string) (*KeyStore, error) {
+	ks := &KeyStore{
+		path:     fmt.Sprintf("%s/%s", dir, KeystoreName),
+		password: []byte(password),
+		keys:     make([]*Key, 0),
+	}
+
+	data, err := os.ReadFile(ks.path)
+	if goerrors.Is(err, fs.ErrNotExist) {
+		return ks, ks.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != KeystoreVersion {
+		return nil, errors.ErrKeystoreVersion
+	}
+
+	gcm, err := newGCM(ks.password, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.ErrKeystorePassword
+	}
+
+	if err := json.Unmarshal(plaintext, &ks.keys); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// NewKey generates a key and saves it in the keystore
+func (ks *KeyStore) NewKey() (*Key, error) {
+	privkey, pubkey, err := cryptography.GenerateKeys()
+	if err != nil {
+		return nil, err
+	}
+	return ks.addKey(&Key{Privkey: privkey, Pubkey: pubkey})
+}
+
+// ImportKey saves the private key in the keystore
+func (ks *KeyStore) ImportKey(privkey []byte) (*Key, error) {
+	pubkey, err := cryptography.PublicKeyOf(privkey)
+	if err != nil {
+		return nil, err
+	}
+	return ks.addKey(&Key{Privkey: privkey, Pubkey: pubkey})
+}
+
+func (ks *KeyStore) Keys() []*Key {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	keys := make([]*Key, len(ks.keys))
+	copy(keys, ks.keys)
+	return keys
+}
+
+// GetKey returns the key of the address hash, nil if the address is not of the keystore
+func (ks *KeyStore) GetKey(address []byte) *Key {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	for _, key := range ks.keys {
+		if bytes.Equal(key.Address(), address) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (ks *KeyStore) addKey(key *Key) (*Key, error) {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	for _, k := range ks.keys {
+		if bytes.Equal(k.Pubkey, key.Pubkey) {
+			return k, nil
+		}
+	}
+
+	ks.keys = append(ks.keys, key)
+	if err := ks.save(); err != nil {
+		ks.keys = ks.keys[:len(ks.keys)-1]
+		return nil, err
+	}
+	return key, nil
+}
+
+// save writes a temporary file then renames it, so the keystore is never half written
+func (ks *KeyStore) save() error {
+	plaintext, err := json.Marshal(ks.keys)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(ks.password, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	file := keystoreFile{
+		Version:    KeystoreVersion,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	tmp := ks.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+func newGCM(password, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(password, salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,270 @@
+package wallet
+
+import (
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Coin is an unspent output locked to a key of the wallet
+type Coin struct {
+	Point model.OutPoint
+	Out   *model.Out
+	Key   *Key
+}
+
+// Wallet tracks the coins of the keys in the keystore, it listens to the blocks applied to the utxo
+type Wallet struct {
+	database.IBlockDB
+	keystore *KeyStore
+	coins    map[string]*Coin
+	// the coins spent by the transactions created but not on chain yet
+	pending map[string]bool
+	lock    sync.Mutex
+}
+
+func NewWallet(blockdb database.IBlockDB, keystore *KeyStore) *Wallet {
+	return &Wallet{
+		IBlockDB: blockdb,
+		keystore: keystore,
+		coins:    make(map[string]*Coin),
+		pending:  make(map[string]bool),
+	}
+}
+
+func (w *Wallet) KeyStore() *KeyStore {
+	return w.keystore
+}
+
+// UnspentLister lists the unspent outputs locked to the address hash keyed by model.OutPoint.Key(), e.g. the utxo of the main chain
+type UnspentLister interface {
+	ListUnspent(address []byte) map[string]*model.Out
+}
+
+// Rescan rebuilds the coins from the unspent outputs of the keys, the spent blocks are not read so it works on the pruned node
+func (w *Wallet) Rescan(utxo UnspentLister) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	coins := make(map[string]*Coin)
+	for _, key := range w.keystore.Keys() {
+		for k, out := range utxo.ListUnspent(key.Address()) {
+			point, err := model.ParseOutPoint(k)
+			if err != nil {
+				return err
+			}
+			coins[k] = &Coin{Point: point, Out: out, Key: key}
+		}
+	}
+
+	w.coins = coins
+	w.pending = make(map[string]bool)
+	return nil
+}
+
+func (w *Wallet) ApplyBlock(block *model.Block) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.applyBlock(block)
+}
+
+// RollbackBlock removes the coins created by the block and restores the coins spent by it
+func (w *Wallet) RollbackBlock(block *model.Block) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	txs := block.GetTxs()
+	for i := len(txs) - 1; i >= 0; i-- {
+		tx := txs[i]
+		for j := range tx.Outs {
+			point := model.OutPoint{Hash: tx.Hash, Index: uint32(j)}
+			delete(w.coins, point.Key())
+			delete(w.pending, point.Key())
+		}
+
+		for _, in := range tx.Ins {
+			out := in.PrevOut
+			if out == nil {
+				out = w.getOut(in.OutPoint())
+			}
+			if out != nil {
+				w.addCoin(in.OutPoint(), out)
+			}
+		}
+	}
+}
+
+// Balance sums the values of all the coins, including the ones spent by the pending transactions
+func (w *Wallet) Balance() uint64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var balance uint64
+	for _, coin := range w.coins {
+		balance += coin.Out.Value
+	}
+	return balance
+}
+
+// Coins returns the coins not spent by the pending transactions, from the largest to the smallest
+func (w *Wallet) Coins() []*Coin {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.spendable()
+}
+
+// CreateTx builds a transaction paying the value to the address hash and the fee to the miner,
+// the change goes back to the first key of the keystore, the coins spent are locked until
+// the transaction is on chain or released by Release
+func (w *Wallet) CreateTx(to []byte, value, fee uint64) (*model.Transaction, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	keys := w.keystore.Keys()
+	if len(keys) == 0 {
+		return nil, errors.ErrWalletNotEnoughValues
+	}
+
+	tx, coins, err := BuildTx(w.spendable(), to, keys[0].Address(), value, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, coin := range coins {
+		w.pending[coin.Point.Key()] = true
+	}
+	return tx, nil
+}
+
+// BuildTx selects the coins for the value and the fee and signs the transaction spending them by their keys,
+// the change goes to the change address hash, it returns the coins spent
+func BuildTx(coins []*Coin, to, change []byte, value, fee uint64) (*model.Transaction, []*Coin, error) {
+	sorted := append([]*Coin(nil), coins...)
+	sortCoins(sorted)
+
+	if value+fee < value {
+		return nil, nil, errors.ErrWalletValueOverflow
+	}
+	selected, total, err := selectCoins(sorted, value+fee)
+	if err != nil {
+		return nil, nil, err
+	}
+	if total < value+fee {
+		return nil, nil, errors.ErrWalletNotEnoughValues
+	}
+
+	tx := &model.Transaction{Timestamp: time.Now()}
+	for _, coin := range selected {
+		tx.Ins = append(tx.Ins, &model.In{PrevHash: coin.Point.Hash, Index: coin.Point.Index, PrevOut: coin.Out, Pubkey: coin.Key.Pubkey})
+	}
+
+	tx.Outs = append(tx.Outs, &model.Out{Address: to, Value: value})
+	if left := total - value - fee; left > 0 {
+		tx.Outs = append(tx.Outs, &model.Out{Address: change, Value: left})
+	}
+	tx.InLen = uint32(len(tx.Ins))
+	tx.OutLen = uint32(len(tx.Outs))
+
+	for i, in := range tx.Ins {
+		sig, err := cryptography.SignContent(selected[i].Key.Privkey, tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		in.Signature = sig
+	}
+
+	tx.Hash, err = tx.ComputeHash()
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, selected, nil
+}
+
+// Release unlocks the coins spent by the transaction which will never be on chain
+func (w *Wallet) Release(tx *model.Transaction) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, in := range tx.Ins {
+		delete(w.pending, in.OutPoint().Key())
+	}
+}
+
+func (w *Wallet) applyBlock(block *model.Block) {
+	for _, tx := range block.GetTxs() {
+		for _, in := range tx.Ins {
+			delete(w.coins, in.OutPoint().Key())
+			delete(w.pending, in.OutPoint().Key())
+		}
+
+		for i, out := range tx.Outs {
+			w.addCoin(model.OutPoint{Hash: tx.Hash, Index: uint32(i)}, out)
+		}
+	}
+}
+
+func (w *Wallet) addCoin(point model.OutPoint, out *model.Out) {
+	key := w.keystore.GetKey(out.Address)
+	if key == nil {
+		return
+	}
+	w.coins[point.Key()] = &Coin{Point: point, Out: out, Key: key}
+}
+
+// getOut finds the spent output from the database, nil if not found
+func (w *Wallet) getOut(point model.OutPoint) *model.Out {
+	tx, err := w.GetTx(point.Hash)
+	if err != nil || tx == nil || int(point.Index) >= len(tx.Outs) {
+		return nil
+	}
+	return tx.Outs[point.Index]
+}
+
+func (w *Wallet) spendable() []*Coin {
+	coins := make([]*Coin, 0, len(w.coins))
+	for key, coin := range w.coins {
+		if !w.pending[key] {
+			coins = append(coins, coin)
+		}
+	}
+
+	sortCoins(coins)
+	return coins
+}
+
+// sortCoins sorts the coins from the largest to the smallest
+func sortCoins(coins []*Coin) {
+	sort.Slice(coins, func(i, j int) bool {
+		return coins[i].Out.Value > coins[j].Out.Value
+	})
+}
+
+// selectCoins picks a coin of the exact target value if any to avoid the change,
+// otherwise the largest coins until the target is reached, the coins are sorted from the largest
+func selectCoins(coins []*Coin, target uint64) ([]*Coin, uint64, error) {
+	for _, coin := range coins {
+		if coin.Out.Value == target {
+			return []*Coin{coin}, target, nil
+		}
+	}
+
+	var total uint64
+	selected := make([]*Coin, 0)
+	for _, coin := range coins {
+		if total >= target {
+			break
+		}
+		if total+coin.Out.Value < total {
+			return nil, 0, errors.ErrWalletValueOverflow
+		}
+		selected = append(selected, coin)
+		total += coin.Out.Value
+	}
+	return selected, total, nil
+}
@@ -66,7 +66,7 @@ func Test_Merkle_Marshal_Duplicate_Failed(t *testing.T) {
 }
 
 func Test_Merkle_Marshal_Batch_Succeed(t *testing.T) {
-	for n := 2; n < 50; n++ {
+	for n := 1; n < 50; n++ {
 		vals := make([]string, n)
 		for i := 0; i < n; i++ {
 			vals[i] = fmt.Sprintf("Hello%d", i)
@@ -92,11 +92,11 @@ func Test_Merkle_Marshal_Batch_Succeed(t *testing.T) {
 
 func Test_Merkle_Unmarshal_Failed(t *testing.T) {
 	files := make(map[string]string)
-	files["collection_unmarshal_failed_node_size.json"] = collection.ErrMtNodeSize
-	files["collection_unmarshal_failed_few_rows.json"] = collection.ErrMtFewRows
-	files["collection_unmarshal_failed_few_parent_hash.json"] = collection.ErrMtFewParentHash
-	files["collection_unmarshal_failed_no_parent_hash.json"] = collection.ErrMtNoParentHash
-	files["collection_unmarshal_failed_duplicate_hash.json"] = collection.ErrMtDuplicateHash
+	files["merkle_unmarshal_failed_node_size.json"] = collection.ErrMtNodeSize
+	files["merkle_unmarshal_failed_few_rows.json"] = collection.ErrMtFewRows
+	files["merkle_unmarshal_failed_few_parent_hash.json"] = collection.ErrMtFewParentHash
+	files["merkle_unmarshal_failed_no_parent_hash.json"] = collection.ErrMtNoParentHash
+	files["merkle_unmarshal_failed_duplicate_hash.json"] = collection.ErrMtDuplicateHash
 
 	for file, expect := range files {
 		data, err := os.ReadFile(file)
@@ -182,7 +182,7 @@ func Test_Merkle_Validate_Fail(t *testing.T) {
 }
 
 func Test_Merkle_Validate_Success_From_Json(t *testing.T) {
-	data, err := os.ReadFile("collection_success.json")
+	data, err := os.ReadFile("merkle_success.json")
 	if err != nil {
 		t.Fatalf("read json file error: %s", err)
 	}
@@ -203,7 +203,7 @@ func Test_Merkle_Validate_Success_From_Json(t *testing.T) {
 }
 
 func Test_Merkle_Validate_Fail_From_Json(t *testing.T) {
-	data, err := os.ReadFile("collection_validate_failed.json")
+	data, err := os.ReadFile("merkle_validate_failed.json")
 	if err != nil {
 		t.Fatalf("read json file error: %s", err)
 	}
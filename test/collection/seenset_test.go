@@ -0,0 +1,57 @@
+package collection
+
+import (
+	"Bitcoin/src/collection"
+	"fmt"
+	"testing"
+)
+
+func Test_SeenSet_Add(t *testing.T) {
+	set := collection.NewSeenSet(10)
+
+	if !set.Add([]byte("hello")) {
+		t.Fatal("the first add should succeed")
+	}
+	if set.Add([]byte("hello")) {
+		t.Fatal("the seen hash should not be added again")
+	}
+	if !set.Has([]byte("hello")) || set.Len() != 1 {
+		t.Fatal("the added hash should be seen")
+	}
+}
+
+func Test_SeenSet_Evict_Oldest(t *testing.T) {
+	size := 10
+	set := collection.NewSeenSet(size)
+	for i := 0; i < size+3; i++ {
+		set.Add([]byte(fmt.Sprintf("hello%d", i)))
+	}
+
+	if set.Len() != size {
+		t.Fatalf("expect len: %d, actual: %d", size, set.Len())
+	}
+	for i := 0; i < size+3; i++ {
+		expect := i >= 3
+		if set.Has([]byte(fmt.Sprintf("hello%d", i))) != expect {
+			t.Fatalf("hello%d expect seen: %v", i, expect)
+		}
+	}
+}
+
+func Test_SeenSet_Remove(t *testing.T) {
+	set := collection.NewSeenSet(3)
+	set.Add([]byte("hello0"))
+	set.Remove([]byte("hello0"))
+
+	if set.Has([]byte("hello0")) {
+		t.Fatal("the removed hash should not be seen")
+	}
+
+	// the re-added hash owns a new slot, it's not evicted with its old slot
+	set.Add([]byte("hello0"))
+	set.Add([]byte("hello1"))
+	set.Add([]byte("hello2"))
+	if !set.Has([]byte("hello0")) || set.Len() != 3 {
+		t.Fatal("the re-added hash should be seen")
+	}
+}
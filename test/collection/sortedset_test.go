@@ -2,6 +2,8 @@ package collection
 
 import (
 	"Bitcoin/src/collection"
+	"math/rand"
+	"sort"
 	"testing"
 )
 
@@ -98,3 +100,151 @@ type Entity struct {
 	id     int
 	number int
 }
+
+func Test_Remove_Random(t *testing.T) {
+	sortedset := collection.NewSortedSet[int, int, *Entity]()
+	n := 200
+	for i := 0; i < n; i++ {
+		sortedset.Insert(i, i%7, &Entity{id: i, number: i % 7})
+	}
+
+	removed := make(map[int]bool)
+	for _, i := range rand.Perm(n)[:n/2] {
+		sortedset.Remove(i, i%7)
+		removed[i] = true
+
+		if sortedset.Get(i, i%7) != nil {
+			t.Fatalf("entity %d should be removed", i)
+		}
+	}
+
+	if sortedset.Len() != n-len(removed) {
+		t.Fatalf("expect len: %d, actual: %d", n-len(removed), sortedset.Len())
+	}
+
+	entities := sortedset.TopMax(0, sortedset.Len())
+	if len(entities) != sortedset.Len() {
+		t.Fatalf("expect entities len: %d, actual: %d", sortedset.Len(), len(entities))
+	}
+	for i, entity := range entities {
+		if removed[entity.id] {
+			t.Fatalf("removed entity %d still in the set", entity.id)
+		}
+		if i > 0 && entities[i-1].number > entity.number {
+			t.Fatalf("The %d entity is smaller than the %d entity", i, i+1)
+		}
+		if sortedset.Get(entity.id, entity.number) != entity {
+			t.Fatalf("can not get entity %d", entity.id)
+		}
+	}
+
+	for sortedset.Len() > 0 {
+		max := sortedset.Max()
+		if sortedset.PopMax() != max {
+			t.Fatal("pop max entity is not the max entity")
+		}
+	}
+	if sortedset.Max() != nil {
+		t.Fatal("get max entity for empty set")
+	}
+}
+
+func Test_PopMax(t *testing.T) {
+	sortedset := collection.NewSortedSet[int, int, *Entity]()
+	for _, i := range rand.Perm(10) {
+		sortedset.Insert(i, i, &Entity{id: i, number: i})
+	}
+
+	for i := 9; i >= 0; i-- {
+		max := sortedset.PopMax()
+		if max == nil || max.number != i {
+			t.Fatalf("expect pop max: %d, actual: %v", i, max)
+		}
+		if sortedset.Len() != i {
+			t.Fatalf("expect len: %d, actual: %d", i, sortedset.Len())
+		}
+	}
+	if sortedset.PopMax() != nil {
+		t.Fatal("pop max entity for empty set")
+	}
+}
+
+func Test_TopMax_Range(t *testing.T) {
+	sortedset := collection.NewSortedSet[int, int, *Entity]()
+	for i := 0; i < 10; i++ {
+		sortedset.Insert(i, i, &Entity{id: i, number: i})
+	}
+
+	// the partial range in the middle
+	entities := sortedset.TopMax(3, 7)
+	if len(entities) != 4 || entities[0].number != 3 || entities[3].number != 6 {
+		t.Fatalf("expect entities 3 to 6, actual: %d entities", len(entities))
+	}
+
+	// the end is clamped to the length
+	entities = sortedset.TopMax(5, 100)
+	if len(entities) != 5 || entities[0].number != 5 || entities[4].number != 9 {
+		t.Fatalf("expect entities 5 to 9, actual: %d entities", len(entities))
+	}
+
+	for _, r := range [][2]int{{8, 3}, {4, 4}, {10, 20}} {
+		if entities := sortedset.TopMax(r[0], r[1]); len(entities) != 0 {
+			t.Fatalf("expect no entity in [%d, %d), actual: %d", r[0], r[1], len(entities))
+		}
+	}
+}
+
+func Test_Get_Remove_Same_Score(t *testing.T) {
+	sortedset := collection.NewSortedSet[int, int, *Entity]()
+	for i := 1; i <= 5; i++ {
+		sortedset.Insert(i, 3, &Entity{id: i, number: 3})
+	}
+
+	if entity := sortedset.Get(3, 3); entity == nil || entity.id != 3 {
+		t.Fatalf("expect entity %d, actual: %v", 3, entity)
+	}
+	if sortedset.Get(9, 3) != nil || sortedset.Get(3, 4) != nil {
+		t.Fatal("get entity with the unknown key or the other score")
+	}
+
+	// the entity is removed only with its score
+	sortedset.Remove(3, 4)
+	if sortedset.Len() != 5 {
+		t.Fatalf("expect len: %d, actual: %d", 5, sortedset.Len())
+	}
+	sortedset.Remove(3, 3)
+	if sortedset.Get(3, 3) != nil || sortedset.Len() != 4 {
+		t.Fatalf("entity %d should be removed", 3)
+	}
+
+	// the entities of the same score keep the order they are inserted
+	entities := sortedset.TopMax(0, sortedset.Len())
+	for i, id := range []int{1, 2, 4, 5} {
+		if entities[i].id != id || sortedset.Get(id, 3) != entities[i] {
+			t.Fatalf("expect entity %d at %d, actual: %d", id, i, entities[i].id)
+		}
+	}
+}
+
+func Test_Insert_Spans(t *testing.T) {
+	sortedset := collection.NewSortedSet[int, int, *Entity]()
+	n := 1000
+	entities := make([]*Entity, n)
+	for i := 0; i < n; i++ {
+		// enough entities for the new levels of the header
+		entities[i] = &Entity{id: i, number: rand.Intn(n)}
+		sortedset.Insert(i, entities[i].number, entities[i])
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].number < entities[j].number
+	})
+
+	// the rank of each entity is found by the spans
+	for i, entity := range entities {
+		top := sortedset.TopMax(i, i+1)
+		if len(top) != 1 || top[0] != entity {
+			t.Fatalf("expect entity %d at rank %d", entity.id, i)
+		}
+	}
+}
@@ -37,22 +37,17 @@ func NewTransaction(blockHash []byte) *model.Transaction {
 		log.Fatalf("compute hash error: %s", err)
 	}
 
-	sig, err := cryptography.Sign(privkey, prevHash)
-	if err != nil {
-		log.Fatalf("sign prev hash error: %s", err)
-	}
-
 	ins := []*model.In{
 		{
-			PrevHash:  prevHash,
-			Index:     0,
-			Signature: sig,
+			PrevHash: prevHash,
+			Index:    0,
+			Pubkey:   pubkey,
 		},
 	}
 
 	outs := []*model.Out{{
-		Pubkey: pubkey,
-		Value:  1,
+		Address: cryptography.AddressHash(pubkey),
+		Value:   1,
 	}}
 
 	if blockHash == nil {
@@ -71,6 +66,12 @@ func NewTransaction(blockHash []byte) *model.Transaction {
 		BlockHash: blockHash,
 	}
 
+	sig, err := cryptography.SignContent(privkey, tx)
+	if err != nil {
+		log.Fatalf("sign tx error: %s", err)
+	}
+	tx.Ins[0].Signature = sig
+
 	hash, err := tx.ComputeHash()
 	if err != nil {
 		log.Fatalf("compute tx hash error: %s", err)
@@ -101,12 +102,12 @@ func NewBlock(number uint64, difficultyLevel uint64, prevhash []byte) *model.Blo
 	rootHash := tree.Table[len(tree.Table)-1][0].Hash
 
 	block := &model.Block{
-		Prevhash:   prevhash,
-		Number:     number,
-		RootHash:   rootHash,
-		Difficulty: infra.ComputeDifficulty(infra.MakeDifficulty(difficultyLevel)),
-		Time:       time.Now(),
-		Body:       tree,
+		Prevhash: prevhash,
+		Number:   number,
+		RootHash: rootHash,
+		Bits:     infra.MakeBits(difficultyLevel),
+		Time:     time.Now(),
+		Body:     tree,
 	}
 
 	hash, err := block.FindHash(context.TODO())
@@ -2,6 +2,10 @@ package config
 
 import (
 	"Bitcoin/src/config"
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/database"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -16,6 +20,22 @@ func Test_Read(t *testing.T) {
 		t.Fatalf("unexpect config, expect: %v, actual: %v", expect, cfg.DataDir)
 	}
 
+	if cfg.Genesis != config.DefaultGenesis {
+		t.Fatalf("default Genesis should be %v, actual: %v", config.DefaultGenesis, cfg.Genesis)
+	}
+
+	if cfg.StorageEngine != database.DefaultEngine {
+		t.Fatalf("default StorageEngine should be %v, actual: %v", database.DefaultEngine, cfg.StorageEngine)
+	}
+
+	if cfg.Network != config.DefaultNetwork {
+		t.Fatalf("default Network should be %v, actual: %v", config.DefaultNetwork, cfg.Network)
+	}
+
+	if len(cfg.MinerAddress) != cryptography.AddressHashLen {
+		t.Fatalf("MinerAddress should be the address hash, actual: %x", cfg.MinerAddress)
+	}
+
 	if cfg.BlocksPerDifficulty != config.DefaultBlocksPerDifficulty {
 		t.Fatalf("default AjustBlockNum should be %v, actual: %v", config.DefaultBlocksPerDifficulty, cfg.BlocksPerDifficulty)
 	}
@@ -23,8 +43,50 @@ func Test_Read(t *testing.T) {
 	if cfg.BlockInterval != config.DefaultBlockInterval {
 		t.Fatalf("default BlockDuration should be %v, actual: %v", config.DefaultBlockInterval, cfg.BlockInterval)
 	}
+}
+
+func Test_Read_Prune_Depth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "data_dir: Bitcoin\nendpoint: localhost:50051\nminer_address: 1Lgqvb3HpU79ZR5sSd6jWRZbpKuST8Cqjb\nprune_size: 500\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config error: %v", err)
+	}
+
+	cfg, err := config.Read(path)
+	if err != nil {
+		t.Fatalf("read config error: %v", err)
+	}
+	// the blocks within the minimum depth are kept even if only the size is set
+	if cfg.PruneSize != 500 || cfg.PruneDepth != config.MinPruneDepth {
+		t.Fatalf("expect prune size: %d and depth: %d, actual: %d and %d", 500, config.MinPruneDepth, cfg.PruneSize, cfg.PruneDepth)
+	}
+}
+
+func Test_Read_Memory_Storage_Engine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "storage_engine: memory\nendpoint: localhost:50051\nminer_address: 1Lgqvb3HpU79ZR5sSd6jWRZbpKuST8Cqjb\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config error: %v", err)
+	}
+
+	// the memory engine does not need the data dir
+	cfg, err := config.Read(path)
+	if err != nil {
+		t.Fatalf("read config error: %v", err)
+	}
+	if cfg.StorageEngine != database.MemoryEngine {
+		t.Fatalf("expect storage engine: %s, actual: %s", database.MemoryEngine, cfg.StorageEngine)
+	}
+}
+
+func Test_Read_Unknown_Compression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "data_dir: Bitcoin\nendpoint: localhost:50051\nminer_address: 1Lgqvb3HpU79ZR5sSd6jWRZbpKuST8Cqjb\ncompression: zip\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config error: %v", err)
+	}
 
-	if cfg.InitDifficultyLevel != config.DefaultInitDifficultyLevel {
-		t.Fatalf("default InitDifficulty should be %v, actual: %v", config.DefaultInitDifficultyLevel, cfg.InitDifficultyLevel)
+	if _, err := config.Read(path); err == nil {
+		t.Fatal("read config with unknown compression should fail")
 	}
 }
@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
+	"Bitcoin/test"
+	"bytes"
+	"testing"
+)
+
+func Test_Address_Golden(t *testing.T) {
+	// the well known bitcoin address of the zero hash
+	address := &cryptography.Address{Prefix: 0x00, Hash: make([]byte, cryptography.AddressHashLen)}
+
+	expect := "1111111111111111111114oLvT2"
+	if address.String() != expect {
+		t.Fatalf("expect address: %s, actual: %s", expect, address.String())
+	}
+
+	parsed, err := cryptography.ParseAddress(expect)
+	if err != nil {
+		t.Fatalf("parse address error: %v", err)
+	}
+	if parsed.Prefix != address.Prefix || !bytes.Equal(parsed.Hash, address.Hash) {
+		t.Fatalf("the parsed address mismatch with the encoded one")
+	}
+}
+
+func Test_Address_Round_Trip(t *testing.T) {
+	_, pubkey := test.NewKeys()
+
+	for _, network := range []string{cryptography.MainNet, cryptography.TestNet} {
+		prefix, err := cryptography.NetworkPrefix(network)
+		if err != nil {
+			t.Fatalf("get prefix of network %s error: %v", network, err)
+		}
+
+		address := cryptography.NewAddress(prefix, pubkey)
+		parsed, err := cryptography.ParseNetworkAddress(address.String(), network)
+		if err != nil {
+			t.Fatalf("parse address %s error: %v", address, err)
+		}
+		if !bytes.Equal(parsed.Hash, cryptography.AddressHash(pubkey)) {
+			t.Fatalf("the parsed hash mismatch with the pubkey hash")
+		}
+	}
+}
+
+func Test_ParseAddress_Checksum(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	s := []byte(cryptography.NewAddress(0x00, pubkey).String())
+
+	// replace the last letter with another one of the alphabet
+	if s[len(s)-1] == '2' {
+		s[len(s)-1] = '3'
+	} else {
+		s[len(s)-1] = '2'
+	}
+
+	if _, err := cryptography.ParseAddress(string(s)); err != errors.ErrAddressChecksum {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrAddressChecksum, err)
+	}
+}
+
+func Test_ParseAddress_Invalid(t *testing.T) {
+	for _, s := range []string{"", "0OIl", "1111111111"} {
+		if _, err := cryptography.ParseAddress(s); err != errors.ErrAddressInvalid {
+			t.Fatalf("expect error of %q: %v, actual: %v", s, errors.ErrAddressInvalid, err)
+		}
+	}
+}
+
+func Test_ParseNetworkAddress_Mismatch(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	address := cryptography.NewAddress(0x6f, pubkey)
+
+	if _, err := cryptography.ParseNetworkAddress(address.String(), cryptography.MainNet); err != errors.ErrAddressNetworkMismatch {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrAddressNetworkMismatch, err)
+	}
+}
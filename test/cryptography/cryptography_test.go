@@ -41,22 +41,22 @@ func Test_Block_Hash_Field_Order_Doesnot_Matter(t *testing.T) {
 		t.Fatalf("hash string error: %v", err)
 	}
 	timestamp := time.Now()
-	difficulty := infra.ComputeDifficulty(infra.MakeDifficulty(10))
+	bits := infra.MakeBits(10)
 
 	block1 := &model.Block{
-		Number:     1,
-		Prevhash:   hash,
-		RootHash:   hash,
-		Difficulty: difficulty,
-		Time:       timestamp,
+		Number:   1,
+		Prevhash: hash,
+		RootHash: hash,
+		Bits:     bits,
+		Time:     timestamp,
 	}
 
 	block2 := &model.Block{
-		Number:     1,
-		RootHash:   hash,
-		Prevhash:   hash,
-		Time:       timestamp,
-		Difficulty: difficulty,
+		Number:   1,
+		RootHash: hash,
+		Prevhash: hash,
+		Time:     timestamp,
+		Bits:     bits,
 	}
 
 	hash1, err := cryptography.Hash(block1)
@@ -178,3 +178,44 @@ func Test_TransactionReq_Hash(t *testing.T) {
 		t.Fatalf("Wrong hash, expect: %v, actual: %v", expect, actual)
 	}
 }
+
+type testContent struct {
+	hash []byte
+}
+
+func (c *testContent) SignatureHash() ([]byte, error) {
+	return c.hash, nil
+}
+
+func Test_SignContent(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generate key err: %s", err)
+	}
+	privkey, err := cryptography.EncodePrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Encode private key err: %s", err)
+	}
+	pubkey, err := cryptography.EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Encode public key err: %s", err)
+	}
+
+	hash, err := cryptography.Hash("content")
+	if err != nil {
+		t.Fatalf("Hash err: %s", err)
+	}
+
+	signature, err := cryptography.SignContent(privkey, &testContent{hash: hash})
+	if err != nil {
+		t.Fatalf("Sign content err: %s", err)
+	}
+
+	valid, err := cryptography.Verify(pubkey, hash, signature)
+	if err != nil {
+		t.Fatalf("Verify signature err: %s", err)
+	}
+	if !valid {
+		t.Fatalf("Verify failed")
+	}
+}
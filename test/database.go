@@ -0,0 +1,20 @@
+package test
+
+import (
+	"Bitcoin/src/database"
+	"Bitcoin/src/model"
+)
+
+// NewTestBaseDB makes an in-memory database.IBaseDB
+func NewTestBaseDB() database.IBaseDB {
+	return database.NewMemoryDB()
+}
+
+// NewTestBlockDB makes an in-memory database.IBlockDB with the blocks
+func NewTestBlockDB(blocks ...*model.Block) database.IBlockDB {
+	blockdb := database.NewBlockDB(NewTestBaseDB(), false)
+	for _, block := range blocks {
+		blockdb.SaveBlock(block)
+	}
+	return blockdb
+}
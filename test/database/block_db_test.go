@@ -2,8 +2,11 @@ package database
 
 import (
 	"Bitcoin/src/database"
+	"Bitcoin/src/infra"
+	"Bitcoin/src/model"
 	"Bitcoin/test"
 	"bytes"
+	"math/big"
 	"testing"
 
 	"github.com/syndtr/goleveldb/leveldb"
@@ -19,7 +22,7 @@ func Test_BlockDB_Get(t *testing.T) {
 
 	block := test.NewBlock(1, 10, nil)
 
-	blockdb := database.NewBlockDB(db)
+	blockdb := database.NewBlockDB(&database.BaseDB{Database: db}, false)
 	err = blockdb.SaveBlock(block)
 	if err != nil {
 		t.Fatalf("save block error: %s", err)
@@ -53,7 +56,7 @@ func Test_BlockContentDB_Get(t *testing.T) {
 	defer cleanUp(db, DBPath)
 
 	tx := test.NewTransaction([]byte{})
-	blockContentDb := database.NewBlockDB(db)
+	blockContentDb := database.NewBlockDB(&database.BaseDB{Database: db}, false)
 	err = blockContentDb.SaveTx(tx)
 	if err != nil {
 		t.Fatalf("save transaction error: %v", err)
@@ -76,3 +79,261 @@ func Test_BlockContentDB_Get(t *testing.T) {
 		t.Fatalf("transaction hash are not identical, expect: %x, actual: %x", tx.Hash, newTx.Hash)
 	}
 }
+
+func Test_BlockDB_Index(t *testing.T) {
+	db, err := leveldb.OpenFile(DBPath, nil)
+	if err != nil {
+		t.Fatalf("open %s error: %v", DBPath, err)
+	}
+
+	defer cleanUp(db, DBPath)
+
+	genesis := test.NewBlock(1, 10, nil)
+	block := test.NewBlock(2, 10, genesis.Hash)
+
+	blockdb := database.NewBlockDB(&database.BaseDB{Database: db}, false)
+	if err := blockdb.SaveBlock(genesis); err != nil {
+		t.Fatalf("save block error: %v", err)
+	}
+	if err := blockdb.SaveHeader(block); err != nil {
+		t.Fatalf("save header error: %v", err)
+	}
+
+	index, err := blockdb.GetIndex(block.Hash)
+	if err != nil || index == nil {
+		t.Fatalf("get index error: %v", err)
+	}
+	expect := new(big.Int).Add(infra.Work(genesis.Bits), infra.Work(block.Bits))
+	if index.Work.Cmp(expect) != 0 || index.Seq != 2 || index.Status != model.BlockHeaderValid {
+		t.Fatalf("unexpected index, work: %v, seq: %d, status: %d", index.Work, index.Seq, index.Status)
+	}
+
+	// the body upgrades the status, the sequence of the first time seen is kept
+	if err := blockdb.SaveBlock(block); err != nil {
+		t.Fatalf("save block error: %v", err)
+	}
+	index, err = blockdb.GetIndex(block.Hash)
+	if err != nil || index.Seq != 2 || index.Status != model.BlockDataValid {
+		t.Fatalf("unexpected index, seq: %d, status: %d, error: %v", index.Seq, index.Status, err)
+	}
+
+	indexes, err := blockdb.ListIndex()
+	if err != nil || len(indexes) != 2 {
+		t.Fatalf("expect indexes: %d, actual: %d, error: %v", 2, len(indexes), err)
+	}
+}
+
+func Test_BlockDB_Utxo(t *testing.T) {
+	db, err := leveldb.OpenFile(DBPath, nil)
+	if err != nil {
+		t.Fatalf("open %s error: %v", DBPath, err)
+	}
+
+	defer cleanUp(db, DBPath)
+
+	blockdb := database.NewBlockDB(&database.BaseDB{Database: db}, false)
+	out := &model.Out{Address: []byte("address"), Value: 10}
+	if err := blockdb.SaveUtxo(map[string]*model.Out{"a:0": out, "b:0": out}, []byte("block1")); err != nil {
+		t.Fatalf("save utxo error: %v", err)
+	}
+	// the nil output is spent
+	if err := blockdb.SaveUtxo(map[string]*model.Out{"a:0": nil}, []byte("block2")); err != nil {
+		t.Fatalf("save utxo error: %v", err)
+	}
+
+	utxo, best, err := blockdb.LoadUtxo()
+	if err != nil {
+		t.Fatalf("load utxo error: %v", err)
+	}
+	if !bytes.Equal(best, []byte("block2")) {
+		t.Fatalf("expect best block: %s, actual: %s", "block2", best)
+	}
+	if len(utxo) != 1 || utxo["b:0"] == nil || utxo["b:0"].Value != 10 {
+		t.Fatalf("expect only the unspent output, actual: %v", utxo)
+	}
+}
+
+func Test_BlockDB_Reindex(t *testing.T) {
+	db, err := leveldb.OpenFile(DBPath, nil)
+	if err != nil {
+		t.Fatalf("open %s error: %v", DBPath, err)
+	}
+
+	defer cleanUp(db, DBPath)
+
+	genesis := test.NewBlock(1, 10, nil)
+	block := test.NewBlock(2, 10, genesis.Hash)
+	header := test.NewBlock(3, 10, block.Hash)
+
+	blockdb := database.NewBlockDB(&database.BaseDB{Database: db}, false)
+	for _, b := range []*model.Block{genesis, block} {
+		if err := blockdb.SaveBlock(b); err != nil {
+			t.Fatalf("save block error: %v", err)
+		}
+	}
+	if err := blockdb.SaveHeader(header); err != nil {
+		t.Fatalf("save header error: %v", err)
+	}
+
+	// the broken index is rebuilt from the saved blocks and headers
+	if err := blockdb.SaveIndex(&model.BlockIndex{Hash: block.Hash, Work: big.NewInt(0), Status: model.BlockInvalid}); err != nil {
+		t.Fatalf("save index error: %v", err)
+	}
+	if err := blockdb.Reindex(); err != nil {
+		t.Fatalf("reindex error: %v", err)
+	}
+
+	indexes, err := blockdb.ListIndex()
+	if err != nil || len(indexes) != 3 {
+		t.Fatalf("expect indexes: %d, actual: %d, error: %v", 3, len(indexes), err)
+	}
+	expects := map[string]model.BlockStatus{
+		string(genesis.Hash): model.BlockDataValid,
+		string(block.Hash):   model.BlockDataValid,
+		string(header.Hash):  model.BlockHeaderValid,
+	}
+	work := big.NewInt(0)
+	for _, b := range []*model.Block{genesis, block, header} {
+		work = new(big.Int).Add(work, infra.Work(b.Bits))
+		index, err := blockdb.GetIndex(b.Hash)
+		if err != nil || index == nil {
+			t.Fatalf("get index error: %v", err)
+		}
+		if index.Status != expects[string(b.Hash)] || index.Work.Cmp(work) != 0 || index.Seq != b.Number {
+			t.Fatalf("unexpected index of block %d, status: %d, work: %v, seq: %d", b.Number, index.Status, index.Work, index.Seq)
+		}
+	}
+}
+
+func Test_BlockDB_Compress(t *testing.T) {
+	blockdb := database.NewBlockDB(database.NewMemoryDB(), true)
+
+	block := test.NewBlock(1, 1, nil)
+	if err := blockdb.SaveBlock(block); err != nil {
+		t.Fatalf("save block error: %v", err)
+	}
+
+	newBlock, err := blockdb.GetBlock(block.Hash, true)
+	if err != nil {
+		t.Fatalf("get block error: %v", err)
+	}
+	if !bytes.Equal(newBlock.Hash, block.Hash) || !bytes.Equal(newBlock.RootHash, block.RootHash) {
+		t.Fatalf("expect block %x with root %x, actual: %x with %x", block.Hash, block.RootHash, newBlock.Hash, newBlock.RootHash)
+	}
+	if len(newBlock.GetTxs()) != len(block.GetTxs()) {
+		t.Fatalf("expect %d transactions, actual: %d", len(block.GetTxs()), len(newBlock.GetTxs()))
+	}
+	// the tree is rebuilt from the transactions
+	if !bytes.Equal(newBlock.Body.Table[len(newBlock.Body.Table)-1][0].Hash, block.RootHash) {
+		t.Fatalf("the root of the rebuilt tree should be %x", block.RootHash)
+	}
+
+	tx := block.GetTxs()[0]
+	newTx, err := blockdb.GetTx(tx.Hash)
+	if err != nil || newTx == nil {
+		t.Fatalf("get transaction %x error: %v", tx.Hash, err)
+	}
+	if !bytes.Equal(model.EncodeTx(newTx), model.EncodeTx(tx)) || !bytes.Equal(newTx.BlockHash, tx.BlockHash) {
+		t.Fatalf("transaction %x is changed", tx.Hash)
+	}
+}
+
+func Test_BlockDB_Migrate(t *testing.T) {
+	basedb := database.NewMemoryDB()
+	blockdb := database.NewBlockDB(basedb, false)
+
+	// the records saved as JSON before the binary format
+	block := test.NewBlock(1, 1, nil)
+	basedb.Save([]byte(database.BlockTable), block.Hash, block)
+	basedb.Save([]byte(database.BlockContentTable), block.RootHash, block.Body)
+	for _, tx := range block.GetTxs() {
+		basedb.Save([]byte(database.TxTable), tx.Hash, tx)
+	}
+	basedb.Save([]byte(database.BlockTreeTable), block.Hash, &model.BlockIndex{Hash: block.Hash, Number: block.Number, Status: model.BlockDataValid})
+
+	migrated, err := blockdb.Migrate()
+	if err != nil {
+		t.Fatalf("migrate error: %v", err)
+	}
+	if expect := 2 + len(block.GetTxs()); migrated != expect {
+		t.Fatalf("expect %d records migrated, actual: %d", expect, migrated)
+	}
+
+	var data database.Raw
+	if _, err := basedb.Get([]byte(database.BlockContentTable), block.RootHash, &data); err != nil || data[0] == '{' {
+		t.Fatalf("the content should not be JSON after the migration, error: %v", err)
+	}
+
+	newBlock, err := blockdb.GetBlock(block.Hash, true)
+	if err != nil {
+		t.Fatalf("get block error: %v", err)
+	}
+	if !bytes.Equal(newBlock.Hash, block.Hash) || len(newBlock.GetTxs()) != len(block.GetTxs()) {
+		t.Fatalf("expect block %x with %d transactions, actual: %x with %d", block.Hash, len(block.GetTxs()), newBlock.Hash, len(newBlock.GetTxs()))
+	}
+
+	// the index saved before is keyed by the number
+	hashes, err := blockdb.ListHashes(block.Number)
+	if err != nil || len(hashes) != 1 || !bytes.Equal(hashes[0], block.Hash) {
+		t.Fatalf("expect the hash %x of the number %d, actual: %x, error: %v", block.Hash, block.Number, hashes, err)
+	}
+
+	// the size of the bodies saved before is counted
+	if size, err := blockdb.BodySize(); err != nil || size != scanBodySize(t, basedb) {
+		t.Fatalf("expect body size: %d, actual: %d, error: %v", scanBodySize(t, basedb), size, err)
+	}
+
+	migrated, err = blockdb.Migrate()
+	if err != nil || migrated != 0 {
+		t.Fatalf("the migration should run once, but %d records migrated again, error: %v", migrated, err)
+	}
+}
+
+func Test_BlockDB_Body_Size(t *testing.T) {
+	basedb := database.NewMemoryDB()
+	blockdb := database.NewBlockDB(basedb, false)
+
+	block1 := test.NewBlock(1, 1, nil)
+	block2 := test.NewBlock(2, 1, block1.Hash)
+	for _, block := range []*model.Block{block1, block2, block1} {
+		if err := blockdb.SaveBlock(block); err != nil {
+			t.Fatalf("save block error: %v", err)
+		}
+	}
+	if err := blockdb.SaveTx(test.NewTransaction([]byte{})); err != nil {
+		t.Fatalf("save transaction error: %v", err)
+	}
+
+	// the block saved again is not counted twice
+	size, err := blockdb.BodySize()
+	if err != nil || size == 0 || size != scanBodySize(t, basedb) {
+		t.Fatalf("expect body size: %d, actual: %d, error: %v", scanBodySize(t, basedb), size, err)
+	}
+
+	txs := [][]byte{block1.GetTxs()[0].Hash, block1.GetTxs()[0].Hash}
+	deleted, err := blockdb.PruneBlocks([]*model.Block{block1}, txs, block1.Number)
+	if err != nil {
+		t.Fatalf("prune blocks error: %v", err)
+	}
+
+	pruned, err := blockdb.BodySize()
+	if err != nil || pruned != size-deleted || pruned != scanBodySize(t, basedb) {
+		t.Fatalf("expect body size: %d, actual: %d, error: %v", scanBodySize(t, basedb), pruned, err)
+	}
+}
+
+// scanBodySize sums the length of the records of the block bodies
+func scanBodySize(t *testing.T, basedb database.IBaseDB) int64 {
+	var total int64
+	for _, table := range []string{database.BlockContentTable, database.TxTable, database.UndoTable} {
+		iter := basedb.Iterator([]byte(table), nil, false)
+		for iter.Next() {
+			total += int64(len(iter.Value()))
+		}
+		if err := iter.Error(); err != nil {
+			t.Fatalf("scan %s error: %v", table, err)
+		}
+		iter.Release()
+	}
+	return total
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
+	"path/filepath"
+	"testing"
+)
+
+// forEachEngine runs the conformance case against every registered storage engine
+func forEachEngine(t *testing.T, run func(t *testing.T, db database.IBaseDB)) {
+	for _, name := range database.Engines() {
+		t.Run(name, func(t *testing.T) {
+			db, err := database.Open(name, filepath.Join(t.TempDir(), DBPath))
+			if err != nil {
+				t.Fatalf("open %s error: %v", name, err)
+			}
+			defer db.Close()
+
+			run(t, db)
+		})
+	}
+}
+
+func Test_Open_Engines(t *testing.T) {
+	names := database.Engines()
+	if len(names) != 2 || names[0] != database.LevelDBEngine || names[1] != database.MemoryEngine {
+		t.Fatalf("should register %s and %s, but %v", database.LevelDBEngine, database.MemoryEngine, names)
+	}
+
+	_, err := database.Open("unknown", t.TempDir())
+	if err != errors.ErrStorageEngineUnknown {
+		t.Fatalf("should be %v, but %v", errors.ErrStorageEngineUnknown, err)
+	}
+}
+
+func Test_Engine_Save_Get(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		key := []byte("Hello")
+		if err := db.Save([]byte(TestTable), key, "World"); err != nil {
+			t.Fatalf("save %s error: %v", key, err)
+		}
+
+		var val string
+		has, err := db.Get([]byte(TestTable), key, &val)
+		if err != nil || !has {
+			t.Fatalf("get %s should has it, but %v %v", key, has, err)
+		}
+		if val != "World" {
+			t.Fatalf("should get World, but %s", val)
+		}
+
+		has, err = db.Get([]byte(TestTable), []byte("Missing"), &val)
+		if err != nil || has {
+			t.Fatalf("get Missing should not has it, but %v %v", has, err)
+		}
+
+		// the value saved by []byte is read as it is by database.Raw
+		if err := db.Save([]byte(TestTable), []byte("Binary"), []byte{0x01, 0x02}); err != nil {
+			t.Fatalf("save Binary error: %v", err)
+		}
+		var raw database.Raw
+		has, err = db.Get([]byte(TestTable), []byte("Binary"), &raw)
+		if err != nil || !has || string(raw) != "\x01\x02" {
+			t.Fatalf("get Binary should be 0102, but %x %v %v", raw, has, err)
+		}
+	})
+}
+
+func Test_Engine_Delete(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		key := []byte("Hello")
+		if err := db.Save([]byte(TestTable), key, 1); err != nil {
+			t.Fatalf("save %s error: %v", key, err)
+		}
+		if err := db.Delete([]byte(TestTable), key); err != nil {
+			t.Fatalf("delete %s error: %v", key, err)
+		}
+		// deleting the missing key is not an error
+		if err := db.Delete([]byte(TestTable), key); err != nil {
+			t.Fatalf("delete %s again error: %v", key, err)
+		}
+
+		var val int
+		has, err := db.Get([]byte(TestTable), key, &val)
+		if err != nil {
+			t.Fatalf("get %s error: %v", key, err)
+		}
+		if has {
+			t.Fatalf("%s should be deleted", key)
+		}
+	})
+}
+
+func Test_Engine_Batch(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		if err := db.Save([]byte(TestTable), []byte("a"), 1); err != nil {
+			t.Fatalf("save a error: %v", err)
+		}
+
+		batch := db.StartBatch()
+		if err := batch.Save([]byte(TestTable), []byte("b"), 2); err != nil {
+			t.Fatalf("batch save b error: %v", err)
+		}
+		if err := batch.Delete([]byte(TestTable), []byte("a")); err != nil {
+			t.Fatalf("batch delete a error: %v", err)
+		}
+
+		var val int
+		if has, _ := db.Get([]byte(TestTable), []byte("b"), &val); has {
+			t.Fatalf("b should not be saved before the batch ends")
+		}
+
+		if err := db.EndBatch(batch); err != nil {
+			t.Fatalf("end batch error: %v", err)
+		}
+		if has, _ := db.Get([]byte(TestTable), []byte("a"), &val); has {
+			t.Fatalf("a should be deleted by the batch")
+		}
+		if has, _ := db.Get([]byte(TestTable), []byte("b"), &val); !has || val != 2 {
+			t.Fatalf("b should be 2, but %v %d", has, val)
+		}
+	})
+}
+
+func Test_Engine_Filter_Size(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		for _, key := range []string{"c", "a", "b"} {
+			if err := db.Save([]byte(TestTable), []byte(key), []byte(key)); err != nil {
+				t.Fatalf("save %s error: %v", key, err)
+			}
+		}
+
+		vals, err := db.Filter([]byte(TestTable), []byte("b"))
+		if err != nil {
+			t.Fatalf("filter error: %v", err)
+		}
+		if len(vals) != 2 || string(vals[0]) != "b" || string(vals[1]) != "c" {
+			t.Fatalf("should filter b and c, but %q", vals)
+		}
+
+		// the size is approximated by the leveldb engine, it may be 0 before the compaction
+		size, err := db.Size([]byte(TestTable))
+		if err != nil || size < 0 {
+			t.Fatalf("size should not be negative, but %d %v", size, err)
+		}
+	})
+}
+
+func Test_Engine_Iterator(t *testing.T) {
+	cases := []struct {
+		r       *database.Range
+		reverse bool
+		keys    string
+	}{
+		{nil, false, "abcd"},
+		{nil, true, "dcba"},
+		{&database.Range{Start: []byte("b")}, false, "bcd"},
+		{&database.Range{Limit: []byte("c")}, true, "ba"},
+		{&database.Range{Start: []byte("b"), Limit: []byte("d")}, true, "cb"},
+	}
+
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		for _, key := range []string{"c", "a", "d", "b"} {
+			if err := db.Save([]byte(TestTable), []byte(key), []byte(key+key)); err != nil {
+				t.Fatalf("save %s error: %v", key, err)
+			}
+		}
+		// the table sharing the prefix should not be walked
+		if err := db.Save([]byte(TestTable+"s"), []byte("e"), []byte("ee")); err != nil {
+			t.Fatalf("save e error: %v", err)
+		}
+
+		for i, c := range cases {
+			iter := db.Iterator([]byte(TestTable), c.r, c.reverse)
+			keys := ""
+			for iter.Next() {
+				if string(iter.Value()) != string(iter.Key())+string(iter.Key()) {
+					t.Fatalf("case %d: %s should have value %s%s, but %s", i, iter.Key(), iter.Key(), iter.Key(), iter.Value())
+				}
+				keys += string(iter.Key())
+			}
+			if err := iter.Error(); err != nil {
+				t.Fatalf("case %d: iterate error: %v", i, err)
+			}
+			iter.Release()
+
+			if keys != c.keys {
+				t.Fatalf("case %d: should iterate %s, but %s", i, c.keys, keys)
+			}
+		}
+	})
+}
+
+func Test_Engine_Snapshot(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db database.IBaseDB) {
+		if err := db.Save([]byte(TestTable), []byte("a"), 1); err != nil {
+			t.Fatalf("save a error: %v", err)
+		}
+
+		snapshot, err := db.Snapshot()
+		if err != nil {
+			t.Fatalf("snapshot error: %v", err)
+		}
+		defer snapshot.Release()
+
+		if err := db.Save([]byte(TestTable), []byte("a"), 2); err != nil {
+			t.Fatalf("save a error: %v", err)
+		}
+		if err := db.Save([]byte(TestTable), []byte("b"), 3); err != nil {
+			t.Fatalf("save b error: %v", err)
+		}
+
+		var val int
+		has, err := snapshot.Get([]byte(TestTable), []byte("a"), &val)
+		if err != nil || !has {
+			t.Fatalf("snapshot get a should has it, but %v %v", has, err)
+		}
+		if val != 1 {
+			t.Fatalf("snapshot should get 1, but %d", val)
+		}
+
+		iter := snapshot.Iterator([]byte(TestTable), nil, false)
+		count := 0
+		for iter.Next() {
+			count++
+		}
+		iter.Release()
+		if count != 1 {
+			t.Fatalf("snapshot should have 1 key, but %d", count)
+		}
+	})
+}
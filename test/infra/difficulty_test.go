@@ -0,0 +1,85 @@
+package infra
+
+import (
+	"Bitcoin/src/infra"
+	"math/big"
+	"testing"
+)
+
+func Test_CompactToBig(t *testing.T) {
+	cases := map[uint32]string{
+		0x00000000: "0",
+		0x01003456: "0",
+		0x01123456: "12",
+		0x02123456: "1234",
+		0x04123456: "12345600",
+		0x04923456: "-12345600",
+		0x1d00ffff: "ffff0000000000000000000000000000000000000000000000000000",
+	}
+
+	for bits, expect := range cases {
+		actual := infra.CompactToBig(bits)
+		if actual.Text(16) != expect {
+			t.Fatalf("bits %08x, expect target: %s, actual: %s", bits, expect, actual.Text(16))
+		}
+	}
+}
+
+func Test_BigToCompact(t *testing.T) {
+	for _, bits := range []uint32{0x01120000, 0x02123400, 0x04123456, 0x04923456, 0x1d00ffff, 0x2100ffff} {
+		actual := infra.BigToCompact(infra.CompactToBig(bits))
+		if actual != bits {
+			t.Fatalf("expect bits: %08x, actual: %08x", bits, actual)
+		}
+	}
+
+	// the precision is only 3 bytes
+	n, _ := new(big.Int).SetString("123456789a", 16)
+	if bits := infra.BigToCompact(n); bits != 0x05123456 {
+		t.Fatalf("expect bits: %08x, actual: %08x", 0x05123456, bits)
+	}
+}
+
+func Test_CheckProofOfWork(t *testing.T) {
+	bits := infra.MakeBits(8)
+	target := infra.CompactToBig(bits)
+
+	hash := make([]byte, 32)
+	target.FillBytes(hash)
+	if !infra.CheckProofOfWork(hash, bits) {
+		t.Fatalf("hash %x equal to the target should be valid", hash)
+	}
+
+	new(big.Int).Add(target, big.NewInt(1)).FillBytes(hash)
+	if infra.CheckProofOfWork(hash, bits) {
+		t.Fatalf("hash %x greater than the target should be invalid", hash)
+	}
+
+	for _, invalid := range []uint32{0x00000000, 0x04923456, 0x2200ffff} {
+		if infra.CheckProofOfWork(make([]byte, 32), invalid) {
+			t.Fatalf("bits %08x is not a valid target", invalid)
+		}
+	}
+}
+
+func Test_Work(t *testing.T) {
+	bits := infra.MakeBits(8)
+	target := infra.CompactToBig(bits)
+
+	// the work is 2^256 / (target + 1)
+	expect := new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), new(big.Int).Add(target, big.NewInt(1)))
+	if work := infra.Work(bits); work.Cmp(expect) != 0 {
+		t.Fatalf("expect work: %v, actual: %v", expect, work)
+	}
+
+	harder := infra.BigToCompact(new(big.Int).Div(target, big.NewInt(2)))
+	if infra.Work(harder).Cmp(infra.Work(bits)) <= 0 {
+		t.Fatalf("the smaller target should have more work")
+	}
+
+	for _, invalid := range []uint32{0x00000000, 0x04923456} {
+		if infra.Work(invalid).Sign() != 0 {
+			t.Fatalf("bits %08x should have no work", invalid)
+		}
+	}
+}
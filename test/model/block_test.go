@@ -3,6 +3,7 @@ package model
 import (
 	"Bitcoin/src/collection"
 	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
 	"Bitcoin/src/infra"
 	"Bitcoin/src/model"
 	"Bitcoin/src/protocol"
@@ -13,6 +14,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"strconv"
 	"testing"
@@ -20,13 +22,13 @@ import (
 )
 
 type testBlock struct {
-	Number     uint64    `json:"number,omitempty"`
-	Hash       string    `json:"hash,omitempty"`
-	Prevhash   string    `json:"prevhash,omitempty"`
-	RootHash   string    `json:"roothash,omitempty"`
-	Nonce      uint32    `json:"nonce,omitempty"`
-	Difficulty string    `json:"difficulty,omitempty"`
-	Timestamp  time.Time `json:"timestamp,omitempty"`
+	Number    uint64    `json:"number,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	Prevhash  string    `json:"prevhash,omitempty"`
+	RootHash  string    `json:"roothash,omitempty"`
+	Nonce     uint32    `json:"nonce,omitempty"`
+	Bits      string    `json:"bits,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
 }
 
 func (s *testBlock) equal(block *model.Block) (bool, string, string, string) {
@@ -53,9 +55,9 @@ func (s *testBlock) equal(block *model.Block) (bool, string, string, string) {
 		return false, "nonce", fmt.Sprintf("%d", s.Nonce), fmt.Sprintf("%d", block.Nonce)
 	}
 
-	expectDifficulty, _ := strconv.ParseFloat(s.Difficulty, 64)
-	if expectDifficulty != block.Difficulty {
-		return false, "difficulty", fmt.Sprintf("%.0f", expectDifficulty), fmt.Sprintf("%.0f", block.Difficulty)
+	expectBits, _ := strconv.ParseUint(s.Bits, 16, 32)
+	if uint32(expectBits) != block.Bits {
+		return false, "bits", fmt.Sprintf("%08x", expectBits), fmt.Sprintf("%08x", block.Bits)
 	}
 
 	if s.Timestamp.UnixMilli() != block.Time.UnixMilli() {
@@ -131,8 +133,8 @@ func Test_Difficulty(t *testing.T) {
 	}
 
 	for z := uint64(1); z <= 5; z++ {
-		difficulty := infra.ComputeDifficulty(infra.MakeDifficulty(z))
-		log.Printf("difficulty: %x", difficulty)
+		bits := infra.MakeBits(z)
+		log.Printf("bits: %08x", bits)
 
 		for k := z; k < z+5; k++ {
 			for i := uint64(0); i < k; i++ {
@@ -142,14 +144,40 @@ func Test_Difficulty(t *testing.T) {
 			}
 			t.Logf("hash: %x", hash)
 
-			actual := infra.ComputeDifficulty(hash)
-			if actual > difficulty {
-				t.Fatalf("compute difficulty error, %v should smaller then %v, but actally greater", actual, difficulty)
+			if !infra.CheckProofOfWork(hash, bits) {
+				t.Fatalf("hash %x should meet the target of bits %08x, but not", hash, bits)
 			}
 		}
 	}
 }
 
+func Test_GetNextBits(t *testing.T) {
+	block := &model.Block{Number: 10, Bits: infra.MakeBits(8)}
+	target := infra.CompactToBig(block.Bits)
+
+	// twice as slow as expected, so the target doubles
+	block.TotalInterval = 10 * 60 * 1000 * 2
+	bits := block.GetNextBits(10, 60)
+	expect := new(big.Int).Mul(target, big.NewInt(2))
+	if infra.CompactToBig(bits).Cmp(expect) != 0 {
+		t.Fatalf("expect target: %x, actual: %x", expect, infra.CompactToBig(bits))
+	}
+
+	// the adjustment is limited to 4 times
+	block.TotalInterval = 1
+	bits = block.GetNextBits(10, 60)
+	expect = new(big.Int).Div(target, big.NewInt(4))
+	if infra.CompactToBig(bits).Cmp(expect) != 0 {
+		t.Fatalf("expect target: %x, actual: %x", expect, infra.CompactToBig(bits))
+	}
+
+	// no retarget inside a period
+	block.Number = 11
+	if bits = block.GetNextBits(10, 60); bits != block.Bits {
+		t.Fatalf("expect bits: %08x, actual: %08x", block.Bits, bits)
+	}
+}
+
 func Test_Block_From(t *testing.T) {
 	req := newBlockReq()
 	block, err := model.BlockFrom(req)
@@ -166,6 +194,41 @@ func Test_Block_From(t *testing.T) {
 	if !same {
 		t.Fatalf("%s mismatch, expect: %s actual: %s", field, expect, actual)
 	}
+
+	txs := block.GetTxs()
+	for i, tx := range txs {
+		if tx == nil || !bytes.Equal(tx.Hash, req.Txs[i].Hash) || !bytes.Equal(tx.BlockHash, block.Hash) {
+			t.Fatalf("the transaction %d of the block mismatch", i)
+		}
+	}
+}
+
+func Test_Block_From_Txs_Mismatch(t *testing.T) {
+	req := newBlockReq()
+	req.Txs = req.Txs[1:]
+
+	if _, err := model.BlockFrom(req); err != errors.ErrBlockContentInvalid {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockContentInvalid, err)
+	}
+}
+
+func Test_Block_To_From_Single_Tx(t *testing.T) {
+	block := test.NewBlock(1, 10, nil)
+	block.Body, _ = collection.BuildTree([]*model.Transaction{test.NewTransaction(nil)})
+
+	req, err := model.BlockTo(block)
+	if err != nil {
+		t.Fatalf("block to err: %v", err)
+	}
+
+	actual, err := model.BlockFrom(req)
+	if err != nil {
+		t.Fatalf("block from err: %v", err)
+	}
+	txs := actual.GetTxs()
+	if len(txs) != 1 || !bytes.Equal(txs[0].Hash, block.GetTxs()[0].Hash) {
+		t.Fatalf("the transaction of the single transaction block mismatch")
+	}
 }
 
 func Test_Block_To(t *testing.T) {
@@ -186,6 +249,10 @@ func Test_Block_To(t *testing.T) {
 		log.Fatalf("block req root hash mismatch, expect: %x, actual: %x", req.RootHash, rootHash)
 	}
 
+	if len(req.Txs) != len(tree.Table[0]) {
+		t.Fatalf("expect txs: %d, actual: %d", len(tree.Table[0]), len(req.Txs))
+	}
+
 	same, field, expect, actual := equal(req, block)
 	if !same {
 		t.Fatalf("%s mismatch, expect: %s actual: %s", field, expect, actual)
@@ -203,9 +270,29 @@ func Test_Block_FindHash(t *testing.T) {
 		t.Fatalf("block %x nonce should not be 0", block.Hash)
 	}
 
-	difficulty := infra.ComputeDifficulty(hash)
-	if difficulty > block.Difficulty {
-		t.Fatalf("the difficulty %.0f of block hash %x nonce > %.0f", difficulty, block.Hash, block.Difficulty)
+	if !infra.CheckProofOfWork(hash, block.Bits) {
+		t.Fatalf("the block hash %x doesn't meet the target of bits %08x", hash, block.Bits)
+	}
+}
+
+func Test_Header_To_From(t *testing.T) {
+	req := newBlockReq()
+	block, err := model.BlockFrom(req)
+	if err != nil {
+		t.Fatalf("block from err: %v", err)
+	}
+
+	headerReq := model.HeaderTo(block)
+	if headerReq.Content != nil || headerReq.Txs != nil {
+		t.Fatalf("the header should not have the content")
+	}
+
+	header := model.HeaderFrom(headerReq)
+	if header.Body != nil {
+		t.Fatalf("the header should not have the body")
+	}
+	if ok, field, expect, actual := equal(req, header); !ok {
+		t.Fatalf("header %s mismatch, expect: %s, actual: %s", field, expect, actual)
 	}
 }
 
@@ -232,13 +319,20 @@ func newBlockReq() *protocol.BlockReq {
 		log.Fatalf("marshal tree err: %v", err)
 	}
 
+	txReqs := make([]*protocol.TransactionReq, len(txs))
+	for i, tx := range txs {
+		txReqs[i] = model.TransactionTo(tx)
+	}
+
 	block := &protocol.BlockReq{
-		Number:    1,
-		Prevhash:  prevHash,
-		RootHash:  rootHash,
-		Nonce:     10,
-		Timestamp: time.Now().UnixMilli(),
-		Content:   content,
+		Number:        1,
+		Prevhash:      prevHash,
+		RootHash:      rootHash,
+		Nonce:         10,
+		Timestamp:     time.Now().UnixMilli(),
+		TotalInterval: 60000,
+		Content:       content,
+		Txs:           txReqs,
 	}
 
 	hash, err := cryptography.Hash(block)
@@ -247,7 +341,7 @@ func newBlockReq() *protocol.BlockReq {
 	}
 	block.Hash = hash
 
-	block.Difficulty = infra.ComputeDifficulty(hash)
+	block.Bits = infra.BigToCompact(infra.HashToBig(hash))
 
 	return block
 }
@@ -273,13 +367,42 @@ func equal(req *protocol.BlockReq, block *model.Block) (bool, string, string, st
 		return false, "nonce", fmt.Sprintf("%d", req.Nonce), fmt.Sprintf("%d", block.Nonce)
 	}
 
-	if req.Difficulty != block.Difficulty {
-		return false, "difficulty", fmt.Sprintf("%.0f", req.Difficulty), fmt.Sprintf("%.0f", block.Difficulty)
+	if req.Bits != block.Bits {
+		return false, "bits", fmt.Sprintf("%08x", req.Bits), fmt.Sprintf("%08x", block.Bits)
 	}
 
 	if req.Timestamp != block.Time.UnixMilli() {
 		return false, "timestamp", fmt.Sprintf("%v", req.Timestamp), fmt.Sprintf("%v", block.Time)
 	}
 
+	if req.TotalInterval != block.TotalInterval {
+		return false, "totalInterval", fmt.Sprintf("%d", req.TotalInterval), fmt.Sprintf("%d", block.TotalInterval)
+	}
+
 	return true, "", "", ""
 }
+
+func Test_BlockUndo_Restore(t *testing.T) {
+	txs := []*model.Transaction{test.NewTransaction(nil), test.NewTransaction(nil)}
+	tree, err := collection.BuildTree(txs)
+	if err != nil {
+		t.Fatalf("build tree error: %v", err)
+	}
+	block := &model.Block{Body: tree}
+
+	outs := []*model.Out{{Value: 1}, {Value: 2}}
+	undo := &model.BlockUndo{PrevOuts: outs}
+	if err := undo.Restore(block); err != nil {
+		t.Fatalf("restore undo error: %v", err)
+	}
+	for i, tx := range block.GetTxs() {
+		if tx.Ins[0].PrevOut != outs[i] {
+			t.Fatalf("the spent output of transaction %d should be restored", i)
+		}
+	}
+
+	undo = &model.BlockUndo{PrevOuts: outs[:1]}
+	if err := undo.Restore(block); err != errors.ErrBlockUndoMismatch {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockUndoMismatch, err)
+	}
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"Bitcoin/src/model"
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// the golden vectors pin the binary encoding, other implementations must reproduce them byte for byte
+
+const (
+	goldenTxEncoding     = "0100000001000000020000000100000020111111111111111111111111111111111111111111111111111111111111111100000001000000030a0b0c00000002aabb0000000200000003010203000000000000000600000002040500000000000000040000018bcfe56800"
+	goldenTxHash         = "1e9814b8a7a61525932bb2738bf2a1159727baf4258d57f06350bee8c6bfea4a"
+	goldenSigHash        = "75f5397aad833caffa240212934ea0c3b68ea7d4427a6862a84b3e625ae3e823"
+	goldenHeaderEncoding = "010000000000000002000000202222222222222222222222222222222222222222222222222222222222222222000000203333333333333333333333333333333333333333333333333333333333333333000000071f00ffff0000018bcfe65260"
+	goldenBlockHash      = "2baf9d5deeeb51d65bf64ee9f70932578cac160bda5474dfe90d7f71690d803c"
+)
+
+func Test_EncodeTx_Golden(t *testing.T) {
+	tx := newGoldenTx()
+
+	data := model.EncodeTx(tx)
+	if hex.EncodeToString(data) != goldenTxEncoding {
+		t.Fatalf("expect encoding: %s, actual: %x", goldenTxEncoding, data)
+	}
+
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		t.Fatalf("compute hash error: %v", err)
+	}
+	if hex.EncodeToString(hash) != goldenTxHash {
+		t.Fatalf("expect hash: %s, actual: %x", goldenTxHash, hash)
+	}
+
+	sighash, err := tx.SignatureHash()
+	if err != nil {
+		t.Fatalf("compute signature hash error: %v", err)
+	}
+	if hex.EncodeToString(sighash) != goldenSigHash {
+		t.Fatalf("expect signature hash: %s, actual: %x", goldenSigHash, sighash)
+	}
+}
+
+func Test_EncodeHeader_Golden(t *testing.T) {
+	block := newGoldenBlock()
+
+	data := model.EncodeHeader(block)
+	if hex.EncodeToString(data) != goldenHeaderEncoding {
+		t.Fatalf("expect encoding: %s, actual: %x", goldenHeaderEncoding, data)
+	}
+
+	hash, err := block.ComputeHash()
+	if err != nil {
+		t.Fatalf("compute hash error: %v", err)
+	}
+	if hex.EncodeToString(hash) != goldenBlockHash {
+		t.Fatalf("expect hash: %s, actual: %x", goldenBlockHash, hash)
+	}
+}
+
+func Test_ComputeHash_Ignore_Time_Zone(t *testing.T) {
+	tx := newGoldenTx()
+	hash, _ := tx.ComputeHash()
+
+	tx.Timestamp = tx.Timestamp.In(time.FixedZone("UTC+8", 8*60*60))
+	hash1, _ := tx.ComputeHash()
+
+	if !bytes.Equal(hash, hash1) {
+		t.Fatalf("transaction hash is changed from [%x] to [%x] in another time zone", hash, hash1)
+	}
+}
+
+func Test_DecodeTx(t *testing.T) {
+	tx := newGoldenTx()
+
+	newtx, err := model.DecodeTx(model.EncodeTx(tx))
+	if err != nil {
+		t.Fatalf("decode transaction error: %v", err)
+	}
+	if !bytes.Equal(model.EncodeTx(newtx), model.EncodeTx(tx)) {
+		t.Fatal("decoded transaction is not equal with transaction")
+	}
+	if !newtx.Timestamp.Equal(tx.Timestamp) {
+		t.Fatalf("expect timestamp: %v, actual: %v", tx.Timestamp, newtx.Timestamp)
+	}
+}
+
+func Test_DecodeHeader(t *testing.T) {
+	block := newGoldenBlock()
+
+	newblock, err := model.DecodeHeader(model.EncodeHeader(block))
+	if err != nil {
+		t.Fatalf("decode header error: %v", err)
+	}
+	if !bytes.Equal(model.EncodeHeader(newblock), model.EncodeHeader(block)) {
+		t.Fatal("decoded header is not equal with header")
+	}
+}
+
+func Test_Decode_Malformed(t *testing.T) {
+	data := model.EncodeTx(newGoldenTx())
+
+	_, err := model.DecodeTx(data[:len(data)-1])
+	if err == nil {
+		t.Fatal("decode truncated transaction should fail")
+	}
+
+	data[0] = model.EncodingVersion + 1
+	_, err = model.DecodeTx(data)
+	if err == nil {
+		t.Fatal("decode transaction of unknown version should fail")
+	}
+}
+
+func newGoldenTx() *model.Transaction {
+	return &model.Transaction{
+		InLen:  1,
+		OutLen: 2,
+		Ins: []*model.In{
+			{PrevHash: bytes.Repeat([]byte{0x11}, 32), Index: 1, Pubkey: []byte{0x0a, 0x0b, 0x0c}, Signature: []byte{0xaa, 0xbb}},
+		},
+		Outs: []*model.Out{
+			{Address: []byte{0x01, 0x02, 0x03}, Value: 6},
+			{Address: []byte{0x04, 0x05}, Value: 4},
+		},
+		Timestamp: time.UnixMilli(1700000000000),
+	}
+}
+
+func newGoldenBlock() *model.Block {
+	return &model.Block{
+		Number:   2,
+		Prevhash: bytes.Repeat([]byte{0x22}, 32),
+		RootHash: bytes.Repeat([]byte{0x33}, 32),
+		Nonce:    7,
+		Bits:     0x1f00ffff,
+		Time:     time.UnixMilli(1700000060000),
+	}
+}
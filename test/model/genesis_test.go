@@ -0,0 +1,63 @@
+package model
+
+import (
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"Bitcoin/test"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+)
+
+func Test_MakeGenesisBlock_Deterministic(t *testing.T) {
+	genesis := newGenesis()
+
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatalf("marshal genesis error: %v", err)
+	}
+
+	var newGenesis model.Genesis
+	if err := json.Unmarshal(data, &newGenesis); err != nil {
+		t.Fatalf("unmarshal genesis error: %v", err)
+	}
+
+	block, err := model.MakeGenesisBlock(genesis)
+	if err != nil {
+		t.Fatalf("make genesis block error: %v", err)
+	}
+
+	block1, err := model.MakeGenesisBlock(&newGenesis)
+	if err != nil {
+		t.Fatalf("make genesis block from file error: %v", err)
+	}
+
+	if !bytes.Equal(block.Hash, genesis.Hash) || !bytes.Equal(block1.Hash, genesis.Hash) {
+		t.Fatalf("expect genesis hash: %x, actual: %x and %x", genesis.Hash, block.Hash, block1.Hash)
+	}
+}
+
+func Test_MakeGenesisBlock_Invalid(t *testing.T) {
+	genesis := newGenesis()
+	genesis.Nonce++
+
+	_, err := model.MakeGenesisBlock(genesis)
+	if err != errors.ErrGenesisInvalid {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrGenesisInvalid, err)
+	}
+}
+
+func newGenesis() *model.Genesis {
+	_, pubkey := test.NewKeys()
+	outs := []*model.Out{{Address: cryptography.AddressHash(pubkey), Value: 50}}
+
+	genesis, err := model.MineGenesis(context.Background(), outs, 4, time.Now())
+	if err != nil {
+		log.Fatalf("mine genesis error: %v", err)
+	}
+	return genesis
+}
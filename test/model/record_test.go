@@ -0,0 +1,55 @@
+package model
+
+import (
+	"Bitcoin/src/model"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_DecodeBlockRecord(t *testing.T) {
+	block := newGoldenBlock()
+	block.Hash = bytes.Repeat([]byte{0x44}, 32)
+	block.Time = block.Time.Add(123 * time.Nanosecond)
+	block.TotalInterval = 1000
+	block.Miner = "miner"
+
+	newblock, err := model.DecodeBlockRecord(model.EncodeBlockRecord(block))
+	if err != nil {
+		t.Fatalf("decode block record error: %v", err)
+	}
+	if !bytes.Equal(newblock.Hash, block.Hash) || !bytes.Equal(model.EncodeHeader(newblock), model.EncodeHeader(block)) {
+		t.Fatal("decoded block is not equal with block")
+	}
+	if !newblock.Time.Equal(block.Time) || newblock.TotalInterval != block.TotalInterval || newblock.Miner != block.Miner {
+		t.Fatalf("expect time: %v, total interval: %d, miner: %s, actual: %v, %d, %s",
+			block.Time, block.TotalInterval, block.Miner, newblock.Time, newblock.TotalInterval, newblock.Miner)
+	}
+}
+
+func Test_DecodeContentRecord(t *testing.T) {
+	tx := newGoldenTx()
+	tx.Hash, _ = tx.ComputeHash()
+	tx.BlockHash = bytes.Repeat([]byte{0x44}, 32)
+	// the zero time is kept
+	coinbase := &model.Transaction{Hash: []byte{0x55}, Outs: []*model.Out{{Address: []byte{0x01}, Value: 50}}}
+
+	txs, err := model.DecodeContentRecord(model.EncodeContentRecord([]*model.Transaction{coinbase, tx}))
+	if err != nil {
+		t.Fatalf("decode content record error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expect 2 transactions, actual: %d", len(txs))
+	}
+	if !bytes.Equal(txs[1].Hash, tx.Hash) || !bytes.Equal(txs[1].BlockHash, tx.BlockHash) || !bytes.Equal(model.EncodeTx(txs[1]), model.EncodeTx(tx)) {
+		t.Fatal("decoded transaction is not equal with transaction")
+	}
+	if !txs[0].Timestamp.IsZero() || txs[0].Outs[0].Value != 50 {
+		t.Fatalf("expect zero time and value 50, actual: %v and %d", txs[0].Timestamp, txs[0].Outs[0].Value)
+	}
+
+	data := model.EncodeTxRecord(tx)
+	if _, err := model.DecodeTxRecord(data[:len(data)-1]); err == nil {
+		t.Fatal("decode truncated transaction record should fail")
+	}
+}
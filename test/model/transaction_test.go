@@ -91,13 +91,14 @@ func newTransactionReq() *protocol.TransactionReq {
 		{
 			PrevHash:  prevHash,
 			Index:     0,
+			Pubkey:    pubkey,
 			Signature: sig,
 		},
 	}
 
 	out := &protocol.OutReq{
-		Pubkey: pubkey,
-		Value:  1,
+		Address: cryptography.AddressHash(pubkey),
+		Value:   1,
 	}
 	outs := []*protocol.OutReq{out}
 	tx := &protocol.TransactionReq{
@@ -136,7 +137,7 @@ func equalIns(reqs []*protocol.InReq, ins []*model.In) bool {
 		return false
 	}
 	for i := 0; i < len(reqs); i++ {
-		if !bytes.Equal(reqs[i].PrevHash, ins[i].PrevHash) || !bytes.Equal(reqs[i].Signature, ins[i].Signature) || reqs[i].Index != ins[i].Index {
+		if !bytes.Equal(reqs[i].PrevHash, ins[i].PrevHash) || !bytes.Equal(reqs[i].Pubkey, ins[i].Pubkey) || !bytes.Equal(reqs[i].Signature, ins[i].Signature) || reqs[i].Index != ins[i].Index {
 			return false
 		}
 	}
@@ -149,9 +150,25 @@ func equalOuts(reqs []*protocol.OutReq, outs []*model.Out) bool {
 		return false
 	}
 	for i := 0; i < len(reqs); i++ {
-		if !bytes.Equal(reqs[i].Pubkey, outs[i].Pubkey) || reqs[i].Value != outs[i].Value {
+		if !bytes.Equal(reqs[i].Address, outs[i].Address) || reqs[i].Value != outs[i].Value {
 			return false
 		}
 	}
 	return true
 }
+
+func Test_ParseOutPoint(t *testing.T) {
+	point := model.OutPoint{Hash: []byte{0x01, 0xab}, Index: 3}
+
+	actual, err := model.ParseOutPoint(point.Key())
+	if err != nil {
+		t.Fatalf("parse out point error: %v", err)
+	}
+	if !bytes.Equal(actual.Hash, point.Hash) || actual.Index != point.Index {
+		t.Fatalf("expect out point: %s, actual: %s", point.Key(), actual.Key())
+	}
+
+	if _, err := model.ParseOutPoint("01ab"); err == nil {
+		t.Fatal("parse out point without index should fail")
+	}
+}
@@ -1,18 +1,33 @@
 package service
 
 import (
+	"Bitcoin/src/collection"
+	"Bitcoin/src/config"
+	"Bitcoin/src/cryptography"
 	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/infra"
 	"Bitcoin/src/model"
 	"Bitcoin/src/service"
 	"Bitcoin/test"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func Test_Validate_Succeed(t *testing.T) {
+	cfg := newConfig()
 	prevBlock := test.NewBlock(1, 10, nil)
-	block := test.NewBlock(2, 10, prevBlock.Hash)
+	block := newNextBlock(cfg, prevBlock)
 	blockdb := newBlockDB()
-	serv := service.NewBlockService(blockdb)
+	serv := service.NewBlockService(cfg, blockdb)
 
 	blockdb.SaveBlock(prevBlock)
 
@@ -23,11 +38,601 @@ func Test_Validate_Succeed(t *testing.T) {
 	t.Logf("Block %x validate succeed", block.Hash)
 }
 
-func newBlockDB(blocks ...*model.Block) database.IBlockDB {
-	basedb := newTestBaseDB()
-	blockdb := &database.BlockDB{IBaseDB: basedb}
-	for _, block := range blocks {
-		blockdb.SaveBlock(block)
+func Test_Validate_Bits_Mismatch(t *testing.T) {
+	cfg := newConfig()
+	prevBlock := test.NewBlock(1, 10, nil)
+	block := newNextBlock(cfg, prevBlock)
+	block.Bits = infra.MakeBits(8)
+	findHash(block)
+
+	serv := service.NewBlockService(cfg, newBlockDB(prevBlock))
+
+	err := serv.Validate(block)
+	if err != errors.ErrBlockBitsMismatch {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockBitsMismatch, err)
+	}
+}
+
+func Test_Validate_Retarget(t *testing.T) {
+	cfg := newConfig()
+	prevBlock := test.NewBlock(cfg.BlocksPerDifficulty, 10, nil)
+	prevBlock.TotalInterval = cfg.BlocksPerDifficulty * cfg.BlockInterval * 1000 * 2
+	findHash(prevBlock)
+	block := newNextBlock(cfg, prevBlock)
+
+	if block.Bits == prevBlock.Bits {
+		t.Fatalf("bits %08x should be retargeted", block.Bits)
+	}
+	if block.TotalInterval != 0 {
+		t.Fatalf("expect total interval: %d, actual: %d", 0, block.TotalInterval)
+	}
+
+	serv := service.NewBlockService(cfg, newBlockDB(prevBlock))
+	if err := serv.Validate(block); err != nil {
+		t.Fatalf("validate block failed: %v", err)
+	}
+}
+
+func Test_Validate_Interval_Mismatch(t *testing.T) {
+	cfg := newConfig()
+	prevBlock := test.NewBlock(1, 10, nil)
+	block := newNextBlock(cfg, prevBlock)
+	block.TotalInterval += 1000
+	findHash(block)
+
+	serv := service.NewBlockService(cfg, newBlockDB(prevBlock))
+
+	err := serv.Validate(block)
+	if err != errors.ErrBlockIntervalMismatch {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockIntervalMismatch, err)
+	}
+}
+
+func Test_GetReward(t *testing.T) {
+	cfg := newConfig()
+	prevBlock := test.NewBlock(cfg.BlocksPerRewrad, 10, nil)
+	block := newNextBlock(cfg, prevBlock)
+
+	serv := service.NewBlockService(cfg, newBlockDB(prevBlock))
+
+	// the reward halves after the block of number BlocksPerRewrad
+	reward, err := serv.GetReward(block)
+	if err != nil {
+		t.Fatalf("get reward failed: %v", err)
+	}
+	if reward != cfg.InitRewrad/2 {
+		t.Fatalf("expect reward: %d, actual: %d", cfg.InitRewrad/2, reward)
+	}
+}
+
+func Test_GetBlockByNumber(t *testing.T) {
+	cfg := newConfig()
+	blocks := []*model.Block{test.NewBlock(1, 10, nil)}
+	for i := 1; i < 4; i++ {
+		blocks = append(blocks, newNextBlock(cfg, blocks[i-1]))
+	}
+	// the fork makes the number of blocks[2] not unique in the index
+	fork := newNextBlock(cfg, blocks[1])
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, fork)...))
+
+	for _, expect := range blocks {
+		block, err := serv.GetBlockByNumber(blocks[len(blocks)-1].Hash, expect.Number, false)
+		if err != nil {
+			t.Fatalf("get block %d failed: %v", expect.Number, err)
+		}
+		if !bytes.Equal(block.Hash, expect.Hash) {
+			t.Fatalf("expect block %d: %x, actual: %x", expect.Number, expect.Hash, block.Hash)
+		}
+	}
+
+	block, err := serv.GetBlockByNumber(fork.Hash, fork.Number, true)
+	if err != nil || !bytes.Equal(block.Hash, fork.Hash) {
+		t.Fatalf("expect block %d of the fork: %x, actual: %v, error: %v", fork.Number, fork.Hash, block, err)
+	}
+
+	_, err = serv.GetBlockByNumber(blocks[len(blocks)-1].Hash, 5, false)
+	if err != errors.ErrBlockNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockNotFound, err)
+	}
+}
+
+func Test_ValidateHeader_Succeed(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 3)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks[0]))
+
+	// the parent is only a header, the body is not needed
+	if err := serv.AddHeader(blocks[1]); err != nil {
+		t.Fatalf("add header failed: %v", err)
+	}
+	if err := serv.ValidateHeader(blocks[2]); err != nil {
+		t.Fatalf("validate header failed: %v", err)
+	}
+
+	if err := serv.ValidateHeader(blocks[1]); err != errors.ErrBlockExist {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockExist, err)
+	}
+	if block, _ := serv.GetBlock(blocks[1].Hash, false); block != nil {
+		t.Fatalf("the block of the header should not be saved")
+	}
+}
+
+func Test_ValidateHeader_Prev_Not_Found(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 3)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks[0]))
+
+	if err := serv.ValidateHeader(blocks[2]); err != errors.ErrPrevBlockNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrPrevBlockNotFound, err)
+	}
+}
+
+func Test_GetLocator(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 30)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks...))
+
+	locator, err := serv.GetLocator(blocks[len(blocks)-1].Hash)
+	if err != nil {
+		t.Fatalf("get locator failed: %v", err)
+	}
+
+	// 30, 29, ..., 21, then 19, 15, 7 with the doubled steps, and the genesis block
+	expects := []uint64{30, 29, 28, 27, 26, 25, 24, 23, 22, 21, 19, 15, 7, 1}
+	if len(locator) != len(expects) {
+		t.Fatalf("expect locator len: %d, actual: %d", len(expects), len(locator))
+	}
+	for i, number := range expects {
+		if !bytes.Equal(locator[i], blocks[number-1].Hash) {
+			t.Fatalf("expect locator %d is block %d", i, number)
+		}
+	}
+}
+
+func Test_GetHeaders(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 10)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks...))
+	tip := blocks[len(blocks)-1].Hash
+
+	// the first block of the locator on the chain is the fork point
+	locator := [][]byte{[]byte("unknown"), blocks[3].Hash, blocks[0].Hash}
+	headers, err := serv.GetHeaders(tip, locator, nil)
+	if err != nil {
+		t.Fatalf("get headers failed: %v", err)
+	}
+	if len(headers) != 6 {
+		t.Fatalf("expect headers: %d, actual: %d", 6, len(headers))
+	}
+	for i, header := range headers {
+		if !bytes.Equal(header.Hash, blocks[i+4].Hash) || header.Body != nil {
+			t.Fatalf("expect header %d is block %d", i, i+5)
+		}
+	}
+
+	headers, err = serv.GetHeaders(tip, [][]byte{[]byte("unknown")}, nil)
+	if err != nil || headers != nil {
+		t.Fatalf("no header should be returned without a known block of the locator, error: %v", err)
+	}
+}
+
+func Test_GetHeaders_Fork(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 6)
+	fork := newNextBlock(cfg, blocks[1])
+	branch := []*model.Block{fork, newNextBlock(cfg, fork)}
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, branch...)...))
+
+	// the locator of the branch forks from the main chain at blocks[1]
+	headers, err := serv.GetHeaders(blocks[5].Hash, [][]byte{branch[1].Hash, branch[0].Hash, blocks[1].Hash, blocks[0].Hash}, nil)
+	if err != nil {
+		t.Fatalf("get headers failed: %v", err)
+	}
+	if len(headers) != 4 {
+		t.Fatalf("expect headers: %d, actual: %d", 4, len(headers))
+	}
+	for i, header := range headers {
+		if !bytes.Equal(header.Hash, blocks[i+2].Hash) {
+			t.Fatalf("expect header %d is block %d", i, i+3)
+		}
+	}
+
+	// the headers of the branch for the locator of the main chain
+	headers, err = serv.GetHeaders(branch[1].Hash, [][]byte{blocks[5].Hash, blocks[3].Hash, blocks[1].Hash}, nil)
+	if err != nil {
+		t.Fatalf("get headers failed: %v", err)
+	}
+	if len(headers) != 2 || !bytes.Equal(headers[0].Hash, branch[0].Hash) || !bytes.Equal(headers[1].Hash, branch[1].Hash) {
+		t.Fatalf("expect the headers of the branch, actual: %d headers", len(headers))
+	}
+}
+
+func Test_GetBlocks(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 5)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks...))
+
+	actual, end, err := serv.GetBlocks(blocks[4].Hash, [][]byte{blocks[1].Hash}, nil)
+	if err != nil {
+		t.Fatalf("get blocks failed: %v", err)
+	}
+	if end != 5 || len(actual) != 3 {
+		t.Fatalf("expect blocks: %d, end: %d, actual blocks: %d, end: %d", 3, 5, len(actual), end)
+	}
+	for i, block := range actual {
+		if !bytes.Equal(block.Hash, blocks[i+2].Hash) || len(block.GetTxs()) == 0 {
+			t.Fatalf("expect block %d with the body", i+3)
+		}
+	}
+}
+
+func Test_GetHeaders_Max(t *testing.T) {
+	cfg := newConfig()
+	blockdb := newBlockDB()
+
+	// the headers are not mined, only their links are walked
+	headers := make([]*model.Block, 0)
+	var prevhash []byte
+	for i := 1; i <= 2*service.MaxHeadersPerGetHeadersReq+10; i++ {
+		header := &model.Block{Number: uint64(i), Prevhash: prevhash, Hash: []byte(fmt.Sprintf("header%d", i))}
+		if err := blockdb.SaveHeader(header); err != nil {
+			t.Fatalf("save header error: %v", err)
+		}
+		headers = append(headers, header)
+		prevhash = header.Hash
+	}
+	tip := headers[len(headers)-1]
+	serv := service.NewBlockService(cfg, blockdb)
+
+	// only the first headers after the fork point are returned however far the tip is
+	actual, err := serv.GetHeaders(tip.Hash, [][]byte{[]byte("unknown"), headers[0].Hash}, nil)
+	if err != nil {
+		t.Fatalf("get headers failed: %v", err)
+	}
+	if len(actual) != service.MaxHeadersPerGetHeadersReq {
+		t.Fatalf("expect headers: %d, actual: %d", service.MaxHeadersPerGetHeadersReq, len(actual))
+	}
+	for i, header := range actual {
+		if !bytes.Equal(header.Hash, headers[i+1].Hash) {
+			t.Fatalf("expect header %d: %s, actual: %s", headers[i+1].Number, headers[i+1].Hash, header.Hash)
+		}
+	}
+}
+
+func Test_BranchWork(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 3)
+	fork := newNextBlock(cfg, blocks[1])
+	branch := []*model.Block{fork, newNextBlock(cfg, fork)}
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, branch...)...))
+
+	work, otherWork, err := serv.BranchWork(branch[1].Hash, blocks[2].Hash)
+	if err != nil {
+		t.Fatalf("branch work failed: %v", err)
+	}
+
+	blockWork := infra.Work(blocks[2].Bits)
+	if work.Cmp(new(big.Int).Mul(blockWork, big.NewInt(2))) != 0 || otherWork.Cmp(blockWork) != 0 {
+		t.Fatalf("expect work: %v and %v, actual: %v and %v", 2, 1, work, otherWork)
+	}
+}
+
+func Test_MissingBodies(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 4)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks[:2]...))
+	for _, block := range blocks[2:] {
+		if err := serv.AddHeader(block); err != nil {
+			t.Fatalf("add header failed: %v", err)
+		}
+	}
+
+	hashes, err := serv.MissingBodies(blocks[3].Hash)
+	if err != nil {
+		t.Fatalf("missing bodies failed: %v", err)
+	}
+	if len(hashes) != 2 || !bytes.Equal(hashes[0], blocks[2].Hash) || !bytes.Equal(hashes[1], blocks[3].Hash) {
+		t.Fatalf("the headers without body should be returned from the oldest")
+	}
+}
+
+func Test_TryAddGenesis_Save(t *testing.T) {
+	path := writeGenesis(t)
+	blockdb := newBlockDB()
+	serv := service.NewBlockService(newConfig(), blockdb)
+
+	genesis, err := serv.TryAddGenesis(path)
+	if err != nil {
+		t.Fatalf("add genesis failed: %v", err)
+	}
+
+	block, err := serv.GetBlock(genesis.Hash, false)
+	if err != nil || block == nil {
+		t.Fatalf("genesis block %x should be saved: %v", genesis.Hash, err)
+	}
+
+	if _, err := serv.TryAddGenesis(path); err != nil {
+		t.Fatalf("add the same genesis again failed: %v", err)
+	}
+}
+
+func Test_TryAddGenesis_Mismatch(t *testing.T) {
+	blockdb := newBlockDB()
+	serv := service.NewBlockService(newConfig(), blockdb)
+
+	if _, err := serv.TryAddGenesis(writeGenesis(t)); err != nil {
+		t.Fatalf("add genesis failed: %v", err)
+	}
+
+	_, err := serv.TryAddGenesis(writeGenesis(t))
+	if err != errors.ErrGenesisMismatch {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrGenesisMismatch, err)
+	}
+}
+
+func writeGenesis(t *testing.T) string {
+	_, pubkey := test.NewKeys()
+	outs := []*model.Out{{Address: cryptography.AddressHash(pubkey), Value: 50}}
+
+	genesis, err := model.MineGenesis(context.Background(), outs, 4, time.Now())
+	if err != nil {
+		t.Fatalf("mine genesis error: %v", err)
+	}
+
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatalf("marshal genesis error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write genesis error: %v", err)
+	}
+	return path
+}
+
+func Test_MarkInvalid(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 4)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks[:3]...))
+
+	if err := serv.MarkInvalid(blocks[1]); err != nil {
+		t.Fatalf("mark invalid failed: %v", err)
+	}
+
+	index, err := serv.GetIndex(blocks[2].Hash)
+	if err != nil || index.Status != model.BlockFailedChild {
+		t.Fatalf("the descendant should be failed, error: %v", err)
+	}
+	if err := serv.ValidateHeader(blocks[3]); err != errors.ErrBlockInvalid {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockInvalid, err)
+	}
+	if err := serv.Validate(blocks[1]); err != errors.ErrBlockInvalid {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockInvalid, err)
+	}
+}
+
+func Test_ChainTips(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 3)
+	fork := newNextBlock(cfg, blocks[0])
+	header := newNextBlock(cfg, blocks[2])
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, fork)...))
+	if err := serv.AddHeader(header); err != nil {
+		t.Fatalf("add header failed: %v", err)
+	}
+
+	// the header without the body is not a chain to apply
+	chains, err := serv.ChainTips()
+	if err != nil {
+		t.Fatalf("chain tips failed: %v", err)
+	}
+	if len(chains) != 2 {
+		t.Fatalf("expect chains: %d, actual: %d", 2, len(chains))
+	}
+	for _, chain := range chains {
+		if !bytes.Equal(chain.LastBlockHash, blocks[2].Hash) && !bytes.Equal(chain.LastBlockHash, fork.Hash) {
+			t.Fatalf("unexpected chain tip: %x", chain.LastBlockHash)
+		}
+	}
+
+	tips, err := serv.ListChainTips(blocks[2].Hash)
+	if err != nil {
+		t.Fatalf("list chain tips failed: %v", err)
+	}
+	expects := []struct {
+		hash      []byte
+		status    string
+		branchLen uint64
+	}{
+		{header.Hash, "headers-only", 1},
+		{blocks[2].Hash, "active", 0},
+		{fork.Hash, "valid-fork", 1},
+	}
+	if len(tips) != len(expects) {
+		t.Fatalf("expect tips: %d, actual: %d", len(expects), len(tips))
+	}
+	for i, expect := range expects {
+		if !bytes.Equal(tips[i].Hash, expect.hash) || tips[i].Status != expect.status || tips[i].BranchLen != expect.branchLen {
+			t.Fatalf("expect tip %d: %s with branch %d, actual: %s with branch %d", i, expect.status, expect.branchLen, tips[i].Status, tips[i].BranchLen)
+		}
+	}
+}
+
+func Test_VerifyBlock(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 2)
+	block := newCoinbaseBlock(cfg, blocks[1])
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, block)...))
+
+	stored, err := serv.GetBlock(block.Hash, true)
+	if err != nil {
+		t.Fatalf("get block failed: %v", err)
+	}
+	if err := serv.VerifyBlock(stored); err != nil {
+		t.Fatalf("verify block failed: %v", err)
+	}
+
+	// the transactions of the block spend the unknown outputs, so it has no undo
+	stored, err = serv.GetBlock(blocks[1].Hash, true)
+	if err != nil {
+		t.Fatalf("get block failed: %v", err)
 	}
-	return blockdb
+	if err := serv.VerifyBlock(stored); err != errors.ErrBlockUndoNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockUndoNotFound, err)
+	}
+
+	stored.Nonce++
+	if err := serv.VerifyBlock(stored); err != errors.ErrIdentityHashInvalid {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrIdentityHashInvalid, err)
+	}
+}
+
+func Test_Prune(t *testing.T) {
+	cfg := newConfig()
+	cfg.PruneDepth = 2
+	blocks := newChain(cfg, 5)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks...))
+
+	// the first transaction of the block 2 has the unspent output
+	kept := blocks[1].GetTxs()[0]
+	unspent := func(point model.OutPoint) bool {
+		return bytes.Equal(point.Hash, kept.Hash)
+	}
+	if err := serv.Prune(blocks[4].Hash, unspent); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+
+	pruned, err := serv.GetPruned()
+	if err != nil || pruned != 3 {
+		t.Fatalf("expect pruned: %d, actual: %d, error: %v", 3, pruned, err)
+	}
+	if _, err := serv.GetBlock(blocks[2].Hash, true); err != errors.ErrBlockPruned {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockPruned, err)
+	}
+	if header, err := serv.GetHeader(blocks[2].Hash); header == nil || err != nil {
+		t.Fatalf("the header of the pruned block should be kept, error: %v", err)
+	}
+	if block, err := serv.GetBlock(blocks[3].Hash, true); block == nil || err != nil {
+		t.Fatalf("the block within the depth should be kept, error: %v", err)
+	}
+
+	if tx, _ := serv.GetTx(kept.Hash); tx == nil {
+		t.Fatalf("the transaction with the unspent output should be kept")
+	}
+	if tx, _ := serv.GetTx(blocks[1].GetTxs()[1].Hash); tx != nil {
+		t.Fatalf("the spent transaction should be pruned")
+	}
+
+	if _, _, err := serv.GetBlocks(blocks[4].Hash, [][]byte{blocks[0].Hash}, nil); err != errors.ErrBlockPruned {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockPruned, err)
+	}
+}
+
+func Test_Prune_Under_Size(t *testing.T) {
+	cfg := newConfig()
+	cfg.PruneDepth = 2
+	cfg.PruneSize = 1
+	blocks := newChain(cfg, 5)
+	serv := service.NewBlockService(cfg, newBlockDB(blocks...))
+
+	unspent := func(point model.OutPoint) bool { return false }
+	if err := serv.Prune(blocks[4].Hash, unspent); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if block, err := serv.GetBlock(blocks[1].Hash, true); block == nil || err != nil {
+		t.Fatalf("no block should be pruned under the size, error: %v", err)
+	}
+}
+
+func Test_Prune_From_Pruned(t *testing.T) {
+	cfg := newConfig()
+	cfg.PruneDepth = 2
+	blocks := newChain(cfg, 5)
+	// the block of the side chain at the number of blocks[1]
+	side := newNextBlock(cfg, blocks[0])
+	serv := service.NewBlockService(cfg, newBlockDB(append(blocks, side)...))
+
+	unspent := func(point model.OutPoint) bool { return false }
+	if err := serv.Prune(blocks[3].Hash, unspent); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if _, err := serv.GetBlock(side.Hash, true); err != errors.ErrBlockPruned {
+		t.Fatalf("the block of the side chain should be pruned, expect error: %v, actual: %v", errors.ErrBlockPruned, err)
+	}
+	if block, err := serv.GetBlock(blocks[2].Hash, true); block == nil || err != nil {
+		t.Fatalf("the block within the depth should be kept, error: %v", err)
+	}
+
+	// the next prune continues from the number pruned to
+	if err := serv.Prune(blocks[4].Hash, unspent); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	pruned, err := serv.GetPruned()
+	if err != nil || pruned != blocks[2].Number {
+		t.Fatalf("expect pruned: %d, actual: %d, error: %v", blocks[2].Number, pruned, err)
+	}
+	if _, err := serv.GetBlock(blocks[2].Hash, true); err != errors.ErrBlockPruned {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockPruned, err)
+	}
+	if block, err := serv.GetBlock(blocks[3].Hash, true); block == nil || err != nil {
+		t.Fatalf("the block within the depth should be kept, error: %v", err)
+	}
+}
+
+func newConfig() *config.Config {
+	return &config.Config{
+		BlocksPerDifficulty: 10,
+		BlocksPerRewrad:     100,
+		InitRewrad:          50,
+		BlockInterval:       60,
+	}
+}
+
+// newNextBlock makes a block on top of prevBlock with the expected bits and total interval
+func newNextBlock(cfg *config.Config, prevBlock *model.Block) *model.Block {
+	block := test.NewBlock(prevBlock.Number+1, 10, prevBlock.Hash)
+	block.Bits = prevBlock.GetNextBits(cfg.BlocksPerDifficulty, cfg.BlockInterval)
+	block.TotalInterval = prevBlock.GetNextTotalInterval(block.Time, cfg.BlocksPerDifficulty)
+	findHash(block)
+	return block
+}
+
+// newCoinbaseBlock makes a block with only the coinbase on top of prevBlock
+func newCoinbaseBlock(cfg *config.Config, prevBlock *model.Block) *model.Block {
+	_, pubkey := test.NewKeys()
+	tree, err := collection.BuildTree([]*model.Transaction{newCoinbaseTx(pubkey, cfg.InitRewrad)})
+	if err != nil {
+		log.Fatalf("build merkle tree error: %v", err)
+	}
+
+	block := newNextBlock(cfg, prevBlock)
+	block.Body = tree
+	block.RootHash = tree.Table[len(tree.Table)-1][0].Hash
+	findHash(block)
+	return block
+}
+
+// newChain makes n blocks on top of each other from the block of number 1
+func newChain(cfg *config.Config, n int) []*model.Block {
+	blocks := []*model.Block{test.NewBlock(1, 10, nil)}
+	for i := 1; i < n; i++ {
+		blocks = append(blocks, newNextBlock(cfg, blocks[i-1]))
+	}
+	return blocks
+}
+
+func findHash(block *model.Block) {
+	hash, err := block.FindHash(context.TODO())
+	if err != nil {
+		log.Fatalf("find block hash error: %s", err)
+	}
+	block.Hash = hash
+	for _, tx := range block.GetTxs() {
+		tx.BlockHash = hash
+	}
+}
+
+func newBlockDB(blocks ...*model.Block) database.IBlockDB {
+	return test.NewTestBlockDB(blocks...)
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"Bitcoin/src/infra"
+	"Bitcoin/src/model"
+	"Bitcoin/src/service"
+	"Bitcoin/test"
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func Test_ApplyChain_More_Work_Wins(t *testing.T) {
+	chainService := service.NewChainService(test.NewTestBlockDB(), map[string]*model.Out{})
+	genesis := newChainIndex("genesis", nil, 8, 1)
+	chainService.ApplyChain(genesis)
+
+	// the long chain of the easy blocks
+	prev := genesis
+	for i := 0; i < 4; i++ {
+		prev = newChainIndex(fmt.Sprintf("easy%d", i), prev, 1, prev.Seq+1)
+		chainService.ApplyChain(prev)
+	}
+
+	// the short chain of the hard block wins with more work
+	hard := newChainIndex("hard", genesis, 8, prev.Seq+1)
+	applyChain, rollbackChain := chainService.ApplyChain(hard)
+	if applyChain == nil || rollbackChain == nil {
+		t.Fatalf("the chain with more work should be the new main chain")
+	}
+
+	mainChain := chainService.GetMainChain()
+	if !bytes.Equal(mainChain.LastBlockHash, hard.Hash) || mainChain.Length != 2 {
+		t.Fatalf("expect main chain: %x, actual: %x", hard.Hash, mainChain.LastBlockHash)
+	}
+	if mainChain.Work.Cmp(hard.Work) != 0 {
+		t.Fatalf("expect work: %v, actual: %v", hard.Work, mainChain.Work)
+	}
+
+	chains := chainService.GetChains()
+	if len(chains) != 2 || chains[0] != mainChain {
+		t.Fatalf("the main chain should be the first of the chains")
+	}
+}
+
+func Test_ApplyChain_Same_Work_First_Seen(t *testing.T) {
+	chainService := service.NewChainService(test.NewTestBlockDB(), map[string]*model.Out{})
+	genesis := newChainIndex("genesis", nil, 8, 1)
+	chainService.ApplyChain(genesis)
+
+	first := newChainIndex("first", genesis, 8, 2)
+	second := newChainIndex("second", genesis, 8, 3)
+	chainService.ApplyChain(first)
+	if applyChain, _ := chainService.ApplyChain(second); applyChain != nil {
+		t.Fatalf("the chain seen later with the same work should be a side chain")
+	}
+
+	if !bytes.Equal(chainService.GetMainChain().LastBlockHash, first.Hash) {
+		t.Fatalf("the chain seen first should be the main chain")
+	}
+}
+
+func Test_SetChains(t *testing.T) {
+	chainService := service.NewChainService(test.NewTestBlockDB(), map[string]*model.Out{})
+	genesis := newChainIndex("genesis", nil, 8, 1)
+	first := newChainIndex("first", genesis, 8, 3)
+	second := newChainIndex("second", genesis, 8, 2)
+
+	chainService.SetChains([]*model.Chain{
+		{LastBlockHash: first.Hash, Length: first.Number, Work: first.Work, Seen: first.Seq},
+		{LastBlockHash: second.Hash, Length: second.Number, Work: second.Work, Seen: second.Seq},
+	})
+	if !bytes.Equal(chainService.GetMainChain().LastBlockHash, second.Hash) {
+		t.Fatalf("the chain seen first should be the main chain")
+	}
+
+	// the block extends the tip set before
+	third := newChainIndex("third", first, 8, 4)
+	if applyChain, rollbackChain := chainService.ApplyChain(third); applyChain == nil || rollbackChain == nil {
+		t.Fatalf("the longer chain should be the new main chain")
+	}
+	if chainService.ChainLen() != 2 {
+		t.Fatalf("expect chains: %d, actual: %d", 2, chainService.ChainLen())
+	}
+}
+
+// newChainIndex makes an index with only the fields used by the chain service
+func newChainIndex(name string, prev *model.BlockIndex, difficultyLevel uint64, seq uint64) *model.BlockIndex {
+	index := &model.BlockIndex{Hash: []byte(name), Number: 1, Work: infra.Work(infra.MakeBits(difficultyLevel)), Seq: seq}
+	if prev != nil {
+		index.Prevhash = prev.Hash
+		index.Number = prev.Number + 1
+		index.Work = new(big.Int).Add(prev.Work, index.Work)
+	}
+	return index
+}
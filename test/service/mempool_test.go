@@ -1,8 +1,11 @@
 package service
 
 import (
+	"Bitcoin/src/model"
 	"Bitcoin/src/service"
+	"Bitcoin/test"
 	"testing"
+	"time"
 )
 
 func Test_Load(t *testing.T) {
@@ -12,3 +15,30 @@ func Test_Load(t *testing.T) {
 		t.Fatalf("load mempool from %v error: %v", dir, err)
 	}
 }
+
+func Test_Conflict(t *testing.T) {
+	prevTx, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
+	_, pubkey := test.NewKeys()
+
+	other := &model.Transaction{
+		Ins:       tx.Ins,
+		Outs:      newOuts(pubkey, 9),
+		Timestamp: prevTx.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(other)
+
+	mempool := service.NewMemPool(10)
+	mempool.Put(tx)
+
+	if mempool.Conflict(tx) {
+		t.Fatalf("transaction %x should not conflict with itself", tx.Hash)
+	}
+	if !mempool.Conflict(other) {
+		t.Fatalf("transaction %x should conflict with %x", other.Hash, tx.Hash)
+	}
+
+	mempool.Remove([]*model.Transaction{tx})
+	if mempool.Conflict(other) {
+		t.Fatalf("transaction %x should not conflict after %x removed", other.Hash, tx.Hash)
+	}
+}
@@ -2,19 +2,21 @@ package service
 
 import (
 	"Bitcoin/src/bitcoin/client"
+	bcerrors "Bitcoin/src/errors"
 	"Bitcoin/src/model"
 	"Bitcoin/src/protocol"
 	"Bitcoin/src/service"
 	"Bitcoin/test"
+	"bytes"
 	"errors"
 	"fmt"
 	"testing"
 )
 
-func Test_SendTx_Check_Nodes(t *testing.T) {
-	channels := make(map[string]TxChannel)
-	makeclient := func(channel TxChannel) client.IBitcoinClient {
-		return &TestBitcoinClient{txChannel: channel}
+func Test_AnnounceTx_Check_Nodes(t *testing.T) {
+	channels := make(map[string]InvChannel)
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
 	}
 	nodes := generateNodes(service.MaxBroadcastNodes+5, 5001, channels, makeclient)
 
@@ -22,28 +24,89 @@ func Test_SendTx_Check_Nodes(t *testing.T) {
 	endpoint := "localhost:5000"
 	serv := service.NewNodeService(endpoint, nil)
 	serv.AddNodes(nodes...)
-	serv.SendTx(tx)
+	serv.AnnounceTx(tx)
 
-	n, err := checkNodes(channels, endpoint)
+	n, err := checkNodes(channels, endpoint, tx.Hash)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
 	if n != len(channels) {
 		t.Fatalf("expect receive %d request, actual: %d", len(channels), n)
 	}
+	if serv.MarkSeen(tx.Hash) {
+		t.Fatalf("the announced transaction should be seen")
+	}
+
+	// each transaction is announced to a node at most once
+	serv.AnnounceTx(tx)
+	n, err = checkNodes(channels, endpoint, tx.Hash)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n != 0 {
+		t.Fatalf("expect receive %d request, actual: %d", 0, n)
+	}
+}
+
+func Test_AnnounceTx_Skip_Known_Nodes(t *testing.T) {
+	channels := make(map[string]InvChannel)
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
+	}
+	nodes := generateNodes(5, 5001, channels, makeclient)
+
+	tx := test.NewTransaction([]byte{})
+	endpoint := "localhost:5000"
+	serv := service.NewNodeService(endpoint, nil)
+	serv.AddNodes(nodes...)
+	// the node announced the transaction to us
+	serv.MarkKnown(nodes[0].Addr, tx.Hash)
+	serv.AnnounceTx(tx)
+
+	n, err := checkNodes(channels, endpoint, tx.Hash)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n != len(channels)-1 {
+		t.Fatalf("expect receive %d request, actual: %d", len(channels)-1, n)
+	}
+}
+
+func Test_AnnounceTx_Skip_Not_Relay_Nodes(t *testing.T) {
+	channels := make(map[string]InvChannel)
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
+	}
+	nodes := generateNodes(5, 5001, channels, makeclient)
+	nodes[0].Features = []string{model.FeatureQuery}
+	nodes[1].Handshaked = false
+
+	tx := test.NewTransaction([]byte{})
+	endpoint := "localhost:5000"
+	serv := service.NewNodeService(endpoint, nil)
+	serv.AddNodes(nodes...)
+	serv.AnnounceTx(tx)
+
+	n, err := checkNodes(channels, endpoint, tx.Hash)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n != len(channels)-2 {
+		t.Fatalf("expect receive %d request, actual: %d", len(channels)-2, n)
+	}
 }
 
-func Test_SendTx_Not_Remove_Failed_Not_Enough_Nodes(t *testing.T) {
-	makeclient := func(channel TxChannel) client.IBitcoinClient {
-		return &TestBitcoinClient{txChannel: channel}
+func Test_AnnounceTx_Not_Remove_Failed_Not_Enough_Nodes(t *testing.T) {
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
 	}
-	makeFailedClient := func(channel TxChannel) client.IBitcoinClient {
-		return &FailedBitcoinClient{txChannel: channel}
+	makeFailedClient := func(channel InvChannel) client.IBitcoinClient {
+		return &FailedBitcoinClient{invChannel: channel}
 	}
 
 	sendCounts := []int{model.MaxFailedCount - 2, model.MaxFailedCount - 1, model.MaxFailedCount - 5}
 	for _, sendCount := range sendCounts {
-		channels := make(map[string]TxChannel)
+		channels := make(map[string]InvChannel)
 		nodes := generateNodes(service.MaxBroadcastNodes+5, 5001, channels, makeclient)
 		failNodes := generateNodes(2, 6001, channels, makeFailedClient)
 
@@ -52,11 +115,11 @@ func Test_SendTx_Not_Remove_Failed_Not_Enough_Nodes(t *testing.T) {
 		serv.AddNodes(nodes...)
 		serv.AddNodes(failNodes...)
 
-		tx := test.NewTransaction([]byte{})
 		for i := 0; i < sendCount; i++ {
-			serv.SendTx(tx)
+			tx := test.NewTransaction([]byte{})
+			serv.AnnounceTx(tx)
 
-			n, err := checkNodes(channels, endpoint)
+			n, err := checkNodes(channels, endpoint, tx.Hash)
 			if err != nil {
 				t.Fatal(err.Error())
 			}
@@ -74,17 +137,17 @@ func Test_SendTx_Not_Remove_Failed_Not_Enough_Nodes(t *testing.T) {
 	}
 }
 
-func Test_SendTx_Remove_Failed_Enough_Nodes(t *testing.T) {
-	makeclient := func(channel TxChannel) client.IBitcoinClient {
-		return &TestBitcoinClient{txChannel: channel}
+func Test_AnnounceTx_Remove_Failed_Enough_Nodes(t *testing.T) {
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
 	}
-	makeFailedClient := func(channel TxChannel) client.IBitcoinClient {
-		return &FailedBitcoinClient{txChannel: channel}
+	makeFailedClient := func(channel InvChannel) client.IBitcoinClient {
+		return &FailedBitcoinClient{invChannel: channel}
 	}
 
 	sendCounts := []int{model.MaxFailedCount, model.MaxFailedCount + 1, model.MaxFailedCount + 10}
 	for _, sendCount := range sendCounts {
-		channels := make(map[string]TxChannel)
+		channels := make(map[string]InvChannel)
 		nodes := generateNodes(service.MaxBroadcastNodes+5, 5001, channels, makeclient)
 		failNodes := generateNodes(2, 6001, channels, makeFailedClient)
 
@@ -93,18 +156,22 @@ func Test_SendTx_Remove_Failed_Enough_Nodes(t *testing.T) {
 		serv.AddNodes(nodes...)
 		serv.AddNodes(failNodes...)
 
+		// the failed nodes don't know the transaction, so it's announced to them again
 		tx := test.NewTransaction([]byte{})
 		for i := 0; i < sendCount; i++ {
-			serv.SendTx(tx)
+			serv.AnnounceTx(tx)
 
-			n, err := checkNodes(channels, endpoint)
+			n, err := checkNodes(channels, endpoint, tx.Hash)
 			if err != nil {
 				t.Fatal(err.Error())
 			}
 
 			expect := len(channels)
+			if i > 0 {
+				expect = len(failNodes)
+			}
 			if i >= model.MaxFailedCount {
-				expect = len(channels) - len(failNodes)
+				expect = 0
 			}
 
 			if n != expect {
@@ -121,15 +188,15 @@ func Test_SendTx_Remove_Failed_Enough_Nodes(t *testing.T) {
 	}
 }
 
-func Test_SendTx_Not_Remove_Rarely_Failed_Nodes(t *testing.T) {
-	makeclient := func(channel TxChannel) client.IBitcoinClient {
-		return &TestBitcoinClient{txChannel: channel}
+func Test_AnnounceTx_Not_Remove_Rarely_Failed_Nodes(t *testing.T) {
+	makeclient := func(channel InvChannel) client.IBitcoinClient {
+		return &TestBitcoinClient{invChannel: channel}
 	}
-	makeProbablyFailedClient := func(channel TxChannel) client.IBitcoinClient {
-		return &ProbablyFailedBitcoinClient{txChannel: channel, m: 0, n: 2}
+	makeProbablyFailedClient := func(channel InvChannel) client.IBitcoinClient {
+		return &ProbablyFailedBitcoinClient{invChannel: channel, m: 0, n: 2}
 	}
 
-	channels := make(map[string]TxChannel)
+	channels := make(map[string]InvChannel)
 	nodes := generateNodes(service.MaxBroadcastNodes+5, 5001, channels, makeclient)
 	probablyFailNodes := generateNodes(2, 6001, channels, makeProbablyFailedClient)
 
@@ -138,11 +205,11 @@ func Test_SendTx_Not_Remove_Rarely_Failed_Nodes(t *testing.T) {
 	serv.AddNodes(nodes...)
 	serv.AddNodes(probablyFailNodes...)
 
-	tx := test.NewTransaction([]byte{})
 	for i := 0; i < model.MaxFailedCount*2; i++ {
-		serv.SendTx(tx)
+		tx := test.NewTransaction([]byte{})
+		serv.AnnounceTx(tx)
 
-		_, err := checkNodes(channels, endpoint)
+		_, err := checkNodes(channels, endpoint, tx.Hash)
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -218,35 +285,137 @@ func Test_Add_Invalid_Addrs(t *testing.T) {
 	}
 }
 
-type TxChannel chan *protocol.TransactionReq
-type MakeClient func(TxChannel) client.IBitcoinClient
+func Test_Handshake_Accept(t *testing.T) {
+	genesis := []byte("genesis")
+	serv := service.NewNodeService("localhost:5000", nil)
+	serv.SetIdentity("main", genesis, func() uint64 { return 3 })
+
+	reply := &protocol.HandshakeReply{Version: model.ProtocolVersion, Network: "main", Genesis: genesis, Height: 5, Features: model.Features}
+	serv.AddNodes(&model.Node{Addr: "localhost:5001", Client: &HandshakeBitcoinClient{reply: reply}})
+
+	if err := serv.Handshake("localhost:5001"); err != nil {
+		t.Fatalf("handshake error: %v", err)
+	}
+
+	node := serv.GetNode("localhost:5001")
+	if !node.Handshaked || node.Height != 5 || !node.HasFeature(model.FeatureRelay) {
+		t.Fatalf("the handshake of node %v should be stored", node.Addr)
+	}
+	if best := serv.BestNode(3); best != node {
+		t.Fatalf("node %v should be the best node to sync from", node.Addr)
+	}
+	if best := serv.BestNode(5); best != nil {
+		t.Fatalf("no node should be higher than %d", 5)
+	}
+}
+
+func Test_Handshake_Refuse(t *testing.T) {
+	genesis := []byte("genesis")
+	replies := map[error]*protocol.HandshakeReply{
+		bcerrors.ErrPeerNetworkMismatch: {Version: model.ProtocolVersion, Network: "test", Genesis: genesis},
+		bcerrors.ErrPeerGenesisMismatch: {Version: model.ProtocolVersion, Network: "main", Genesis: []byte("other")},
+		bcerrors.ErrPeerVersionTooOld:   {Version: model.MinProtocolVersion - 1, Network: "main", Genesis: genesis},
+	}
+
+	for expect, reply := range replies {
+		serv := service.NewNodeService("localhost:5000", nil)
+		serv.SetIdentity("main", genesis, func() uint64 { return 0 })
+		serv.AddNodes(&model.Node{Addr: "localhost:5001", Client: &HandshakeBitcoinClient{reply: reply}})
+
+		if err := serv.Handshake("localhost:5001"); err != expect {
+			t.Fatalf("expect error: %v, actual: %v", expect, err)
+		}
+		if serv.GetNode("localhost:5001") != nil {
+			t.Fatalf("the refused node should be removed")
+		}
+	}
+}
+
+func Test_AcceptHandshake(t *testing.T) {
+	genesis := []byte("genesis")
+	serv := service.NewNodeService("localhost:5000", nil)
+	serv.SetIdentity("main", genesis, func() uint64 { return 3 })
+
+	handshake := &model.Handshake{Version: model.ProtocolVersion, Network: "main", Genesis: genesis, Height: 7, Addr: "localhost:5001"}
+	local, err := serv.AcceptHandshake(handshake)
+	if err != nil {
+		t.Fatalf("accept handshake error: %v", err)
+	}
+	if local.Height != 3 || local.Network != "main" || local.Addr != "localhost:5000" {
+		t.Fatalf("the local handshake mismatch: %+v", local)
+	}
+
+	node := serv.GetNode("localhost:5001")
+	if node == nil || !node.Handshaked || node.Height != 7 {
+		t.Fatalf("the node of the accepted handshake should be added")
+	}
+
+	handshake = &model.Handshake{Version: model.ProtocolVersion, Network: "main", Genesis: []byte("other"), Addr: "localhost:5002"}
+	if _, err := serv.AcceptHandshake(handshake); err != bcerrors.ErrPeerGenesisMismatch {
+		t.Fatalf("expect error: %v, actual: %v", bcerrors.ErrPeerGenesisMismatch, err)
+	}
+	if serv.GetNode("localhost:5002") != nil {
+		t.Fatalf("the node of the refused handshake should not be added")
+	}
+}
+
+func Test_IsHandshaked(t *testing.T) {
+	serv := service.NewNodeService("localhost:5000", nil)
+	serv.AddNodes(&model.Node{Addr: "localhost:5001", Handshaked: true}, &model.Node{Addr: "localhost:5002"})
+
+	if !serv.IsHandshaked("localhost:5001") {
+		t.Fatalf("the node localhost:5001 should be handshaked")
+	}
+	for _, addr := range []string{"localhost:5002", "localhost:5003", ""} {
+		if serv.IsHandshaked(addr) {
+			t.Fatalf("the node %q should not be handshaked", addr)
+		}
+	}
+}
+
+type InvChannel chan *protocol.InvReq
+type MakeClient func(InvChannel) client.IBitcoinClient
 
-func generateNodes(n, start int, channels map[string]TxChannel, makeclient MakeClient) []*model.Node {
+func generateNodes(n, start int, channels map[string]InvChannel, makeclient MakeClient) []*model.Node {
 	nodes := make([]*model.Node, n)
 
 	for i := 0; i < n; i++ {
 		addr := fmt.Sprintf("localhost:%d", start+i)
-		channels[addr] = make(chan *protocol.TransactionReq, 1)
+		channels[addr] = make(chan *protocol.InvReq, 1)
 		nodes[i] = &model.Node{
-			Addr:   addr,
-			Client: makeclient(channels[addr]),
+			Addr:       addr,
+			Client:     makeclient(channels[addr]),
+			Handshaked: true,
+			Features:   model.Features,
 		}
 	}
 
 	return nodes
 }
 
-func checkNodes(channels map[string]TxChannel, endpoint string) (int, error) {
+func checkNodes(channels map[string]InvChannel, endpoint string, hash []byte) (int, error) {
 	n := 0
+	size := len(channels) + 1
+	if size > service.MaxBroadcastNodes+1 {
+		size = service.MaxBroadcastNodes + 1
+	}
 	for _, channel := range channels {
 		select {
 		case req := <-channel:
-			if len(req.Nodes) != service.MaxBroadcastNodes+1 {
-				return n, fmt.Errorf("the nodes size of transaction request are invalid, expect: %v, actual: %v", service.MaxBroadcastNodes+1, len(req.Nodes))
+			if len(req.Items) != 1 || !bytes.Equal(req.Items[0].Hash, hash) {
+				return n, fmt.Errorf("the items of inventory request are invalid: %v", req.Items)
+			}
+
+			if req.Node != endpoint {
+				return n, fmt.Errorf("the node of inventory request are invalid, expect: %v, actual: %v", endpoint, req.Node)
+			}
+
+			if len(req.Nodes) != size {
+				return n, fmt.Errorf("the nodes size of inventory request are invalid, expect: %v, actual: %v", size, len(req.Nodes))
 			}
 
 			if req.Nodes[0] != endpoint {
-				return n, fmt.Errorf("the first node of transaction request are invalid, expect: %v, actual: %v", endpoint, req.Nodes[0])
+				return n, fmt.Errorf("the first node of inventory request are invalid, expect: %v, actual: %v", endpoint, req.Nodes[0])
 			}
 
 			addrMap := make(map[string]string)
@@ -269,18 +438,64 @@ func checkNodes(channels map[string]TxChannel, endpoint string) (int, error) {
 	return n, nil
 }
 
+// TestQueryClient stubs the handshake and query methods of client.IBitcoinClient
+type TestQueryClient struct{}
+
+func (cli *TestQueryClient) Handshake(req *protocol.HandshakeReq) (*protocol.HandshakeReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetData(req *protocol.GetDataReq) (*protocol.GetDataReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetHeaders(req *protocol.GetHeadersReq) (*protocol.GetHeadersReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetChainInfo(req *protocol.GetChainInfoReq) (*protocol.GetChainInfoReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetBlockByHash(req *protocol.GetBlockByHashReq) (*protocol.BlockReq, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetBlockByNumber(req *protocol.GetBlockByNumberReq) (*protocol.BlockReq, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetTransaction(req *protocol.GetTransactionReq) (*protocol.GetTransactionReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) GetBalance(req *protocol.GetBalanceReq) (*protocol.GetBalanceReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) ListUnspent(req *protocol.ListUnspentReq) (*protocol.ListUnspentReply, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cli *TestQueryClient) ListChainTips(req *protocol.ListChainTipsReq) (*protocol.ListChainTipsReply, error) {
+	return nil, errors.New("not implemented")
+}
+
 type TestBitcoinClient struct {
-	txChannel    chan *protocol.TransactionReq
-	blockChannel chan *protocol.BlockReq
+	TestQueryClient
+	invChannel chan *protocol.InvReq
+}
+
+func (client *TestBitcoinClient) Inv(req *protocol.InvReq) (*protocol.InvReply, error) {
+	client.invChannel <- req
+	return &protocol.InvReply{Result: true}, nil
 }
 
 func (client *TestBitcoinClient) SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
-	client.txChannel <- req
 	return &protocol.TransactionReply{Result: true}, nil
 }
 
 func (client *TestBitcoinClient) SendBlock(req *protocol.BlockReq) (*protocol.BlockReply, error) {
-	// client.channel <- req
 	return &protocol.BlockReply{Result: true}, nil
 }
 
@@ -288,19 +503,32 @@ func (cli *TestBitcoinClient) GetBlocks(req *protocol.GetBlocksReq) (*protocol.G
 	return nil, errors.New("not implemented")
 }
 
+// HandshakeBitcoinClient replies the handshake
+type HandshakeBitcoinClient struct {
+	TestBitcoinClient
+	reply *protocol.HandshakeReply
+}
+
+func (cli *HandshakeBitcoinClient) Handshake(req *protocol.HandshakeReq) (*protocol.HandshakeReply, error) {
+	return cli.reply, nil
+}
+
 type FailedBitcoinClient struct {
-	txChannel    chan *protocol.TransactionReq
-	blockChannel chan *protocol.BlockReq
+	TestQueryClient
+	invChannel chan *protocol.InvReq
+}
+
+func (client *FailedBitcoinClient) Inv(req *protocol.InvReq) (*protocol.InvReply, error) {
+	client.invChannel <- req
+	return &protocol.InvReply{Result: false}, errors.New("send inv failed")
 }
 
 func (client *FailedBitcoinClient) SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
-	client.txChannel <- req
 	return &protocol.TransactionReply{Result: false}, errors.New("send tx failed")
 }
 
 func (client *FailedBitcoinClient) SendBlock(req *protocol.BlockReq) (*protocol.BlockReply, error) {
-	// client.channel <- req
-	return &protocol.BlockReply{Result: false}, errors.New("send tx failed")
+	return &protocol.BlockReply{Result: false}, errors.New("send block failed")
 }
 
 func (cli *FailedBitcoinClient) GetBlocks(req *protocol.GetBlocksReq) (*protocol.GetBlocksReply, error) {
@@ -308,32 +536,29 @@ func (cli *FailedBitcoinClient) GetBlocks(req *protocol.GetBlocksReq) (*protocol
 }
 
 type ProbablyFailedBitcoinClient struct {
-	txChannel    chan *protocol.TransactionReq
-	blockChannel chan *protocol.BlockReq
-	m            int
-	n            int
+	TestQueryClient
+	invChannel chan *protocol.InvReq
+	m          int
+	n          int
 }
 
-func (client *ProbablyFailedBitcoinClient) SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
+func (client *ProbablyFailedBitcoinClient) Inv(req *protocol.InvReq) (*protocol.InvReply, error) {
 	client.m++
 	if client.m == client.n {
 		client.m = 0
-		return &protocol.TransactionReply{Result: false}, errors.New("send tx failed")
+		return &protocol.InvReply{Result: false}, errors.New("send inv failed")
 	} else {
-		client.txChannel <- req
-		return &protocol.TransactionReply{Result: true}, nil
+		client.invChannel <- req
+		return &protocol.InvReply{Result: true}, nil
 	}
 }
 
+func (client *ProbablyFailedBitcoinClient) SendTx(req *protocol.TransactionReq) (*protocol.TransactionReply, error) {
+	return &protocol.TransactionReply{Result: true}, nil
+}
+
 func (client *ProbablyFailedBitcoinClient) SendBlock(req *protocol.BlockReq) (*protocol.BlockReply, error) {
-	client.m++
-	if client.m == client.n {
-		client.m = 0
-		return &protocol.BlockReply{Result: false}, errors.New("send tx failed")
-	} else {
-		// client.channel <- req
-		return &protocol.BlockReply{Result: true}, nil
-	}
+	return &protocol.BlockReply{Result: true}, nil
 }
 
 func (cli *ProbablyFailedBitcoinClient) GetBlocks(req *protocol.GetBlocksReq) (*protocol.GetBlocksReply, error) {
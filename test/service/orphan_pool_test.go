@@ -0,0 +1,138 @@
+package service
+
+import (
+	"Bitcoin/src/model"
+	"Bitcoin/src/service"
+	"Bitcoin/test"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_OrphanPool_PopChildren(t *testing.T) {
+	cfg := newConfig()
+	parent := test.NewBlock(1, 10, nil)
+	child := newNextBlock(cfg, parent)
+	sibling := newNextBlock(cfg, parent)
+	grandchild := newNextBlock(cfg, child)
+
+	pool := service.NewOrphanBlockPool()
+	for _, block := range []*model.Block{child, sibling, grandchild} {
+		if !pool.Put(block, "peer") {
+			t.Fatalf("put orphan %x failed", block.Hash)
+		}
+	}
+	if pool.Put(child, "peer") {
+		t.Fatalf("the orphan should not be put again")
+	}
+
+	children := pool.PopChildren(parent.Hash)
+	if len(children) != 2 || !bytes.Equal(children[0].Item.Hash, child.Hash) || !bytes.Equal(children[1].Item.Hash, sibling.Hash) {
+		t.Fatalf("expect the children of the parent")
+	}
+	if pool.Has(child.Hash) || !pool.Has(grandchild.Hash) || pool.Len() != 1 {
+		t.Fatalf("only the popped children should be removed")
+	}
+}
+
+func Test_OrphanPool_Peer_Limit(t *testing.T) {
+	pool := service.NewOrphanBlockPool()
+	for i := 0; i < service.MaxOrphanBlocksPerPeer; i++ {
+		if !pool.Put(test.NewBlock(2, 1, nil), "peer") {
+			t.Fatalf("put orphan %d failed", i)
+		}
+	}
+
+	if pool.Put(test.NewBlock(2, 1, nil), "peer") {
+		t.Fatalf("the peer should not put more than %d orphans", service.MaxOrphanBlocksPerPeer)
+	}
+	if !pool.Put(test.NewBlock(2, 1, nil), "other") {
+		t.Fatalf("the other peer should put the orphan")
+	}
+
+	// the peer can put again after its orphans are connected
+	pool.PopChildren(test.NewBlock(1, 1, nil).Prevhash)
+	if !pool.Put(test.NewBlock(2, 1, nil), "peer") {
+		t.Fatalf("the peer should put the orphan after the orphans are connected")
+	}
+}
+
+func Test_OrphanPool_Evict_When_Full(t *testing.T) {
+	pool := service.NewOrphanBlockPool()
+	first := newOrphan(0)
+	pool.Put(first, "peer0")
+	for i := 1; i <= service.MaxOrphanBlocks; i++ {
+		pool.Put(newOrphan(i), fmt.Sprintf("peer%d", i))
+	}
+
+	if pool.Len() != service.MaxOrphanBlocks {
+		t.Fatalf("expect orphans: %d, actual: %d", service.MaxOrphanBlocks, pool.Len())
+	}
+	if pool.Has(first.Hash) {
+		t.Fatalf("the oldest orphan should be evicted")
+	}
+}
+
+func Test_OrphanPool_Expire(t *testing.T) {
+	pool := service.NewOrphanBlockPool()
+	orphan := newOrphan(0)
+	pool.Put(orphan, "peer")
+
+	pool.Expire(time.Now())
+	if !pool.Has(orphan.Hash) {
+		t.Fatalf("the orphan should not expire yet")
+	}
+
+	pool.Expire(time.Now().Add(service.OrphanBlockExpiry + time.Second))
+	if pool.Has(orphan.Hash) || pool.Len() != 0 {
+		t.Fatalf("the orphan should expire")
+	}
+	if pool.Take(orphan.Hash) != nil {
+		t.Fatalf("the expired orphan should not be taken")
+	}
+}
+
+func Test_OrphanPool_PopChildren_Of_Parents(t *testing.T) {
+	parent, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
+	other, _ := newTransactionPair(10, 8, time.Minute, nil, []byte{})
+	// the child of both parents, it waits for the other once though it spends the other twice
+	child := newOrphanTx(parent, other, other)
+
+	pool := service.NewOrphanTxPool()
+	if !pool.Put(tx, "peer") || !pool.Put(child, "peer") {
+		t.Fatalf("put orphans failed")
+	}
+
+	orphans := pool.PopChildren(other.Hash)
+	if len(orphans) != 1 || !bytes.Equal(orphans[0].Item.Hash, child.Hash) || orphans[0].Addr != "peer" {
+		t.Fatalf("expect the child of the other parent")
+	}
+
+	// the popped child is not waiting for the first parent any more
+	orphans = pool.PopChildren(parent.Hash)
+	if len(orphans) != 1 || !bytes.Equal(orphans[0].Item.Hash, tx.Hash) {
+		t.Fatalf("expect the child of the parent")
+	}
+	if pool.Len() != 0 {
+		t.Fatalf("expect orphans: %d, actual: %d", 0, pool.Len())
+	}
+}
+
+// newOrphan makes a block with a distinct unknown parent
+func newOrphan(i int) *model.Block {
+	block := test.NewBlock(2, 1, []byte(fmt.Sprintf("parent%d", i)))
+	findHash(block)
+	return block
+}
+
+// newOrphanTx makes a transaction spending the first outputs of the parents
+func newOrphanTx(parents ...*model.Transaction) *model.Transaction {
+	_, pubkey := test.NewKeys()
+	tx := &model.Transaction{Outs: newOuts(pubkey, 1)}
+	for _, parent := range parents {
+		tx.Ins = append(tx.Ins, newIn(parent, 0))
+	}
+	formalizeTx(tx)
+	return tx
+}
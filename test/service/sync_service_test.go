@@ -0,0 +1,255 @@
+package service
+
+import (
+	bcerrors "Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"Bitcoin/src/protocol"
+	"Bitcoin/src/service"
+	"bytes"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_SyncBlocks_Headers_First(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 6)
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks[:3], newPeerClient(blocks))
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+	checkSynced(t, blockService, chainService, blocks)
+}
+
+func Test_SyncBlocks_No_More_Work(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 4)
+	fork := newNextBlock(cfg, blocks[1])
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks, newPeerClient(append(blocks[:2:2], fork)))
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+
+	// the header is kept, but the body of the weaker branch is not downloaded
+	if header, _ := blockService.GetHeader(fork.Hash); header == nil {
+		t.Fatalf("the header should be synced")
+	}
+	if block, _ := blockService.GetBlock(fork.Hash, false); block != nil {
+		t.Fatalf("the block should not be synced")
+	}
+	if !bytes.Equal(chainService.GetMainChain().LastBlockHash, blocks[3].Hash) {
+		t.Fatalf("the main chain should not be changed")
+	}
+}
+
+func Test_SyncBlocks_Parallel(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 2+3*service.MaxBlocksInFlightPerPeer)
+	peers := []*PeerBitcoinClient{newPeerClient(blocks), newPeerClient(blocks), newPeerClient(blocks)}
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks[:2], peers...)
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+	checkSynced(t, blockService, chainService, blocks)
+
+	for i, peer := range peers {
+		if peer.requests.Load() == 0 {
+			t.Fatalf("the blocks should be downloaded from peer%d too", i)
+		}
+	}
+}
+
+func Test_SyncBlocks_Reassign_Timeout(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 2+2*service.MaxBlocksInFlightPerPeer)
+	stalled := newPeerClient(blocks)
+	stalled.stall = make(chan struct{})
+	defer close(stalled.stall)
+
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks[:2], newPeerClient(blocks), stalled)
+	syncService.SetDownloadTimeout(100 * time.Millisecond)
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+	checkSynced(t, blockService, chainService, blocks)
+
+	// the stalled peer is dropped after the first timeout
+	if stalled.requests.Load() != 1 {
+		t.Fatalf("expect requests to the stalled peer: %d, actual: %d", 1, stalled.requests.Load())
+	}
+}
+
+func Test_SyncBlocks_Reassign_Missing(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 2+2*service.MaxBlocksInFlightPerPeer)
+	// the peer has the headers, but only the first half of the blocks
+	partial := newPeerClient(blocks)
+	partial.missing = make(map[string]bool)
+	for _, block := range blocks[len(blocks)/2:] {
+		partial.missing[string(block.Hash)] = true
+	}
+
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks[:2], partial, newPeerClient(blocks))
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+	checkSynced(t, blockService, chainService, blocks)
+}
+
+func Test_SyncBlocks_Download_Failed(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 6)
+	partial := newPeerClient(blocks)
+	partial.missing = map[string]bool{string(blocks[4].Hash): true}
+
+	blockService, chainService, syncService := newSyncService(service.NewOrphanBlockPool(), blocks[:2], partial)
+
+	err := syncService.SyncBlocks("peer0")
+	if err != bcerrors.ErrBlockDownloadFailed {
+		t.Fatalf("expect error: %v, actual: %v", bcerrors.ErrBlockDownloadFailed, err)
+	}
+
+	// the blocks before the missing one are still connected in order
+	if !bytes.Equal(chainService.GetMainChain().LastBlockHash, blocks[3].Hash) {
+		t.Fatalf("the main chain should end with block %d", blocks[3].Number)
+	}
+	if block, _ := blockService.GetBlock(blocks[5].Hash, false); block != nil {
+		t.Fatalf("the block after the missing one should not be connected")
+	}
+}
+
+func Test_SyncBlocks_Orphans_Not_Downloaded(t *testing.T) {
+	cfg := newConfig()
+	blocks := newChain(cfg, 6)
+	orphanPool := service.NewOrphanBlockPool()
+	for _, block := range blocks[3:] {
+		orphanPool.Put(block, "peer0")
+	}
+
+	peer := newPeerClient(blocks)
+	blockService, chainService, syncService := newSyncService(orphanPool, blocks[:2], peer)
+
+	if err := syncService.SyncBlocks("peer0"); err != nil {
+		t.Fatalf("sync blocks failed: %v", err)
+	}
+	checkSynced(t, blockService, chainService, blocks)
+
+	if peer.requests.Load() != 1 || orphanPool.Len() != 0 {
+		t.Fatalf("only the parent of the orphans should be downloaded")
+	}
+}
+
+// newSyncService syncs the local blocks from the peers, the headers are synced from peer0
+func newSyncService(orphanPool *service.OrphanPool[*model.Block], local []*model.Block, peers ...*PeerBitcoinClient) (*service.BlockService, *service.ChainService, *service.SyncService) {
+	cfg := newConfig()
+	db := newBlockDB(local...)
+	blockService := service.NewBlockService(cfg, db)
+	chainService := service.NewChainService(db, map[string]*model.Out{})
+	for _, block := range local {
+		applyChain(blockService, chainService, block)
+	}
+
+	nodeService := service.NewNodeService("local", []string{})
+	for i, peer := range peers {
+		node := model.NewNode(fmt.Sprintf("peer%d", i), peer)
+		node.Handshaked = true
+		node.Height = peer.blocks[len(peer.blocks)-1].Number
+		nodeService.AddNodes(node)
+	}
+
+	addBlockFunc := func(block *model.Block) error {
+		if err := blockService.Validate(block); err != nil {
+			return err
+		}
+		if err := blockService.SaveBlock(block); err != nil {
+			return err
+		}
+		applyChain(blockService, chainService, block)
+		return nil
+	}
+	return blockService, chainService, service.NewSyncService(chainService, nodeService, blockService, orphanPool, addBlockFunc)
+}
+
+func applyChain(blockService *service.BlockService, chainService *service.ChainService, block *model.Block) {
+	index, err := blockService.GetIndex(block.Hash)
+	if err != nil || index == nil {
+		log.Fatalf("get index of %x error: %v", block.Hash, err)
+	}
+	chainService.ApplyChain(index)
+}
+
+func checkSynced(t *testing.T, blockService *service.BlockService, chainService *service.ChainService, blocks []*model.Block) {
+	for _, block := range blocks {
+		if actual, _ := blockService.GetBlock(block.Hash, false); actual == nil {
+			t.Fatalf("block %d should be synced", block.Number)
+		}
+	}
+
+	tip := blocks[len(blocks)-1]
+	if !bytes.Equal(chainService.GetMainChain().LastBlockHash, tip.Hash) {
+		t.Fatalf("expect main chain: %x, actual: %x", tip.Hash, chainService.GetMainChain().LastBlockHash)
+	}
+}
+
+// PeerBitcoinClient serves the headers and the blocks of its chain, except the missing blocks,
+// the block requests are blocked until stall is closed if it's set
+type PeerBitcoinClient struct {
+	TestBitcoinClient
+	blockService *service.BlockService
+	blocks       []*model.Block
+	missing      map[string]bool
+	stall        chan struct{}
+	requests     atomic.Int32
+}
+
+func newPeerClient(blocks []*model.Block) *PeerBitcoinClient {
+	return &PeerBitcoinClient{
+		blockService: service.NewBlockService(newConfig(), newBlockDB(blocks...)),
+		blocks:       blocks,
+	}
+}
+
+func (cli *PeerBitcoinClient) GetHeaders(req *protocol.GetHeadersReq) (*protocol.GetHeadersReply, error) {
+	tip := cli.blocks[len(cli.blocks)-1]
+	headers, err := cli.blockService.GetHeaders(tip.Hash, req.Locator, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &protocol.GetHeadersReply{}
+	for _, header := range headers {
+		reply.Headers = append(reply.Headers, model.HeaderTo(header))
+	}
+	return reply, nil
+}
+
+func (cli *PeerBitcoinClient) GetData(req *protocol.GetDataReq) (*protocol.GetDataReply, error) {
+	cli.requests.Add(1)
+	if cli.stall != nil {
+		<-cli.stall
+	}
+
+	reply := &protocol.GetDataReply{}
+	for _, item := range req.Items {
+		if cli.missing[string(item.Hash)] {
+			continue
+		}
+		block, err := cli.blockService.GetBlock(item.Hash, true)
+		if err != nil || block == nil {
+			continue
+		}
+		blockReq, err := model.BlockTo(block)
+		if err != nil {
+			return nil, err
+		}
+		reply.Blocks = append(reply.Blocks, blockReq)
+	}
+	return reply, nil
+}
@@ -8,6 +8,10 @@ import (
 	"Bitcoin/src/service"
 	"Bitcoin/test"
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"log"
 
@@ -64,7 +68,7 @@ func Test_Validate_Time_Too_Early(t *testing.T) {
 	formalizeTx(tx)
 
 	txdb := newBlockDB()
-	utxo := make(map[string]uint64)
+	utxo := make(map[string]*model.Out)
 	service := service.NewTransactionService(txdb, utxo)
 	err := service.ValidateTx(tx, nil)
 	if !errors.Is(err, bcerrors.ErrIdentityTooEarly) {
@@ -81,7 +85,7 @@ func Test_Validate_Ins_Len_Mismatch(t *testing.T) {
 	formalizeTx(tx)
 
 	txdb := newBlockDB()
-	utxo := make(map[string]uint64)
+	utxo := make(map[string]*model.Out)
 	service := service.NewTransactionService(txdb, utxo)
 	err := service.ValidateTx(tx, nil)
 	if !errors.Is(err, bcerrors.ErrInLenMismatch) {
@@ -93,7 +97,7 @@ func Test_Validate_Input_PrevTx_Not_Found(t *testing.T) {
 	_, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
 
 	txdb := newBlockDB()
-	utxo := make(map[string]uint64)
+	utxo := make(map[string]*model.Out)
 	service := service.NewTransactionService(txdb, utxo)
 	err := service.ValidateTx(tx, func(hash []byte) *model.Transaction { return nil })
 	if !errors.Is(err, bcerrors.ErrPrevTxNotFound) {
@@ -123,19 +127,138 @@ func Test_Validate_Input_Time_Too_Late(t *testing.T) {
 	}
 }
 
-func Test_Validate_Input_PrevTx_Out_Chain_Not_Found(t *testing.T) {
+func Test_Validate_Input_PrevOut_Spent(t *testing.T) {
 	prevTx, tx := newTransactionPair(10, 0, time.Minute, []byte{}, []byte{})
 	blockdb := newBlockDB()
 	service := newTransactionService(blockdb)
 
+	// the prev transaction is on chain, but its output is not in utxo
 	err := service.SaveTx(prevTx)
 	if err != nil {
 		t.Fatalf("save prev tx on chain error: %v", err)
 	}
 
 	err = service.ValidateTx(tx, nil)
+	if !errors.Is(err, bcerrors.ErrInDoubleSpend) {
+		t.Fatalf("transaction validate failed, expect: %s, actual %s", bcerrors.ErrInDoubleSpend, err)
+	}
+}
+
+func Test_Validate_Input_Spent_Twice_In_Tx(t *testing.T) {
+	prevPrivkey, prevPubkey := test.NewKeys()
+	prevTx := &model.Transaction{
+		Ins:       []*model.In{},
+		Outs:      newOuts(prevPubkey, 10),
+		Timestamp: time.Now(),
+	}
+	formalizeTx(prevTx)
+
+	tx := &model.Transaction{
+		Ins:       []*model.In{newIn(prevTx, 0), newIn(prevTx, 0)},
+		Outs:      newOuts(prevPubkey, 15),
+		Timestamp: prevTx.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(tx, prevPrivkey, prevPrivkey)
+
+	blockdb := newBlockDB()
+	service := newTransactionService(blockdb, prevTx)
+
+	err := service.ValidateTx(tx, nil)
+	if !errors.Is(err, bcerrors.ErrInDoubleSpend) {
+		t.Fatalf("transaction validate failed, expect: %s, actual %s", bcerrors.ErrInDoubleSpend, err)
+	}
+}
+
+func Test_Validate_On_Chain_Txs_Double_Spend(t *testing.T) {
+	prevPrivkey, prevPubkey := test.NewKeys()
+	_, pubkey := test.NewKeys()
+
+	blockHash, err := cryptography.Hash("block")
 	if err != nil {
-		t.Fatalf("transaction validate failed: %v", err)
+		t.Fatalf("compute hash error: %v", err)
+	}
+
+	prevTx := &model.Transaction{
+		Ins:       []*model.In{},
+		Outs:      newOuts(prevPubkey, 10),
+		Timestamp: time.Now(),
+	}
+	formalizeTx(prevTx)
+
+	txs := make([]*model.Transaction, 0)
+	coinbase, err := model.MakeCoinbaseTx(cryptography.AddressHash(pubkey), 10)
+	if err != nil {
+		t.Fatalf("make coinbase error: %v", err)
+	}
+	coinbase.BlockHash = blockHash
+	txs = append(txs, coinbase)
+
+	for i := 0; i < 2; i++ {
+		tx := &model.Transaction{
+			Ins:       []*model.In{newIn(prevTx, 0)},
+			Outs:      newOuts(pubkey, uint64(10-i)),
+			Timestamp: prevTx.Timestamp.Add(time.Minute),
+			BlockHash: blockHash,
+		}
+		formalizeTx(tx, prevPrivkey)
+		txs = append(txs, tx)
+	}
+
+	blockdb := newBlockDB()
+	service := newTransactionService(blockdb, prevTx)
+
+	err = service.ValidateOnChainTxs(txs, blockHash, 9, true)
+	if !errors.Is(err, bcerrors.ErrInDoubleSpend) {
+		t.Fatalf("transactions validate failed, expect: %s, actual %s", bcerrors.ErrInDoubleSpend, err)
+	}
+}
+
+func Test_Validate_On_Chain_Txs_Reward_Mismatch(t *testing.T) {
+	_, pubkey := test.NewKeys()
+
+	blockHash, err := cryptography.Hash("block")
+	if err != nil {
+		t.Fatalf("compute hash error: %v", err)
+	}
+
+	coinbase, err := model.MakeCoinbaseTx(cryptography.AddressHash(pubkey), 11)
+	if err != nil {
+		t.Fatalf("make coinbase error: %v", err)
+	}
+	coinbase.BlockHash = blockHash
+
+	service := newTransactionService(newBlockDB())
+
+	err = service.ValidateOnChainTxs([]*model.Transaction{coinbase}, blockHash, 10, true)
+	if !errors.Is(err, bcerrors.ErrBlockRewardMismatch) {
+		t.Fatalf("transactions validate failed, expect: %s, actual %s", bcerrors.ErrBlockRewardMismatch, err)
+	}
+}
+
+func Test_Verify_On_Chain_Txs(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	prevTx, tx := newTransactionPair(10, 8, time.Minute, nil, nil)
+
+	coinbase, err := model.MakeCoinbaseTx(cryptography.AddressHash(pubkey), 12)
+	if err != nil {
+		t.Fatalf("make coinbase error: %v", err)
+	}
+	coinbase.BlockHash = tx.BlockHash
+
+	// the saved transactions are verified again without the utxo
+	service := newTransactionService(newBlockDB(), prevTx, tx)
+	txs := []*model.Transaction{coinbase, tx}
+	if err := service.VerifyOnChainTxs(txs, tx.BlockHash, 10); err != nil {
+		t.Fatalf("verify transactions failed: %v", err)
+	}
+
+	if err := service.VerifyOnChainTxs(txs, tx.BlockHash, 9); !errors.Is(err, bcerrors.ErrBlockRewardMismatch) {
+		t.Fatalf("expect error: %v, actual: %v", bcerrors.ErrBlockRewardMismatch, err)
+	}
+
+	tx.Ins[0].PrevOut = nil
+	if err := service.VerifyOnChainTxs(txs, tx.BlockHash, 10); !errors.Is(err, bcerrors.ErrPrevTxNotFound) {
+		t.Fatalf("expect error: %v, actual: %v", bcerrors.ErrPrevTxNotFound, err)
 	}
 }
 
@@ -156,14 +279,15 @@ func Test_Validate_In_Sig_Mismatch(t *testing.T) {
 	}
 	formalizeTx(prevTx)
 
-	in := newIn(privkey, prevTx, 0)
-	in.Signature = []byte{}
+	in := newIn(prevTx, 0)
 
 	tx := &model.Transaction{
 		Ins:       []*model.In{in},
 		Outs:      []*model.Out{},
 		Timestamp: time.Now().Add(time.Minute),
 	}
+	formalizeTx(tx, privkey)
+	in.Signature = []byte{}
 	formalizeTx(tx)
 
 	blockdb := newBlockDB()
@@ -175,6 +299,49 @@ func Test_Validate_In_Sig_Mismatch(t *testing.T) {
 	}
 }
 
+func Test_Validate_In_Sig_Replayed(t *testing.T) {
+	prevTx, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
+	_, pubkey := test.NewKeys()
+
+	// copy the signature to another transaction which spends the same output to another recipient
+	other := &model.Transaction{
+		Ins: []*model.In{{
+			PrevHash:  tx.Ins[0].PrevHash,
+			Index:     tx.Ins[0].Index,
+			Pubkey:    tx.Ins[0].Pubkey,
+			Signature: tx.Ins[0].Signature,
+		}},
+		Outs:      newOuts(pubkey, 8),
+		Timestamp: tx.Timestamp,
+		BlockHash: tx.BlockHash,
+	}
+	formalizeTx(other)
+
+	blockdb := newBlockDB()
+	service := newTransactionService(blockdb, prevTx)
+
+	err := service.ValidateTx(other, nil)
+	if !errors.Is(err, bcerrors.ErrInSigInvalid) {
+		t.Fatalf("transaction validate failed, expect: %s, actual %s", bcerrors.ErrInSigInvalid, err)
+	}
+}
+
+func Test_Validate_In_Pubkey_Mismatch(t *testing.T) {
+	prevTx, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
+	privkey, _ := test.NewKeys()
+
+	// a valid signature of another key can't spend the output locked to the address
+	formalizeTx(tx, privkey)
+
+	blockdb := newBlockDB()
+	service := newTransactionService(blockdb, prevTx)
+
+	err := service.ValidateTx(tx, nil)
+	if !errors.Is(err, bcerrors.ErrInPubkeyMismatch) {
+		t.Fatalf("transaction validate failed, expect: %s, actual %s", bcerrors.ErrInPubkeyMismatch, err)
+	}
+}
+
 func Test_Validate_Outs_Len_Not_Match(t *testing.T) {
 	prevTx, tx := newTransactionPair(10, 8, time.Minute, nil, []byte{})
 
@@ -223,6 +390,52 @@ func Test_Validate_Output_Value_Too_Large(t *testing.T) {
 	}
 }
 
+func Test_Validate_In_Pubkey_Not_ECDSA(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("generate rsa key error: %v", err)
+	}
+	x509Encoded, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		log.Fatalf("marshal rsa key error: %v", err)
+	}
+	rsaPubkey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: x509Encoded})
+
+	blockHash, err := cryptography.Hash("block")
+	if err != nil {
+		log.Fatalf("compute hash error: %v", err)
+	}
+
+	// the outputs locked to the garbage pubkeys can't be spent, and the peer sending them can't crash the node
+	for _, pubkey := range [][]byte{[]byte("not a pem"), rsaPubkey} {
+		prevTx := &model.Transaction{
+			Ins:       []*model.In{},
+			Outs:      newOuts(pubkey, 10),
+			Timestamp: time.Now(),
+			BlockHash: blockHash,
+		}
+		formalizeTx(prevTx)
+
+		in := newIn(prevTx, 0)
+		in.Pubkey = pubkey
+		in.Signature = []byte("signature")
+		tx := &model.Transaction{
+			Ins:       []*model.In{in},
+			Outs:      []*model.Out{},
+			Timestamp: time.Now().Add(time.Minute),
+		}
+		formalizeTx(tx)
+
+		blockdb := newBlockDB()
+		service := newTransactionService(blockdb, prevTx)
+
+		err = service.ValidateTx(tx, nil)
+		if !errors.Is(err, bcerrors.ErrInSigInvalid) {
+			t.Fatalf("transaction validate failed, expect: %s, actual %s", bcerrors.ErrInSigInvalid, err)
+		}
+	}
+}
+
 func Test_Validate_Success(t *testing.T) {
 	var totalInput uint64 = 10
 	var totalOutput uint64 = 6
@@ -286,7 +499,7 @@ func newTransactionPair(prevVal, val uint64, duration time.Duration, prevBlockHa
 	formalizeTx(prevTx)
 
 	_, pubkey := test.NewKeys()
-	in := newIn(prevPrivkey, prevTx, 0)
+	in := newIn(prevTx, 0)
 
 	outs := newOuts(pubkey, val)
 	tx := &model.Transaction{
@@ -295,22 +508,16 @@ func newTransactionPair(prevVal, val uint64, duration time.Duration, prevBlockHa
 		Timestamp: now.Add(duration),
 		BlockHash: blockHash,
 	}
-	formalizeTx(tx)
+	formalizeTx(tx, prevPrivkey)
 
 	return prevTx, tx
 }
 
-func newIn(privkey []byte, prevTx *model.Transaction, index uint32) *model.In {
-	sig, err := cryptography.Sign(privkey, prevTx.Hash)
-	if err != nil {
-		log.Fatalf("sign prev hash error: %v", err)
-	}
-
+func newIn(prevTx *model.Transaction, index uint32) *model.In {
 	in := &model.In{
-		PrevHash:  prevTx.Hash,
-		Index:     index,
-		Signature: sig,
-		PrevOut:   prevTx.Outs[index],
+		PrevHash: prevTx.Hash,
+		Index:    index,
+		PrevOut:  prevTx.Outs[index],
 	}
 	return in
 }
@@ -319,15 +526,16 @@ func newOuts(pubkey []byte, val uint64) []*model.Out {
 	outs := make([]*model.Out, 0)
 	if val > 0 {
 		out := &model.Out{
-			Pubkey: pubkey,
-			Value:  val,
+			Address: cryptography.AddressHash(pubkey),
+			Value:   val,
 		}
 		outs = append(outs, out)
 	}
 	return outs
 }
 
-func formalizeTx(tx *model.Transaction) {
+// formalizeTx signs the inputs of tx with privkeys in order and computes the hash
+func formalizeTx(tx *model.Transaction, privkeys ...[]byte) {
 	if tx.InLen == 0 {
 		tx.InLen = uint32(len(tx.Ins))
 	}
@@ -339,6 +547,22 @@ func formalizeTx(tx *model.Transaction) {
 		tx.Timestamp = time.Now()
 	}
 
+	for i, privkey := range privkeys {
+		pubkey, err := cryptography.PublicKeyOf(privkey)
+		if err != nil {
+			log.Fatalf("get public key error: %v", err)
+		}
+		tx.Ins[i].Pubkey = pubkey
+	}
+
+	for i, privkey := range privkeys {
+		sig, err := cryptography.SignContent(privkey, tx)
+		if err != nil {
+			log.Fatalf("sign tx error: %v", err)
+		}
+		tx.Ins[i].Signature = sig
+	}
+
 	hash, err := tx.ComputeHash()
 	if err != nil {
 		log.Fatalf("compute tx hash error: %v", err)
@@ -348,13 +572,17 @@ func formalizeTx(tx *model.Transaction) {
 }
 
 func newTransactionService(blockdb database.IBlockDB, txs ...*model.Transaction) *service.TransactionService {
-	utxo := make(map[string]uint64)
+	utxo := make(map[string]*model.Out)
 	service := service.NewTransactionService(blockdb, utxo)
 	for _, tx := range txs {
 		err := service.SaveTx(tx)
 		if err != nil {
 			log.Fatalf("put tx %x on chain error: %v", tx.Hash, err)
 		}
+		for i, out := range tx.Outs {
+			point := model.OutPoint{Hash: tx.Hash, Index: uint32(i)}
+			utxo[point.Key()] = out
+		}
 	}
 	return service
 }
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"Bitcoin/src/collection"
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"Bitcoin/src/service"
+	"Bitcoin/test"
+	"bytes"
+	"log"
+	"testing"
+	"time"
+)
+
+func Test_ApplyBlock_Spend_Outputs(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newUtxoBlock(coinbase)
+
+	tx := &model.Transaction{
+		Ins:       []*model.In{newIn(coinbase, 0)},
+		Outs:      append(newOuts(pubkey1, 6), newOuts(pubkey, 4)...),
+		Timestamp: coinbase.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(tx, privkey)
+	block2 := newUtxoBlock(newCoinbaseTx(pubkey1, 10), tx)
+
+	utxo := make(map[string]*model.Out)
+	serv := service.NewChainService(test.NewTestBlockDB(), utxo)
+	serv.ApplyBlock(block1)
+
+	if serv.GetUtxo(model.OutPoint{Hash: coinbase.Hash, Index: 0}) == nil {
+		t.Fatalf("the output of coinbase %x should be unspent", coinbase.Hash)
+	}
+
+	serv.ApplyBlock(block2)
+
+	if serv.GetUtxo(model.OutPoint{Hash: coinbase.Hash, Index: 0}) != nil {
+		t.Fatalf("the output of coinbase %x should be spent", coinbase.Hash)
+	}
+	for i := range tx.Outs {
+		out := serv.GetUtxo(model.OutPoint{Hash: tx.Hash, Index: uint32(i)})
+		if out != tx.Outs[i] {
+			t.Fatalf("the output %d of transaction %x should be unspent", i, tx.Hash)
+		}
+	}
+	if len(utxo) != 3 {
+		t.Fatalf("expect utxo size: %d, actual: %d", 3, len(utxo))
+	}
+}
+
+func Test_SwitchBlocks_Restore_Outputs(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newUtxoBlock(coinbase)
+
+	tx := &model.Transaction{
+		Ins:       []*model.In{newIn(coinbase, 0)},
+		Outs:      newOuts(pubkey1, 10),
+		Timestamp: coinbase.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(tx, privkey)
+	block2 := newUtxoBlock(newCoinbaseTx(pubkey1, 10), tx)
+
+	otherCoinbase := newCoinbaseTx(pubkey1, 10)
+	otherBlock2 := newUtxoBlock(otherCoinbase)
+
+	utxo := make(map[string]*model.Out)
+	serv := service.NewChainService(test.NewTestBlockDB(), utxo)
+	serv.ApplyBlock(block1)
+	serv.ApplyBlock(block2)
+	serv.SwitchBlocks([]*model.Block{block2}, []*model.Block{otherBlock2})
+
+	if serv.GetUtxo(model.OutPoint{Hash: coinbase.Hash, Index: 0}) == nil {
+		t.Fatalf("the output of coinbase %x should be restored", coinbase.Hash)
+	}
+	if serv.GetUtxo(model.OutPoint{Hash: tx.Hash, Index: 0}) != nil {
+		t.Fatalf("the output of transaction %x should be removed", tx.Hash)
+	}
+	if serv.GetUtxo(model.OutPoint{Hash: otherCoinbase.Hash, Index: 0}) == nil {
+		t.Fatalf("the output of coinbase %x should be unspent", otherCoinbase.Hash)
+	}
+	if len(utxo) != 2 {
+		t.Fatalf("expect utxo size: %d, actual: %d", 2, len(utxo))
+	}
+}
+
+func Test_SwitchBlocks_Restore_From_Undo(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newStoredUtxoBlock("block1", nil, coinbase)
+
+	tx := &model.Transaction{
+		Ins:       []*model.In{newIn(coinbase, 0)},
+		Outs:      newOuts(pubkey1, 10),
+		Timestamp: coinbase.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(tx, privkey)
+	block2 := newStoredUtxoBlock("block2", block1, newCoinbaseTx(pubkey1, 10), tx)
+	otherBlock2 := newStoredUtxoBlock("otherBlock2", block1, newCoinbaseTx(pubkey1, 10))
+
+	// the blocks are read back from the database, so the spent outputs are only known by the undo
+	blockService := service.NewBlockService(newConfig(), test.NewTestBlockDB(block1, block2, otherBlock2))
+	applyBlocks, rollbackBlocks, err := blockService.GetBlocksOfChain(
+		&model.Chain{LastBlockHash: otherBlock2.Hash}, &model.Chain{LastBlockHash: block2.Hash})
+	if err != nil {
+		t.Fatalf("get blocks of chain failed: %v", err)
+	}
+	if len(rollbackBlocks) != 1 || rollbackBlocks[0] == block2 {
+		t.Fatalf("the rolled back block should be read from the database")
+	}
+
+	utxo := make(map[string]*model.Out)
+	serv := service.NewChainService(test.NewTestBlockDB(), utxo)
+	serv.ApplyBlock(block1)
+	serv.ApplyBlock(block2)
+	serv.SwitchBlocks(rollbackBlocks, applyBlocks)
+
+	out := serv.GetUtxo(model.OutPoint{Hash: coinbase.Hash, Index: 0})
+	if out == nil || out.Value != 10 {
+		t.Fatalf("the output of coinbase %x should be restored", coinbase.Hash)
+	}
+	if serv.GetUtxo(model.OutPoint{Hash: tx.Hash, Index: 0}) != nil {
+		t.Fatalf("the output of transaction %x should be removed", tx.Hash)
+	}
+}
+
+func Test_ValidateBlocks_Fork_Double_Spend(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newUtxoBlock(coinbase)
+	block2 := newUtxoBlock(newCoinbaseTx(pubkey1, 10))
+
+	// the fork spends the output of coinbase twice, each block alone is fine on a side chain
+	spend := func(minutes time.Duration) *model.Transaction {
+		tx := &model.Transaction{
+			Ins:       []*model.In{newIn(coinbase, 0)},
+			Outs:      newOuts(pubkey1, 10),
+			Timestamp: coinbase.Timestamp.Add(minutes * time.Minute),
+		}
+		formalizeTx(tx, privkey)
+		return tx
+	}
+	otherBlock2 := newUtxoBlock(newCoinbaseTx(pubkey1, 10), spend(1))
+	otherBlock3 := newUtxoBlock(newCoinbaseTx(pubkey1, 10), spend(2))
+
+	utxo := make(map[string]*model.Out)
+	serv := service.NewChainService(test.NewTestBlockDB(), utxo)
+	serv.ApplyBlock(block1)
+	serv.ApplyBlock(block2)
+
+	rollbackBlocks, applyBlocks := []*model.Block{block2}, []*model.Block{otherBlock2, otherBlock3}
+	invalid, err := serv.ValidateBlocks(rollbackBlocks, applyBlocks)
+	if err != errors.ErrInDoubleSpend {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrInDoubleSpend, err)
+	}
+	if invalid != otherBlock3 {
+		t.Fatalf("the second block of the fork should be invalid")
+	}
+
+	// the utxo is not changed by the failed switch
+	if err := serv.SwitchBlocks(rollbackBlocks, applyBlocks); err != errors.ErrInDoubleSpend {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrInDoubleSpend, err)
+	}
+	if serv.GetUtxo(model.OutPoint{Hash: coinbase.Hash, Index: 0}) == nil || len(utxo) != 2 {
+		t.Fatalf("the utxo should be kept at block2, actual size: %d", len(utxo))
+	}
+
+	if invalid, err := serv.ValidateBlocks(rollbackBlocks, applyBlocks[:1]); invalid != nil || err != nil {
+		t.Fatalf("the first block of the fork should be valid, actual: %v", err)
+	}
+}
+
+func Test_GetBlocksOfChain_Undo_Not_Found(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	block1 := newStoredUtxoBlock("block1", nil, newCoinbaseTx(pubkey, 10))
+	block2 := newStoredUtxoBlock("block2", block1, newCoinbaseTx(pubkey, 10))
+	otherBlock2 := newStoredUtxoBlock("otherBlock2", block1, newCoinbaseTx(pubkey, 10))
+
+	// the block saved without the undo, e.g. by the older version
+	db := test.NewTestBlockDB(block1, otherBlock2)
+	db.(*database.BlockDB).Save([]byte(database.BlockTable), block2.Hash, block2)
+	db.(*database.BlockDB).Save([]byte(database.BlockContentTable), block2.RootHash, block2.Body)
+	db.SaveTx(block2.GetTxs()[0])
+
+	blockService := service.NewBlockService(newConfig(), db)
+	_, _, err := blockService.GetBlocksOfChain(&model.Chain{LastBlockHash: otherBlock2.Hash}, &model.Chain{LastBlockHash: block2.Hash})
+	if err != errors.ErrBlockUndoNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockUndoNotFound, err)
+	}
+}
+
+func Test_GetBlocksOfChain_Block_Not_Found(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	block1 := newStoredUtxoBlock("block1", nil, newCoinbaseTx(pubkey, 10))
+	block2 := newStoredUtxoBlock("block2", block1, newCoinbaseTx(pubkey, 10))
+	block3 := newStoredUtxoBlock("block3", block2, newCoinbaseTx(pubkey, 10))
+
+	// the unknown tip, and the chain missing the block in the middle
+	blockService := service.NewBlockService(newConfig(), test.NewTestBlockDB(block1, block3))
+	_, _, err := blockService.GetBlocksOfChain(&model.Chain{LastBlockHash: block1.Hash}, &model.Chain{LastBlockHash: []byte("unknown")})
+	if err != errors.ErrBlockNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockNotFound, err)
+	}
+	_, _, err = blockService.GetBlocksOfChain(&model.Chain{LastBlockHash: block3.Hash}, &model.Chain{LastBlockHash: block1.Hash})
+	if err != errors.ErrBlockNotFound {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrBlockNotFound, err)
+	}
+}
+
+func Test_ConnectBlock_Load(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newStoredUtxoBlock("block1", nil, coinbase)
+
+	tx := &model.Transaction{
+		Ins:       []*model.In{newIn(coinbase, 0)},
+		Outs:      newOuts(pubkey1, 10),
+		Timestamp: coinbase.Timestamp.Add(time.Minute),
+	}
+	formalizeTx(tx, privkey)
+	block2 := newStoredUtxoBlock("block2", block1, newCoinbaseTx(pubkey1, 10), tx)
+
+	db := test.NewTestBlockDB(block1)
+	serv := service.NewChainService(db, make(map[string]*model.Out))
+	if err := serv.ApplyBlock(block1); err != nil {
+		t.Fatalf("apply block failed: %v", err)
+	}
+	if err := serv.ConnectBlock(block2); err != nil {
+		t.Fatalf("connect block failed: %v", err)
+	}
+	if block, _ := db.GetBlock(block2.Hash, false); block == nil {
+		t.Fatalf("the connected block should be saved")
+	}
+
+	// the utxo is restored from the database without applying the blocks
+	utxo := make(map[string]*model.Out)
+	best, err := service.NewChainService(db, utxo).Load()
+	if err != nil {
+		t.Fatalf("load utxo failed: %v", err)
+	}
+	if !bytes.Equal(best, block2.Hash) {
+		t.Fatalf("expect best block: %x, actual: %x", block2.Hash, best)
+	}
+	if len(utxo) != 2 || utxo[model.OutPoint{Hash: tx.Hash, Index: 0}.Key()] == nil {
+		t.Fatalf("the utxo should be loaded, actual: %v", utxo)
+	}
+	if _, spent := utxo[model.OutPoint{Hash: coinbase.Hash, Index: 0}.Key()]; spent {
+		t.Fatalf("the spent output should be removed")
+	}
+}
+
+func Test_SwitchBlocks_Reset_Persist(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	block1 := newStoredUtxoBlock("block1", nil, newCoinbaseTx(pubkey, 10))
+	block2 := newStoredUtxoBlock("block2", block1, newCoinbaseTx(pubkey, 5))
+	otherBlock2 := newStoredUtxoBlock("otherBlock2", block1, newCoinbaseTx(pubkey, 7))
+
+	db := test.NewTestBlockDB(block1, block2, otherBlock2)
+	serv := service.NewChainService(db, make(map[string]*model.Out))
+	serv.ApplyBlock(block1)
+	serv.ApplyBlock(block2)
+	if err := serv.SwitchBlocks([]*model.Block{block2}, []*model.Block{otherBlock2}); err != nil {
+		t.Fatalf("switch blocks failed: %v", err)
+	}
+
+	utxo, best, err := db.LoadUtxo()
+	if err != nil {
+		t.Fatalf("load utxo failed: %v", err)
+	}
+	if !bytes.Equal(best, otherBlock2.Hash) || len(utxo) != 2 {
+		t.Fatalf("expect best block: %x with %d outputs, actual: %x with %d outputs", otherBlock2.Hash, 2, best, len(utxo))
+	}
+
+	if err := serv.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	utxo, best, err = db.LoadUtxo()
+	if err != nil || best != nil || len(utxo) != 0 {
+		t.Fatalf("the utxo should be cleared, best block: %x, outputs: %d, error: %v", best, len(utxo), err)
+	}
+}
+
+func Test_GetBalance(t *testing.T) {
+	_, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	utxo := make(map[string]*model.Out)
+	serv := service.NewChainService(test.NewTestBlockDB(), utxo)
+	serv.ApplyBlock(newUtxoBlock(newCoinbaseTx(pubkey, 10)))
+	serv.ApplyBlock(newUtxoBlock(newCoinbaseTx(pubkey, 5)))
+	serv.ApplyBlock(newUtxoBlock(newCoinbaseTx(pubkey1, 7)))
+
+	balance, n := serv.GetBalance(cryptography.AddressHash(pubkey))
+	if balance != 15 || n != 2 {
+		t.Fatalf("expect balance: %d of %d outputs, actual: %d of %d outputs", 15, 2, balance, n)
+	}
+}
+
+func Test_SwitchBlocks_Notify_Listeners(t *testing.T) {
+	_, pubkey := test.NewKeys()
+
+	block1 := newUtxoBlock(newCoinbaseTx(pubkey, 10))
+	block2 := newUtxoBlock(newCoinbaseTx(pubkey, 5))
+	otherBlock2 := newUtxoBlock(newCoinbaseTx(pubkey, 7))
+
+	serv := service.NewChainService(test.NewTestBlockDB(), make(map[string]*model.Out))
+	listener := &TestBlockListener{}
+	serv.AddListener(listener)
+	serv.ApplyBlock(block1)
+	serv.ApplyBlock(block2)
+	serv.SwitchBlocks([]*model.Block{block2}, []*model.Block{otherBlock2})
+
+	expect := []string{"apply", "apply", "rollback", "apply"}
+	if len(listener.events) != len(expect) {
+		t.Fatalf("expect events: %v, actual: %v", expect, listener.events)
+	}
+	for i := range expect {
+		if listener.events[i] != expect[i] {
+			t.Fatalf("expect events: %v, actual: %v", expect, listener.events)
+		}
+	}
+	if listener.blocks[2] != block2 || listener.blocks[3] != otherBlock2 {
+		t.Fatalf("the switched blocks should be notified in order")
+	}
+}
+
+type TestBlockListener struct {
+	events []string
+	blocks []*model.Block
+}
+
+func (l *TestBlockListener) ApplyBlock(block *model.Block) {
+	l.events = append(l.events, "apply")
+	l.blocks = append(l.blocks, block)
+}
+
+func (l *TestBlockListener) RollbackBlock(block *model.Block) {
+	l.events = append(l.events, "rollback")
+	l.blocks = append(l.blocks, block)
+}
+
+func newCoinbaseTx(pubkey []byte, val uint64) *model.Transaction {
+	tx, err := model.MakeCoinbaseTx(cryptography.AddressHash(pubkey), val)
+	if err != nil {
+		log.Fatalf("make coinbase error: %v", err)
+	}
+	return tx
+}
+
+func newUtxoBlock(txs ...*model.Transaction) *model.Block {
+	tree, err := collection.BuildTree(txs)
+	if err != nil {
+		log.Fatalf("build merkle tree error: %v", err)
+	}
+	return &model.Block{Body: tree}
+}
+
+// newStoredUtxoBlock makes a block with the fields needed to save it and walk the chain
+func newStoredUtxoBlock(name string, prev *model.Block, txs ...*model.Transaction) *model.Block {
+	block := newUtxoBlock(txs...)
+	block.Hash = []byte(name)
+	block.Number = 1
+	block.RootHash = block.Body.Table[len(block.Body.Table)-1][0].Hash
+	if prev != nil {
+		block.Prevhash = prev.Hash
+		block.Number = prev.Number + 1
+	}
+	return block
+}
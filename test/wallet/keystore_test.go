@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"Bitcoin/src/errors"
+	"Bitcoin/src/wallet"
+	"bytes"
+	"testing"
+)
+
+func Test_OpenKeyStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	ks, err := wallet.OpenKeyStore(dir, "password")
+	if err != nil {
+		t.Fatalf("open keystore error: %v", err)
+	}
+	key, err := ks.NewKey()
+	if err != nil {
+		t.Fatalf("new key error: %v", err)
+	}
+
+	ks, err = wallet.OpenKeyStore(dir, "password")
+	if err != nil {
+		t.Fatalf("reopen keystore error: %v", err)
+	}
+	keys := ks.Keys()
+	if len(keys) != 1 {
+		t.Fatalf("expect keys: %d, actual: %d", 1, len(keys))
+	}
+	if !bytes.Equal(keys[0].Privkey, key.Privkey) || !bytes.Equal(keys[0].Pubkey, key.Pubkey) {
+		t.Fatalf("the reopened key mismatch with the saved one")
+	}
+}
+
+func Test_OpenKeyStore_Wrong_Password(t *testing.T) {
+	dir := t.TempDir()
+
+	ks, err := wallet.OpenKeyStore(dir, "password")
+	if err != nil {
+		t.Fatalf("open keystore error: %v", err)
+	}
+	if _, err := ks.NewKey(); err != nil {
+		t.Fatalf("new key error: %v", err)
+	}
+
+	_, err = wallet.OpenKeyStore(dir, "wrong")
+	if err != errors.ErrKeystorePassword {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrKeystorePassword, err)
+	}
+}
+
+func Test_ImportKey_Duplicate(t *testing.T) {
+	ks, err := wallet.OpenKeyStore(t.TempDir(), "password")
+	if err != nil {
+		t.Fatalf("open keystore error: %v", err)
+	}
+	key, err := ks.NewKey()
+	if err != nil {
+		t.Fatalf("new key error: %v", err)
+	}
+
+	imported, err := ks.ImportKey(key.Privkey)
+	if err != nil {
+		t.Fatalf("import key error: %v", err)
+	}
+	if imported != key || len(ks.Keys()) != 1 {
+		t.Fatalf("the imported key should be the existing one")
+	}
+	if ks.GetKey(key.Address()) != key {
+		t.Fatalf("the key of address should be found")
+	}
+}
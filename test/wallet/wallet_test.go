@@ -0,0 +1,324 @@
+package wallet
+
+import (
+	"Bitcoin/src/collection"
+	"Bitcoin/src/cryptography"
+	"Bitcoin/src/database"
+	"Bitcoin/src/errors"
+	"Bitcoin/src/model"
+	"Bitcoin/src/service"
+	"Bitcoin/src/wallet"
+	"Bitcoin/test"
+	"bytes"
+	"log"
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_ApplyBlock_Add_Coins(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey, 10), newCoinbaseTx(pubkey1, 7)))
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey, 5)))
+
+	if w.Balance() != 15 {
+		t.Fatalf("expect balance: %d, actual: %d", 15, w.Balance())
+	}
+	if len(w.Coins()) != 2 {
+		t.Fatalf("expect coins: %d, actual: %d", 2, len(w.Coins()))
+	}
+}
+
+func Test_ApplyBlock_Spend_Coins(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	w.ApplyBlock(newBlock(nil, coinbase))
+
+	tx := newTx(privkey, coinbase, newOut(pubkey1, 6), newOut(pubkey, 4))
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey1, 10), tx))
+
+	if w.Balance() != 4 {
+		t.Fatalf("expect balance: %d, actual: %d", 4, w.Balance())
+	}
+	coins := w.Coins()
+	if len(coins) != 1 || !bytes.Equal(coins[0].Point.Hash, tx.Hash) || coins[0].Point.Index != 1 {
+		t.Fatalf("the change of transaction %x should be the only coin", tx.Hash)
+	}
+}
+
+func Test_RollbackBlock_Restore_Coins(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	block1 := newBlock(nil, coinbase, newCoinbaseTx(pubkey1, 1))
+	w := newWallet(t, test.NewTestBlockDB(block1), privkey)
+	w.ApplyBlock(block1)
+
+	tx := newTx(privkey, coinbase, newOut(pubkey1, 10))
+	block2 := newBlock(block1, newCoinbaseTx(pubkey, 5), tx)
+	w.ApplyBlock(block2)
+	if w.Balance() != 5 {
+		t.Fatalf("expect balance: %d, actual: %d", 5, w.Balance())
+	}
+
+	// the spent output is found from the database since the input has no PrevOut
+	w.RollbackBlock(block2)
+	if w.Balance() != 10 {
+		t.Fatalf("expect balance: %d, actual: %d", 10, w.Balance())
+	}
+	coins := w.Coins()
+	if len(coins) != 1 || !bytes.Equal(coins[0].Point.Hash, coinbase.Hash) {
+		t.Fatalf("the output of coinbase %x should be restored", coinbase.Hash)
+	}
+}
+
+func Test_Rescan(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+
+	coinbase := newCoinbaseTx(pubkey, 10)
+	coinbase1 := newCoinbaseTx(pubkey1, 1)
+	tx := newTx(privkey, coinbase, newOut(pubkey1, 3), newOut(pubkey, 7))
+	coinbase2 := newCoinbaseTx(pubkey, 5)
+
+	// the utxo after the coinbase is spent by tx, the blocks are not in the database like pruned
+	utxo := make(map[string]*model.Out)
+	for _, utx := range []*model.Transaction{coinbase1, tx, coinbase2} {
+		for i, out := range utx.Outs {
+			utxo[model.OutPoint{Hash: utx.Hash, Index: uint32(i)}.Key()] = out
+		}
+	}
+
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+	if err := w.Rescan(service.NewChainService(test.NewTestBlockDB(), utxo)); err != nil {
+		t.Fatalf("rescan error: %v", err)
+	}
+
+	if w.Balance() != 12 {
+		t.Fatalf("expect balance: %d, actual: %d", 12, w.Balance())
+	}
+	coins := w.Coins()
+	if len(coins) != 2 || !bytes.Equal(coins[0].Point.Hash, tx.Hash) || coins[0].Point.Index != 1 {
+		t.Fatalf("expect coins: %d with the change of transaction %x first, actual: %d", 2, tx.Hash, len(coins))
+	}
+}
+
+func Test_CreateTx_Succeed(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey, 10), newCoinbaseTx(pubkey, 3), newCoinbaseTx(pubkey, 2)))
+
+	tx, err := w.CreateTx(cryptography.AddressHash(pubkey1), 11, 1)
+	if err != nil {
+		t.Fatalf("create tx error: %v", err)
+	}
+
+	if len(tx.Ins) != 2 || tx.InLen != 2 {
+		t.Fatalf("expect inputs: %d, actual: %d", 2, len(tx.Ins))
+	}
+	if len(tx.Outs) != 2 || tx.OutLen != 2 {
+		t.Fatalf("expect outputs: %d, actual: %d", 2, len(tx.Outs))
+	}
+	if !bytes.Equal(tx.Outs[0].Address, cryptography.AddressHash(pubkey1)) || tx.Outs[0].Value != 11 {
+		t.Fatalf("the first output should pay %d to the receiver", 11)
+	}
+	if !bytes.Equal(tx.Outs[1].Address, cryptography.AddressHash(pubkey)) || tx.Outs[1].Value != 1 {
+		t.Fatalf("the second output should be the change %d", 1)
+	}
+
+	hash, err := tx.ComputeHash()
+	if err != nil || !bytes.Equal(hash, tx.Hash) {
+		t.Fatalf("the transaction hash mismatch")
+	}
+	sighash, err := tx.SignatureHash()
+	if err != nil {
+		t.Fatalf("compute signature hash error: %v", err)
+	}
+	for i, in := range tx.Ins {
+		valid, err := cryptography.Verify(pubkey, sighash, in.Signature)
+		if !valid || err != nil {
+			t.Fatalf("the signature of input %d is invalid", i)
+		}
+	}
+
+	// the spent coins are locked until the transaction is on chain
+	if len(w.Coins()) != 1 {
+		t.Fatalf("expect coins: %d, actual: %d", 1, len(w.Coins()))
+	}
+	w.Release(tx)
+	if len(w.Coins()) != 3 {
+		t.Fatalf("expect coins: %d, actual: %d", 3, len(w.Coins()))
+	}
+}
+
+func Test_CreateTx_Exact_Coin(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey, 10), newCoinbaseTx(pubkey, 4)))
+
+	tx, err := w.CreateTx(pubkey1, 3, 1)
+	if err != nil {
+		t.Fatalf("create tx error: %v", err)
+	}
+	if len(tx.Ins) != 1 || len(tx.Outs) != 1 {
+		t.Fatalf("the coin of the exact value should be spent without change")
+	}
+}
+
+func Test_CreateTx_Not_Enough_Values(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	w := newWallet(t, test.NewTestBlockDB(), privkey)
+
+	w.ApplyBlock(newBlock(nil, newCoinbaseTx(pubkey, 10)))
+
+	if _, err := w.CreateTx(pubkey1, 10, 1); err != errors.ErrWalletNotEnoughValues {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrWalletNotEnoughValues, err)
+	}
+}
+
+func Test_BuildTx_Unsorted_Coins(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	key := &wallet.Key{Privkey: privkey, Pubkey: pubkey}
+
+	// the coins listed by the server are not sorted
+	coins := []*wallet.Coin{
+		{Point: model.OutPoint{Hash: []byte("tx1")}, Out: newOut(pubkey, 2), Key: key},
+		{Point: model.OutPoint{Hash: []byte("tx2")}, Out: newOut(pubkey, 10), Key: key},
+		{Point: model.OutPoint{Hash: []byte("tx3")}, Out: newOut(pubkey, 3), Key: key},
+	}
+
+	tx, spent, err := wallet.BuildTx(coins, cryptography.AddressHash(pubkey1), key.Address(), 11, 1)
+	if err != nil {
+		t.Fatalf("build tx error: %v", err)
+	}
+	if len(spent) != 2 || spent[0].Out.Value != 10 || spent[1].Out.Value != 3 {
+		t.Fatalf("the largest coins should be spent first")
+	}
+	if len(tx.Outs) != 2 || tx.Outs[1].Value != 1 || !bytes.Equal(tx.Outs[1].Address, key.Address()) {
+		t.Fatalf("the second output should be the change %d", 1)
+	}
+	if coins[0].Out.Value != 2 {
+		t.Fatalf("the coins of the caller should not be reordered")
+	}
+
+	if _, _, err := wallet.BuildTx(coins, cryptography.AddressHash(pubkey1), key.Address(), 15, 1); err != errors.ErrWalletNotEnoughValues {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrWalletNotEnoughValues, err)
+	}
+}
+
+func Test_BuildTx_Value_Overflow(t *testing.T) {
+	privkey, pubkey := test.NewKeys()
+	_, pubkey1 := test.NewKeys()
+	key := &wallet.Key{Privkey: privkey, Pubkey: pubkey}
+
+	coins := []*wallet.Coin{
+		{Point: model.OutPoint{Hash: []byte("tx1")}, Out: newOut(pubkey, math.MaxUint64-1), Key: key},
+		{Point: model.OutPoint{Hash: []byte("tx2")}, Out: newOut(pubkey, 2), Key: key},
+	}
+
+	// the value plus the fee wraps around to a small target
+	if _, _, err := wallet.BuildTx(coins, cryptography.AddressHash(pubkey1), key.Address(), math.MaxUint64, 1); err != errors.ErrWalletValueOverflow {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrWalletValueOverflow, err)
+	}
+
+	// the total of the coins wraps around before the target is reached
+	if _, _, err := wallet.BuildTx(coins, cryptography.AddressHash(pubkey1), key.Address(), math.MaxUint64-1, 1); err != errors.ErrWalletValueOverflow {
+		t.Fatalf("expect error: %v, actual: %v", errors.ErrWalletValueOverflow, err)
+	}
+}
+
+func newWallet(t *testing.T, blockdb database.IBlockDB, privkey []byte) *wallet.Wallet {
+	ks, err := wallet.OpenKeyStore(t.TempDir(), "password")
+	if err != nil {
+		t.Fatalf("open keystore error: %v", err)
+	}
+	if _, err := ks.ImportKey(privkey); err != nil {
+		t.Fatalf("import key error: %v", err)
+	}
+	return wallet.NewWallet(blockdb, ks)
+}
+
+func newCoinbaseTx(pubkey []byte, val uint64) *model.Transaction {
+	tx, err := model.MakeCoinbaseTx(cryptography.AddressHash(pubkey), val)
+	if err != nil {
+		log.Fatalf("make coinbase error: %v", err)
+	}
+	return tx
+}
+
+func newOut(pubkey []byte, val uint64) *model.Out {
+	return &model.Out{Address: cryptography.AddressHash(pubkey), Value: val}
+}
+
+// newTx spends all the outputs of the prev transaction
+func newTx(privkey []byte, prevTx *model.Transaction, outs ...*model.Out) *model.Transaction {
+	pubkey, err := cryptography.PublicKeyOf(privkey)
+	if err != nil {
+		log.Fatalf("get public key error: %v", err)
+	}
+
+	tx := &model.Transaction{
+		Outs:      outs,
+		OutLen:    uint32(len(outs)),
+		Timestamp: prevTx.Timestamp.Add(time.Minute),
+	}
+	for i := range prevTx.Outs {
+		tx.Ins = append(tx.Ins, &model.In{PrevHash: prevTx.Hash, Index: uint32(i), Pubkey: pubkey})
+	}
+	tx.InLen = uint32(len(tx.Ins))
+
+	for _, in := range tx.Ins {
+		sig, err := cryptography.SignContent(privkey, tx)
+		if err != nil {
+			log.Fatalf("sign tx error: %v", err)
+		}
+		in.Signature = sig
+	}
+
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		log.Fatalf("compute tx hash error: %v", err)
+	}
+	tx.Hash = hash
+	return tx
+}
+
+func newBlock(prevBlock *model.Block, txs ...*model.Transaction) *model.Block {
+	tree, err := collection.BuildTree(txs)
+	if err != nil {
+		log.Fatalf("build merkle tree error: %v", err)
+	}
+
+	block := &model.Block{
+		RootHash: tree.Table[len(tree.Table)-1][0].Hash,
+		Time:     time.Now(),
+		Body:     tree,
+		Number:   1,
+	}
+	if prevBlock != nil {
+		block.Prevhash = prevBlock.Hash
+		block.Number = prevBlock.Number + 1
+	}
+
+	block.Hash, err = block.ComputeHash()
+	if err != nil {
+		log.Fatalf("compute block hash error: %v", err)
+	}
+	for _, tx := range txs {
+		tx.BlockHash = block.Hash
+	}
+	return block
+}